@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Proqpine/probable-memory/backup"
+	"github.com/Proqpine/probable-memory/config"
+)
+
+// backupInterval is how often backups are taken.
+const backupInterval = 24 * time.Hour
+
+// backupDir returns where the backups of the database are kept, and the
+// database's path.
+func backupDir(cfg config.Config) (backup.Dir, string, error) {
+	database := cfg.Database
+	if database == "" {
+		database = defaultDatabase
+	}
+	if isPostgres(database) {
+		return "", "", errors.New("backups are of SQLite databases: back up PostgreSQL with pg_dump")
+	}
+	if cfg.Backup.Dir == "" {
+		return backup.Dir(filepath.Join(filepath.Dir(database), "backups")), database, nil
+	}
+	dir, err := expandHome(cfg.Backup.Dir)
+	return backup.Dir(dir), database, err
+}
+
+// takeBackup backs the database up and deletes the backups the config no
+// longer keeps.
+func takeBackup(ctx context.Context, cfg config.Config, now time.Time) (backup.Backup, error) {
+	dir, database, err := backupDir(cfg)
+	if err != nil {
+		return backup.Backup{}, err
+	}
+	b, err := dir.Create(ctx, database, now)
+	if err != nil {
+		return backup.Backup{}, err
+	}
+	_, err = dir.Prune(cfg.Backup.KeepDaily, cfg.Backup.KeepWeekly)
+	return b, err
+}
+
+// autoBackups reports whether automatic backups are taken: they are on
+// unless disabled, for SQLite databases that exist.
+func autoBackups(cfg config.Config) bool {
+	if cfg.Backup.Disabled {
+		return false
+	}
+	_, database, err := backupDir(cfg)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(database)
+	return err == nil
+}
+
+// backupIfDue takes a backup if the newest is a day old. Commands run too
+// often, from prompts and editor plugins, to back up every time.
+func backupIfDue(ctx context.Context, cfg config.Config, now time.Time) error {
+	if !autoBackups(cfg) {
+		return nil
+	}
+	dir, _, err := backupDir(cfg)
+	if err != nil {
+		return err
+	}
+	backups, err := dir.List()
+	if err != nil {
+		return err
+	}
+	if len(backups) > 0 && now.Sub(backups[0].Time) < backupInterval {
+		return nil
+	}
+	_, err = takeBackup(ctx, cfg, now)
+	return err
+}
+
+// runBackups takes a backup when the TUI starts and then daily until ctx
+// is done, passing failures to report.
+func runBackups(ctx context.Context, cfg config.Config, report func(error)) {
+	if !autoBackups(cfg) {
+		return
+	}
+	ticker := time.NewTicker(backupInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := takeBackup(ctx, cfg, time.Now()); err != nil && ctx.Err() == nil {
+			report(fmt.Errorf("failed to back up the database: %v", err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// backupCommand takes a backup, lists them, or restores one. It runs
+// without opening the store, since a restore replaces the database file.
+func backupCommand(ctx context.Context, cfg config.Config, args []string) error {
+	dir, database, err := backupDir(cfg)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		b, err := takeBackup(ctx, cfg, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to back up: %v", err)
+		}
+		fmt.Printf("Backed up to %s\n", b.Path)
+		return nil
+	}
+
+	switch args[0] {
+	case "list":
+		backups, err := dir.List()
+		if err != nil {
+			return err
+		}
+		if len(backups) == 0 {
+			fmt.Printf("No backups in %s\n", dir)
+			return nil
+		}
+		fmt.Printf("%-15s  %-16s %9s\n", "ID", "TAKEN", "SIZE")
+		for _, b := range backups {
+			fmt.Printf("%-15s  %-16s %9s\n", b.ID, b.Time.Format("2006-01-02 15:04"), formatSize(b.Size))
+		}
+		return nil
+
+	case "restore":
+		fs := flag.NewFlagSet("backup restore", flag.ContinueOnError)
+		force := fs.Bool("force", false, "restore even if the current database cannot be backed up first")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if fs.NArg() != 1 {
+			return errors.New("usage: backup restore [--force] <id>")
+		}
+		b, err := dir.Get(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		if err := backup.Verify(ctx, b); err != nil {
+			return err
+		}
+		// The database being replaced is backed up too, so the restore
+		// can be undone. A damaged one may fail to back up.
+		var before backup.Backup
+		if _, err := os.Stat(database); err == nil {
+			before, err = dir.Create(ctx, database, time.Now())
+			if err != nil && !*force {
+				return fmt.Errorf("failed to back up the current database first: %v; use --force to restore anyway", err)
+			}
+		}
+		if err := backup.Restore(ctx, b, database); err != nil {
+			return fmt.Errorf("failed to restore: %v", err)
+		}
+		fmt.Printf("Restored %s from %s\n", database, b.ID)
+		if before.ID != "" {
+			fmt.Printf("The database as it was is backup %s\n", before.ID)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("usage: backup [list | restore [--force] <id>]")
+	}
+}
+
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f kB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
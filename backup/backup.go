@@ -0,0 +1,279 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// ErrInUse is returned by Restore when something else has the database
+// open.
+var ErrInUse = errors.New("the database is in use: close the TUI and anything else using it first")
+
+// Backup is one copy of the database, named after when it was taken.
+type Backup struct {
+	ID   string
+	Path string
+	Time time.Time
+	Size int64
+}
+
+// idFormat names backups by local time, so they sort by age.
+const idFormat = "20060102-150405"
+
+const ext = ".db"
+
+// Dir is the directory backups are kept in.
+type Dir string
+
+func (d Dir) path(id string) string {
+	return filepath.Join(string(d), id+ext)
+}
+
+// Create copies the SQLite database file into a new backup with VACUUM
+// INTO, which reads a consistent snapshot while others keep writing. The
+// copy is written under a temporary name, so a backup that is listed is
+// complete.
+func (d Dir) Create(ctx context.Context, database string, now time.Time) (Backup, error) {
+	if _, err := os.Stat(database); err != nil {
+		return Backup{}, err
+	}
+	if err := os.MkdirAll(string(d), 0o700); err != nil {
+		return Backup{}, err
+	}
+	// Ids are to the second; a second backup within one is named after
+	// the next free second.
+	id := now.Format(idFormat)
+	path := d.path(id)
+	for {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			break
+		}
+		now = now.Add(time.Second)
+		id = now.Format(idFormat)
+		path = d.path(id)
+	}
+	tmp := path + ".tmp"
+	os.Remove(tmp)
+
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_busy_timeout=5000", database))
+	if err != nil {
+		return Backup{}, err
+	}
+	defer db.Close()
+	if _, err := db.ExecContext(ctx, "vacuum into ?", tmp); err != nil {
+		os.Remove(tmp)
+		return Backup{}, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return Backup{}, err
+	}
+	return d.stat(id)
+}
+
+func (d Dir) stat(id string) (Backup, error) {
+	t, err := time.ParseInLocation(idFormat, id, time.Local)
+	if err != nil {
+		return Backup{}, fmt.Errorf("no backup %q", id)
+	}
+	path := d.path(id)
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return Backup{}, fmt.Errorf("no backup %q", id)
+	}
+	if err != nil {
+		return Backup{}, err
+	}
+	return Backup{ID: id, Path: path, Time: t, Size: info.Size()}, nil
+}
+
+// Get returns the backup with the given id.
+func (d Dir) Get(id string) (Backup, error) {
+	return d.stat(id)
+}
+
+// List returns the backups in the directory, newest first. Other files are
+// ignored.
+func (d Dir) List() ([]Backup, error) {
+	matches, err := filepath.Glob(filepath.Join(string(d), "*"+ext))
+	if err != nil {
+		return nil, err
+	}
+	var backups []Backup
+	for _, m := range matches {
+		b, err := d.stat(strings.TrimSuffix(filepath.Base(m), ext))
+		if err != nil {
+			continue
+		}
+		backups = append(backups, b)
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Time.After(backups[j].Time) })
+	return backups, nil
+}
+
+// Prune deletes the backups that no rule keeps and returns them. The
+// newest backup of each of the last keepDaily days, and of each of the
+// last keepWeekly weeks, is kept, as is the newest backup of all.
+func (d Dir) Prune(keepDaily, keepWeekly int) ([]Backup, error) {
+	backups, err := d.List()
+	if err != nil {
+		return nil, err
+	}
+	days, weeks := map[string]bool{}, map[string]bool{}
+	var removed []Backup
+	for i, b := range backups {
+		keep := i == 0
+		day := b.Time.Format("2006-01-02")
+		if !days[day] && len(days) < keepDaily {
+			days[day] = true
+			keep = true
+		}
+		year, w := b.Time.ISOWeek()
+		week := fmt.Sprintf("%d-%02d", year, w)
+		if !weeks[week] && len(weeks) < keepWeekly {
+			weeks[week] = true
+			keep = true
+		}
+		if keep {
+			continue
+		}
+		if err := os.Remove(b.Path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, b)
+	}
+	return removed, nil
+}
+
+// Verify runs SQLite's integrity check on a backup.
+func Verify(ctx context.Context, b Backup) error {
+	// Backups are never written once taken, so they can be opened
+	// immutable, which needs no lock or write access.
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro&immutable=1", b.Path))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	rows, err := db.QueryContext(ctx, "pragma integrity_check")
+	if err != nil {
+		return fmt.Errorf("backup %s is damaged: %v", b.ID, err)
+	}
+	defer rows.Close()
+	var problems []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return err
+		}
+		if s != "ok" {
+			problems = append(problems, s)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("backup %s is damaged: %v", b.ID, err)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("backup %s is damaged: %s", b.ID, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Restore verifies b and puts it in place of the database file. Nothing
+// else may have the database open: one reading or writing is refused with
+// ErrInUse, but one that is idle cannot be seen.
+// The old database's write-ahead log is removed first so SQLite never
+// applies it to the backup, then the copy, written beside the database, is
+// renamed over it, so the database is either the old one or the backup.
+func Restore(ctx context.Context, b Backup, database string) error {
+	if err := Verify(ctx, b); err != nil {
+		return err
+	}
+	if _, err := os.Stat(database); err == nil {
+		if err := claim(ctx, database); err != nil {
+			return err
+		}
+	}
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(database + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	tmp := database + ".restoring"
+	if err := copyFile(b.Path, tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, database); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// claim checks that nothing else has the database open, without waiting
+// for it: a reader keeps its write-ahead log from being emptied, and a
+// writer keeps the database from being locked. The log is left empty.
+func claim(ctx context.Context, database string) error {
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_busy_timeout=0", database))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	var busy, pages, checkpointed int
+	err = conn.QueryRowContext(ctx, "pragma wal_checkpoint(truncate)").Scan(&busy, &pages, &checkpointed)
+	if err != nil {
+		return inUse(err)
+	}
+	if busy != 0 {
+		return ErrInUse
+	}
+	if _, err := conn.ExecContext(ctx, "begin exclusive"); err != nil {
+		return inUse(err)
+	}
+	_, err = conn.ExecContext(ctx, "rollback")
+	return err
+}
+
+func inUse(err error) error {
+	var serr sqlite3.Error
+	if errors.As(err, &serr) && (serr.Code == sqlite3.ErrBusy || serr.Code == sqlite3.ErrLocked) {
+		return ErrInUse
+	}
+	return err
+}
+
+func copyFile(from, to string) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Sync(); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
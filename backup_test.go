@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Proqpine/probable-memory/backup"
+	"github.com/Proqpine/probable-memory/config"
+)
+
+func TestBackupPrune(t *testing.T) {
+	dir := backup.Dir(t.TempDir())
+	// Wednesday 2026-10-21, back to the Sunday of the week before last.
+	for _, id := range []string{
+		"20261021-180000", "20261021-090000",
+		"20261020-090000",
+		"20261019-090000",
+		"20261018-090000",
+		"20261011-090000",
+		"20261004-090000",
+	} {
+		if err := os.WriteFile(filepath.Join(string(dir), id+".db"), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := dir.Prune(2, 3); err != nil {
+		t.Fatal(err)
+	}
+	backups, err := dir.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, b := range backups {
+		ids = append(ids, b.ID)
+	}
+	// The newest of the last two days, and of the last three weeks.
+	want := "20261021-180000,20261020-090000,20261018-090000,20261011-090000"
+	if got := strings.Join(ids, ","); got != want {
+		t.Errorf("kept %s, want %s", got, want)
+	}
+}
+
+func TestBackupRestore(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Default()
+	cfg.Database = filepath.Join(t.TempDir(), "activity.db")
+	db, err := sql.Open("sqlite3", "file:"+cfg.Database+"?_journal_mode=WAL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	exec := func(query string) {
+		t.Helper()
+		if _, err := db.Exec(query); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exec("create table notes(body text)")
+	exec("insert into notes values ('kept')")
+
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.Local)
+	b, err := takeBackup(ctx, cfg, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := backupIfDue(ctx, cfg, now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	dir, _, _ := backupDir(cfg)
+	if backups, _ := dir.List(); len(backups) != 1 {
+		t.Errorf("backupIfDue within a day took another backup: %d", len(backups))
+	}
+
+	exec("insert into notes values ('lost')")
+
+	// Nothing may be reading or writing while the file is replaced.
+	db.SetMaxOpenConns(1)
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec("insert into notes values ('pending')"); err != nil {
+		t.Fatal(err)
+	}
+	if err := backup.Restore(ctx, b, cfg.Database); !errors.Is(err, backup.ErrInUse) {
+		t.Errorf("restoring under a writer = %v, want ErrInUse", err)
+	}
+	tx.Rollback()
+	exec("insert into notes values ('lost too')")
+	rows, err := db.Query("select body from notes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Next()
+	if err := backup.Restore(ctx, b, cfg.Database); !errors.Is(err, backup.ErrInUse) {
+		t.Errorf("restoring under a reader = %v, want ErrInUse", err)
+	}
+	rows.Close()
+
+	db.Close()
+	if err := backup.Restore(ctx, b, cfg.Database); err != nil {
+		t.Fatal(err)
+	}
+	db, err = sql.Open("sqlite3", "file:"+cfg.Database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := db.QueryRow("select count(*) from notes").Scan(&n); err != nil || n != 1 {
+		t.Errorf("after restoring, %d notes, %v, want 1", n, err)
+	}
+
+	damaged := filepath.Join(string(dir), "20261018-090000.db")
+	if err := os.WriteFile(damaged, []byte("not a database"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	bad, err := dir.Get("20261018-090000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := backup.Restore(ctx, bad, cfg.Database); err == nil {
+		t.Error("restoring a damaged backup succeeded")
+	}
+}
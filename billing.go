@@ -0,0 +1,310 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Proqpine/probable-memory/invoice"
+	"github.com/Proqpine/probable-memory/sqlite"
+	"github.com/Proqpine/probable-memory/store"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// projectBillable returns the billable default of a project. Projects that
+// have not been set up are not billable.
+func projectBillable(ctx context.Context, q store.ActivityStore, project string) (bool, error) {
+	p, err := q.GetProject(ctx, project)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return p.Billable, err
+}
+
+func billableView(a sqlite.Activity) string {
+	s := "no"
+	if a.Billable {
+		s = "yes"
+	}
+	if a.InvoiceID.Valid {
+		s += " (invoiced, locked)"
+	}
+	return s
+}
+
+type billableToggledMsg struct {
+	billable bool
+}
+
+func (m model) toggleBillable() tea.Msg {
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	a := m.SelectedActivity
+	var updated sqlite.Activity
+	err := m.Store.InTx(ctx, func(tx store.ActivityStore) error {
+		var err error
+		updated, err = tx.SetActivityBillable(ctx, sqlite.SetActivityBillableParams{
+			Billable: !a.Billable,
+			ID:       a.ID,
+		})
+		if err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, a, &updated)
+	})
+	if err != nil {
+		return errorMsg{fmt.Errorf("failed to update activity: %v", err)}
+	}
+	return billableToggledMsg{billable: updated.Billable}
+}
+
+func clientCommand(ctx context.Context, q store.ActivityStore, args []string) error {
+	fs := flag.NewFlagSet("client", flag.ContinueOnError)
+	rate := fs.String("rate", "", "hourly rate, e.g. 95.00")
+	currency := fs.String("currency", "EUR", "ISO 4217 currency code")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	name := strings.Join(fs.Args(), " ")
+	if name == "" {
+		return fmt.Errorf("usage: client [flags] <name>")
+	}
+
+	params := sqlite.UpsertClientParams{Name: name, Currency: strings.ToUpper(*currency)}
+	if *rate != "" {
+		r, err := invoice.ParseMoney(*rate)
+		if err != nil {
+			return err
+		}
+		params.HourlyRate = sql.NullInt64{Int64: r, Valid: true}
+	}
+	c, err := q.UpsertClient(ctx, params)
+	if err != nil {
+		return fmt.Errorf("failed to save client: %v", err)
+	}
+	fmt.Printf("Saved client %q\n", c.Name)
+	return nil
+}
+
+func projectCommand(ctx context.Context, q store.ActivityStore, args []string) error {
+	fs := flag.NewFlagSet("project", flag.ContinueOnError)
+	client := fs.String("client", "", "client the project is billed to")
+	billable := fs.Bool("billable", false, "new activities in the project are billable")
+	rate := fs.String("rate", "", "hourly rate overriding the client's, e.g. 120.00")
+	currency := fs.String("currency", "", "currency of the project rate")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	name := strings.Join(fs.Args(), " ")
+	if name == "" {
+		return fmt.Errorf("usage: project [flags] <name>")
+	}
+
+	params := sqlite.UpsertProjectParams{
+		Name:     name,
+		Client:   sql.NullString{String: *client, Valid: *client != ""},
+		Billable: *billable,
+		Currency: sql.NullString{String: strings.ToUpper(*currency), Valid: *currency != ""},
+	}
+	if *rate != "" {
+		r, err := invoice.ParseMoney(*rate)
+		if err != nil {
+			return err
+		}
+		params.HourlyRate = sql.NullInt64{Int64: r, Valid: true}
+	}
+	p, err := q.UpsertProject(ctx, params)
+	if err != nil {
+		return fmt.Errorf("failed to save project: %v", err)
+	}
+	fmt.Printf("Saved project %q\n", p.Name)
+	return nil
+}
+
+func invoiceCommand(ctx context.Context, q store.ActivityStore, args []string) error {
+	now := time.Now()
+	lastMonth := time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, now.Location())
+
+	fs := flag.NewFlagSet("invoice", flag.ContinueOnError)
+	client := fs.String("client", "", "client to invoice")
+	from := fs.String("from", lastMonth.Format("2006-01-02"), "first day of the period")
+	to := fs.String("to", lastMonth.AddDate(0, 1, -1).Format("2006-01-02"), "last day of the period")
+	format := fs.String("format", "markdown", "output format: markdown, html or text")
+	round := fs.Duration("round", 15*time.Minute, "round each activity to this interval")
+	roundMode := fs.String("round-mode", invoice.RoundUp, "rounding mode: up, down or nearest")
+	output := fs.String("o", "", "write the invoice to this file instead of stdout")
+	dryRun := fs.Bool("dry-run", false, "preview without numbering the invoice or locking activities")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *client == "" {
+		return fmt.Errorf("usage: invoice --client <name> [flags]")
+	}
+	start, err := time.ParseInLocation("2006-01-02", *from, now.Location())
+	if err != nil {
+		return fmt.Errorf("invalid --from: %v", err)
+	}
+	last, err := time.ParseInLocation("2006-01-02", *to, now.Location())
+	if err != nil {
+		return fmt.Errorf("invalid --to: %v", err)
+	}
+	rounding, err := invoice.ParseRounding(*round, *roundMode)
+	if err != nil {
+		return err
+	}
+
+	inv, activities, err := buildInvoice(ctx, q, *client, start, last.AddDate(0, 0, 1), rounding)
+	if err != nil {
+		return err
+	}
+	inv.IssuedAt = now
+
+	if *dryRun {
+		inv.Number = "DRAFT"
+	} else if err := saveInvoice(ctx, q, &inv, activities); err != nil {
+		return err
+	}
+
+	out, err := invoice.Render(inv, *format)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		fmt.Print(out)
+		return nil
+	}
+	if err := os.WriteFile(*output, []byte(out), 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote invoice %s to %s\n", inv.Number, *output)
+	return nil
+}
+
+// buildInvoice collects the uninvoiced billable activities of a client's
+// projects in [start, end) into invoice lines, one per project.
+func buildInvoice(ctx context.Context, q store.ActivityStore, clientName string, start, end time.Time, rounding invoice.Rounding) (invoice.Invoice, []sqlite.Activity, error) {
+	inv := invoice.Invoice{
+		Client:      clientName,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Rounding:    rounding,
+	}
+	client, err := q.GetClient(ctx, clientName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return inv, nil, fmt.Errorf("unknown client %q", clientName)
+	}
+	if err != nil {
+		return inv, nil, err
+	}
+	projects, err := q.QueryProjectsByClient(ctx, sql.NullString{String: clientName, Valid: true})
+	if err != nil {
+		return inv, nil, err
+	}
+
+	var billed []sqlite.Activity
+	for _, p := range projects {
+		activities, err := q.QueryBillableActivities(ctx, sqlite.QueryBillableActivitiesParams{
+			Project:     p.Name,
+			PeriodStart: start,
+			PeriodEnd:   end,
+		})
+		if err != nil {
+			return inv, nil, err
+		}
+		if len(activities) == 0 {
+			continue
+		}
+
+		rate, currency := client.HourlyRate, client.Currency
+		if p.HourlyRate.Valid {
+			rate = p.HourlyRate
+		}
+		if p.Currency.Valid {
+			currency = p.Currency.String
+		}
+		if !rate.Valid {
+			return inv, nil, fmt.Errorf("no hourly rate for project %q or client %q", p.Name, clientName)
+		}
+		if inv.Currency != "" && inv.Currency != currency {
+			return inv, nil, fmt.Errorf("project %q is billed in %s but the invoice is in %s", p.Name, currency, inv.Currency)
+		}
+		inv.Currency = currency
+
+		line := invoice.Line{Project: p.Name, HourlyRate: rate.Int64}
+		for _, a := range activities {
+			line.Items = append(line.Items, invoice.Item{
+				Date:        a.StartTime,
+				Activity:    a.ActivityName,
+				Description: a.Description,
+				Minutes:     rounding.Apply(a.Duration.Int64),
+			})
+		}
+		inv.Lines = append(inv.Lines, line)
+		billed = append(billed, activities...)
+	}
+	if len(inv.Lines) == 0 {
+		return inv, nil, fmt.Errorf("no uninvoiced billable activities for %q from %s to %s",
+			clientName, start.Format("2006-01-02"), end.AddDate(0, 0, -1).Format("2006-01-02"))
+	}
+	return inv, billed, nil
+}
+
+// saveInvoice numbers the invoice and locks its activities in one
+// transaction, so a failure leaves neither a gap nor stray locks.
+func saveInvoice(ctx context.Context, q store.ActivityStore, inv *invoice.Invoice, activities []sqlite.Activity) error {
+	return q.InTx(ctx, func(tx store.ActivityStore) error {
+		year := int64(inv.IssuedAt.Year())
+		seq, err := tx.NextInvoiceSequence(ctx, year)
+		if err != nil {
+			return err
+		}
+		inv.Number = fmt.Sprintf("%d-%04d", year, seq)
+
+		saved, err := tx.InsertInvoice(ctx, sqlite.InsertInvoiceParams{
+			Number:          inv.Number,
+			Year:            year,
+			Sequence:        seq,
+			Client:          inv.Client,
+			PeriodStart:     inv.PeriodStart,
+			PeriodEnd:       inv.PeriodEnd,
+			IssuedAt:        inv.IssuedAt,
+			Currency:        inv.Currency,
+			RoundingMinutes: inv.Rounding.Minutes,
+			RoundingMode:    inv.Rounding.Mode,
+			Total:           inv.Total(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save invoice: %v", err)
+		}
+		for _, l := range inv.Lines {
+			err := tx.InsertInvoiceLine(ctx, sqlite.InsertInvoiceLineParams{
+				InvoiceID:  saved.ID,
+				Project:    l.Project,
+				Minutes:    l.Minutes(),
+				HourlyRate: l.HourlyRate,
+				Amount:     l.Amount(),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to save invoice line: %v", err)
+			}
+		}
+		for _, a := range activities {
+			locked, err := tx.SetActivityInvoice(ctx, sqlite.SetActivityInvoiceParams{
+				InvoiceID: sql.NullInt64{Int64: saved.ID, Valid: true},
+				ID:        a.ID,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to lock activity %q: %v", a.ActivityName, err)
+			}
+			if err := recordChange(ctx, tx, &a, &locked); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
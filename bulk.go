@@ -0,0 +1,482 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Proqpine/probable-memory/sqlite"
+	"github.com/Proqpine/probable-memory/store"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+)
+
+// markDelegate renders marked activities with a check in front of their
+// name. It shares the model's marked set, so marking does not need the
+// list items to be rebuilt.
+type markDelegate struct {
+	list.DefaultDelegate
+	marked map[int64]bool
+}
+
+type markedItem struct {
+	item
+}
+
+func (i markedItem) Title() string { return "✓ " + i.item.Title() }
+
+func (d markDelegate) Render(w io.Writer, m list.Model, index int, li list.Item) {
+	if h, ok := li.(groupHeader); ok {
+		d.renderHeader(w, h)
+		return
+	}
+	if i, ok := li.(item); ok && d.marked[i.activity.ID] {
+		li = markedItem{i}
+	}
+	d.DefaultDelegate.Render(w, m, index, li)
+}
+
+type bulkAction int
+
+const (
+	bulkNone bulkAction = iota
+	bulkProject
+	bulkAddTags
+	bulkRemoveTags
+	bulkDelete
+	bulkBillable
+	bulkNotBillable
+	bulkShift
+	bulkExport
+)
+
+// modifies reports whether the action writes to the marked activities,
+// which is refused for invoiced ones.
+func (a bulkAction) modifies() bool {
+	return a != bulkExport
+}
+
+func (a bulkAction) prompt() string {
+	switch a {
+	case bulkProject:
+		return "New project"
+	case bulkAddTags:
+		return "Tags to add (comma separated)"
+	case bulkRemoveTags:
+		return "Tags to remove (comma separated)"
+	case bulkShift:
+		return "Shift by (e.g. 1h, -30m)"
+	case bulkExport:
+		return "Export to file"
+	}
+	return ""
+}
+
+// toggleMark marks or unmarks the activity under the cursor and makes it
+// the anchor for range selection.
+func (m *model) toggleMark() {
+	i, ok := m.list.SelectedItem().(item)
+	if !ok {
+		return
+	}
+	id := i.activity.ID
+	if m.marked[id] {
+		delete(m.marked, id)
+	} else {
+		m.marked[id] = true
+	}
+	m.markAnchor = m.list.Index()
+	m.list.Title = m.listTitle()
+}
+
+// markRange marks every visible activity between the anchor and the cursor.
+func (m *model) markRange() {
+	if m.markAnchor < 0 {
+		m.toggleMark()
+		return
+	}
+	items := m.list.VisibleItems()
+	from, to := min(m.markAnchor, m.list.Index()), max(m.markAnchor, m.list.Index())
+	for n := from; n <= to && n < len(items); n++ {
+		if i, ok := items[n].(item); ok {
+			m.marked[i.activity.ID] = true
+		}
+	}
+	m.list.Title = m.listTitle()
+}
+
+// markAll marks every activity matching the current filter.
+func (m *model) markAll() {
+	for _, li := range m.list.VisibleItems() {
+		if i, ok := li.(item); ok {
+			m.marked[i.activity.ID] = true
+		}
+	}
+	m.list.Title = m.listTitle()
+}
+
+func (m *model) clearMarks() {
+	clear(m.marked)
+	m.markAnchor = -1
+	m.list.Title = m.listTitle()
+}
+
+// markedActivities returns the marked activities in list order.
+func (m model) markedActivities() []sqlite.Activity {
+	var out []sqlite.Activity
+	for _, a := range m.Activities {
+		if m.marked[a.ID] {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// pruneMarks drops marks for activities that are no longer listed.
+func (m *model) pruneMarks() {
+	listed := make(map[int64]bool, len(m.Activities))
+	for _, a := range m.Activities {
+		listed[a.ID] = true
+	}
+	for id := range m.marked {
+		if !listed[id] {
+			delete(m.marked, id)
+		}
+	}
+}
+
+// bulkOption is an entry in the bulk edit menu.
+type bulkOption struct {
+	binding key.Binding
+	action  bulkAction
+}
+
+func (m model) bulkOptions() []bulkOption {
+	return []bulkOption{
+		{m.keys.bulkProject, bulkProject},
+		{m.keys.bulkAddTags, bulkAddTags},
+		{m.keys.bulkRemoveTags, bulkRemoveTags},
+		{m.keys.bulkBillable, bulkBillable},
+		{m.keys.bulkNotBillable, bulkNotBillable},
+		{m.keys.bulkShift, bulkShift},
+		{m.keys.bulkExport, bulkExport},
+		{m.keys.bulkDelete, bulkDelete},
+	}
+}
+
+type bulkAppliedMsg struct {
+	status string
+	keep   bool
+}
+
+func (m model) updateBulk(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.bulkAction == bulkNone {
+		if key.Matches(msg, m.keys.back) {
+			m.bulkMenu = false
+			return m, nil
+		}
+		var action bulkAction
+		for _, o := range m.bulkOptions() {
+			if key.Matches(msg, o.binding) {
+				action = o.action
+			}
+		}
+		if action == bulkNone {
+			return m, nil
+		}
+		if action.modifies() {
+			for _, a := range m.markedActivities() {
+				if a.InvoiceID.Valid {
+					m.bulkMenu = false
+					cmd := m.list.NewStatusMessage("Invoiced activities are locked")
+					return m, cmd
+				}
+			}
+		}
+		m.bulkAction = action
+		m.bulkErr = ""
+		switch action {
+		case bulkBillable, bulkNotBillable:
+			return m, retryable(m.applyBulk)
+		case bulkDelete:
+			return m, nil
+		}
+		m.bulkInput.Reset()
+		m.bulkInput.Placeholder = action.prompt()
+		if action == bulkExport {
+			m.bulkInput.SetValue("activities.csv")
+		}
+		cmd := m.bulkInput.Focus()
+		return m, cmd
+	}
+
+	if m.bulkAction == bulkDelete {
+		switch {
+		case key.Matches(msg, m.keys.confirmYes):
+			return m, retryable(m.applyBulk)
+		case key.Matches(msg, m.keys.confirmNo):
+			m.bulkAction = bulkNone
+		}
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(msg, m.keys.formCancel):
+		m.bulkAction = bulkNone
+		m.bulkInput.Blur()
+		return m, nil
+	case key.Matches(msg, m.keys.formSubmit):
+		if m.bulkAction == bulkShift {
+			if _, err := time.ParseDuration(m.bulkInput.Value()); err != nil {
+				m.bulkErr = fmt.Sprintf("invalid offset: %v", err)
+				return m, nil
+			}
+		}
+		return m, retryable(m.applyBulk)
+	}
+	var cmd tea.Cmd
+	m.bulkInput, cmd = m.bulkInput.Update(msg)
+	return m, cmd
+}
+
+// applyBulk runs the chosen action on every marked activity in a single
+// transaction. Exports only read, so they run outside one.
+func (m model) applyBulk() tea.Msg {
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	activities := m.markedActivities()
+	value := strings.TrimSpace(m.bulkInput.Value())
+
+	if m.bulkAction == bulkExport {
+		if err := exportActivities(ctx, m.Store, value, activities); err != nil {
+			return errorMsg{fmt.Errorf("failed to export activities: %v", err)}
+		}
+		return bulkAppliedMsg{status: fmt.Sprintf("Exported %d activities to %s", len(activities), value), keep: true}
+	}
+
+	var status string
+	err := m.Store.InTx(ctx, func(tx store.ActivityStore) error {
+		var err error
+		for _, a := range activities {
+			switch m.bulkAction {
+			case bulkProject:
+				err = bulkSetProject(ctx, tx, a, value)
+				status = fmt.Sprintf("Moved %d activities to %q", len(activities), value)
+			case bulkAddTags, bulkRemoveTags:
+				err = bulkTags(ctx, tx, a, parseTags(value), m.bulkAction == bulkAddTags)
+				status = fmt.Sprintf("Updated tags on %d activities", len(activities))
+			case bulkDelete:
+				err = bulkDeleteActivity(ctx, tx, a)
+				status = fmt.Sprintf("Deleted %d activities", len(activities))
+			case bulkBillable, bulkNotBillable:
+				err = bulkSetBillable(ctx, tx, a, m.bulkAction == bulkBillable)
+				status = fmt.Sprintf("Updated billable on %d activities", len(activities))
+			case bulkShift:
+				offset, _ := time.ParseDuration(value)
+				err = bulkShiftActivity(ctx, tx, a, offset)
+				status = fmt.Sprintf("Shifted %d activities by %s", len(activities), offset)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to update %q: %v", a.ActivityName, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errorMsg{err}
+	}
+	return bulkAppliedMsg{status: status}
+}
+
+func bulkSetProject(ctx context.Context, q store.ActivityStore, a sqlite.Activity, project string) error {
+	next, err := q.SetActivityProject(ctx, sqlite.SetActivityProjectParams{Project: project, ID: a.ID})
+	if err != nil {
+		return err
+	}
+	return recordChange(ctx, q, &a, &next)
+}
+
+func bulkSetBillable(ctx context.Context, q store.ActivityStore, a sqlite.Activity, billable bool) error {
+	next, err := q.SetActivityBillable(ctx, sqlite.SetActivityBillableParams{Billable: billable, ID: a.ID})
+	if err != nil {
+		return err
+	}
+	return recordChange(ctx, q, &a, &next)
+}
+
+func bulkTags(ctx context.Context, q store.ActivityStore, a sqlite.Activity, tags []string, add bool) error {
+	for _, tag := range tags {
+		var err error
+		if add {
+			err = q.AddActivityTag(ctx, sqlite.AddActivityTagParams{ActivityID: a.ID, Tag: tag})
+		} else {
+			err = q.RemoveActivityTag(ctx, sqlite.RemoveActivityTagParams{ActivityID: a.ID, Tag: tag})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func bulkDeleteActivity(ctx context.Context, q store.ActivityStore, a sqlite.Activity) error {
+	id := a.ID
+	if err := q.DeleteTimeSegments(ctx, id); err != nil {
+		return err
+	}
+	if err := q.DeleteActivityTags(ctx, id); err != nil {
+		return err
+	}
+	if err := q.DeleteActivity(ctx, a.ID); err != nil {
+		return err
+	}
+	return recordChange(ctx, q, &a, nil)
+}
+
+// bulkShiftActivity moves an activity and its segments by offset, keeping
+// its duration.
+func bulkShiftActivity(ctx context.Context, q store.ActivityStore, a sqlite.Activity, offset time.Duration) error {
+	params := sqlite.UpdateActivityParams{
+		StartTime:    a.StartTime.Add(offset),
+		EndTime:      a.EndTime,
+		Duration:     a.Duration,
+		ActivityName: a.ActivityName,
+		Description:  a.Description,
+		Project:      a.Project,
+		Notes:        a.Notes,
+		ID:           a.ID,
+	}
+	if a.EndTime.Valid {
+		params.EndTime.Time = a.EndTime.Time.Add(offset)
+	}
+	next, err := q.UpdateActivity(ctx, params)
+	if err != nil {
+		return err
+	}
+	segments, err := q.QueryTimeSegments(ctx, a.ID)
+	if err != nil {
+		return err
+	}
+	if err := shiftSegments(ctx, q, segments, offset); err != nil {
+		return err
+	}
+	return recordChange(ctx, q, &a, &next)
+}
+
+// shiftSegments moves segments by offset.
+func shiftSegments(ctx context.Context, q store.ActivityStore, segments []sqlite.TimeSegment, offset time.Duration) error {
+	for _, s := range segments {
+		end := s.EndTime
+		if end.Valid {
+			end.Time = end.Time.Add(offset)
+		}
+		err := q.UpdateTimeSegment(ctx, sqlite.UpdateTimeSegmentParams{
+			StartTime: s.StartTime.Add(offset),
+			EndTime:   end,
+			ID:        s.ID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportActivities writes activities to path as CSV, one row each.
+func exportActivities(ctx context.Context, q store.ActivityStore, path string, activities []sqlite.Activity) error {
+	if path == "" {
+		return fmt.Errorf("no file given")
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"id", "name", "description", "project", "notes", "start", "end", "duration", "billable", "tags"})
+	for _, a := range activities {
+		tags, err := q.QueryActivityTags(ctx, a.ID)
+		if err != nil {
+			return err
+		}
+		w.Write([]string{
+			strconv.FormatInt(a.ID, 10),
+			a.ActivityName,
+			a.Description,
+			a.Project,
+			a.Notes,
+			a.StartTime.Format(time.RFC3339),
+			formatNullTime(a.EndTime),
+			formatNullInt(a.Duration),
+			strconv.FormatBool(a.Billable),
+			strings.Join(tags, ","),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func formatNullTime(t sql.NullTime) string {
+	if !t.Valid {
+		return ""
+	}
+	return t.Time.Format(time.RFC3339)
+}
+
+func formatNullInt(n sql.NullInt64) string {
+	if !n.Valid {
+		return ""
+	}
+	return strconv.FormatInt(n.Int64, 10)
+}
+
+func newBulkInput() textinput.Model {
+	t := newInput()
+	t.CharLimit = maxFieldLength
+	t.Width = 40
+	return t
+}
+
+func (m model) bulkView() string {
+	var b strings.Builder
+	n := len(m.markedActivities())
+	b.WriteString(titleStyle.Render(fmt.Sprintf("Bulk edit: %d activities", n)) + "\n\n")
+	switch m.bulkAction {
+	case bulkNone:
+		options := m.bulkOptions()
+		width := 0
+		for _, o := range options {
+			width = max(width, runewidth.StringWidth(o.binding.Help().Key))
+		}
+		for _, o := range options {
+			if o.binding.Enabled() {
+				fmt.Fprintf(&b, "%s  %s\n", runewidth.FillRight(o.binding.Help().Key, width), o.binding.Help().Desc)
+			}
+		}
+		b.WriteString("\n" + continueStyle.Render(helpLine(m.keys.back)))
+	case bulkDelete:
+		fmt.Fprintf(&b, "Delete %d activities? This cannot be undone from the history view.\n\n", n)
+		b.WriteString(continueStyle.Render(helpLine(m.keys.confirmYes, m.keys.confirmNo)))
+	default:
+		b.WriteString(m.bulkAction.prompt() + "\n")
+		b.WriteString(m.bulkInput.View() + "\n\n")
+		if m.bulkErr != "" {
+			b.WriteString(m.bulkErr + "\n\n")
+		}
+		b.WriteString(continueStyle.Render(helpLine(withDesc(m.keys.formSubmit, "apply"), withDesc(m.keys.formCancel, "back"))))
+	}
+	return appStyle.Render(b.String())
+}
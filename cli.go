@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/Proqpine/probable-memory/config"
+	"github.com/Proqpine/probable-memory/sqlite"
+	"github.com/Proqpine/probable-memory/store"
+)
+
+// runCommand handles the non-interactive subcommands. It is used whenever
+// the program is started with arguments; otherwise the TUI runs.
+func runCommand(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+	// Ctrl-C cancels whatever query is running.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	switch args[0] {
+	case "backup":
+		return backupCommand(ctx, cfg, args[1:])
+	case "encrypt", "rekey", "decrypt":
+		// Not backed up first: the backup would keep the text as it is
+		// now.
+		return encryptionCommand(ctx, cfg, args[0], args[1:])
+	}
+	open := openStore
+	if !needsKey(args[0]) {
+		open = openDatabase
+	}
+	q, closeStore, err := open(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %v", err)
+	}
+	defer closeStore()
+	// status runs from prompts and status lines, so it only reads: it
+	// adds no user and takes no backup.
+	user := currentUser
+	if args[0] == "status" {
+		user = lookupUser
+	}
+	ctx, err = user(ctx, q, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to load user: %v", err)
+	}
+	ctx = withSource(ctx, sourceCLI)
+
+	if err := storeCommand(ctx, q, cfg, args); err != nil {
+		return err
+	}
+	// Backed up after the command, so that unknown and failed commands
+	// take no backup.
+	if args[0] != "status" {
+		if err := backupIfDue(ctx, cfg, time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to back up the database: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// storeCommand runs the subcommands that work on the open store.
+func storeCommand(ctx context.Context, q store.ActivityStore, cfg config.Config, args []string) error {
+	switch args[0] {
+	case "start":
+		return startCommand(ctx, q, args[1:])
+	case "stop":
+		return stopCommand(ctx, q, args[1:])
+	case "pause":
+		return pauseCommand(ctx, q, false)
+	case "resume":
+		return pauseCommand(ctx, q, true)
+	case "heartbeat":
+		return heartbeatCommand(ctx, q)
+	case "client":
+		return clientCommand(ctx, q, args[1:])
+	case "project":
+		return projectCommand(ctx, q, args[1:])
+	case "invoice":
+		return invoiceCommand(ctx, q, args[1:])
+	case "goal":
+		return goalCommand(ctx, q, args[1:])
+	case "goals":
+		return goalsCommand(ctx, q)
+	case "timesheet":
+		return timesheetCommand(ctx, q, args[1:])
+	case "suggest":
+		t, err := loadTheme(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %v", err)
+		}
+		applyTheme(t)
+		return suggestCommand(ctx, q, cfg, args[1:])
+	case "sync":
+		return syncCommand(ctx, q, cfg)
+	case "summary":
+		return summaryCommand(ctx, q, cfg, args[1:])
+	case "status":
+		return statusCommand(ctx, q, cfg, args[1:])
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// needsKey reports whether a command reads or writes descriptions or notes,
+// which need the key when the database is encrypted. The others run
+// without asking for a passphrase, and status asks only when it prints the
+// description.
+func needsKey(command string) bool {
+	switch command {
+	case "pause", "resume", "heartbeat", "client", "project", "goal", "goals", "timesheet", "status":
+		return false
+	}
+	return true
+}
+
+func startCommand(ctx context.Context, q store.ActivityStore, args []string) error {
+	fs := flag.NewFlagSet("start", flag.ContinueOnError)
+	description := fs.String("description", "", "activity description")
+	project := fs.String("project", "", "project name")
+	notes := fs.String("notes", "", "notes")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	name := strings.Join(fs.Args(), " ")
+	if name == "" {
+		return fmt.Errorf("usage: start [flags] <activity name>")
+	}
+
+	running, err := q.QueryRunningActivity(ctx, userFrom(ctx))
+	if err == nil {
+		return fmt.Errorf("%q is already running", running.ActivityName)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	a, err := startActivity(ctx, q, name, *description, *project, *notes, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to start activity: %v", err)
+	}
+	fmt.Printf("Started %q at %s\n", a.ActivityName, a.StartTime.Format("15:04"))
+
+	warning, err := budgetWarning(ctx, q, a.Project, time.Now())
+	if err != nil {
+		return err
+	}
+	if warning != "" {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+	return nil
+}
+
+func stopCommand(ctx context.Context, q store.ActivityStore, args []string) error {
+	fs := flag.NewFlagSet("stop", flag.ContinueOnError)
+	at := fs.String("at", "", "stop time (HH:MM, \"YYYY-MM-DD HH:MM\" or RFC 3339); defaults to now")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	end := now
+	if *at != "" {
+		t, err := parseAt(*at, now)
+		if err != nil {
+			return err
+		}
+		end = t
+	}
+
+	running, err := q.QueryRunningActivity(ctx, userFrom(ctx))
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no activity is running")
+	}
+	if err != nil {
+		return err
+	}
+
+	a, err := stopActivity(ctx, q, running, end)
+	if err != nil {
+		return fmt.Errorf("failed to stop activity: %v", err)
+	}
+	fmt.Printf("Stopped %q after %s\n", a.ActivityName, time.Duration(a.Duration.Int64)*time.Second)
+	return nil
+}
+
+func pauseCommand(ctx context.Context, q store.ActivityStore, resume bool) error {
+	running, err := q.QueryRunningActivity(ctx, userFrom(ctx))
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no activity is running")
+	}
+	if err != nil {
+		return err
+	}
+	if resume {
+		err = resumeActivity(ctx, q, running, time.Now())
+	} else {
+		err = pauseActivity(ctx, q, running, time.Now())
+	}
+	if err != nil {
+		return err
+	}
+	if resume {
+		fmt.Printf("Resumed %q\n", running.ActivityName)
+	} else {
+		fmt.Printf("Paused %q\n", running.ActivityName)
+	}
+	return nil
+}
+
+// heartbeatCommand marks the running activity as still in use, for editor
+// plugins and other tools that know the user is working.
+func heartbeatCommand(ctx context.Context, q store.ActivityStore) error {
+	running, err := q.QueryRunningActivity(ctx, userFrom(ctx))
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no activity is running")
+	}
+	if err != nil {
+		return err
+	}
+	return q.TouchActivity(ctx, sqlite.TouchActivityParams{
+		HeartbeatAt: sql.NullTime{Time: time.Now(), Valid: true},
+		ID:          running.ID,
+	})
+}
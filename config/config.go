@@ -0,0 +1,223 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Proqpine/probable-memory/theme"
+)
+
+// Config holds the user settings read from config.json in the user's
+// config directory. Missing fields keep their defaults.
+type Config struct {
+	// Database is where activities are stored: the path of a SQLite file,
+	// activity.db when empty, or a postgres:// URL for a shared database.
+	Database string `json:"database"`
+
+	// User names who owns the activities recorded here. It defaults to the
+	// operating system's user name.
+	User string `json:"user"`
+
+	// IdleThreshold is how long a running activity may go without TUI
+	// input or a heartbeat before the user is asked what to do with it.
+	IdleThreshold Duration `json:"idle_threshold"`
+
+	Git Git `json:"git"`
+
+	Sync Sync `json:"sync"`
+
+	Encryption Encryption `json:"encryption"`
+
+	Backup Backup `json:"backup"`
+
+	Summary Summary `json:"summary"`
+
+	List List `json:"list"`
+
+	// Keys remaps TUI key bindings by name, e.g. "pause": ["ctrl+p"]. An
+	// empty list disables the binding.
+	Keys map[string][]string `json:"keys"`
+
+	// Theme names the color theme: auto, dark, light, high-contrast or
+	// one of Themes.
+	Theme  string                 `json:"theme"`
+	Themes map[string]theme.Theme `json:"themes"`
+
+	// Templates prefill the add form when their name is entered.
+	Templates []Template `json:"templates"`
+}
+
+// Template is a saved set of activity fields.
+type Template struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Project     string   `json:"project"`
+	Notes       string   `json:"notes"`
+	Tags        []string `json:"tags"`
+	Duration    Duration `json:"duration"`
+}
+
+// List holds how the activity list is ordered. The TUI saves it whenever
+// the user changes either setting.
+type List struct {
+	// Sort is one of start-desc, start, duration, project or name.
+	Sort string `json:"sort"`
+	// Group is one of none, day or project.
+	Group string `json:"group"`
+}
+
+// Git configures how commits are turned into suggested activities.
+type Git struct {
+	// Repositories are paths to local clones to scan.
+	Repositories []string `json:"repositories"`
+	// Author is matched against commit authors; each repository's
+	// user.email is used when it is empty.
+	Author string `json:"author"`
+	// SessionGap is the longest pause between commits in one block.
+	SessionGap Duration `json:"session_gap"`
+	// LeadTime is counted before the first commit of each block.
+	LeadTime Duration `json:"lead_time"`
+}
+
+// Sync configures syncing activities with other devices.
+type Sync struct {
+	// Dir is a directory the devices share, such as a Syncthing or
+	// Dropbox folder. Syncing is off when it is empty.
+	Dir string `json:"dir"`
+}
+
+// Encryption configures where the key of an encrypted database is found.
+type Encryption struct {
+	// KeyFile holds the key, such as a file kept by the OS keyring. When
+	// it is empty the database is unlocked with a passphrase instead.
+	KeyFile string `json:"key_file"`
+}
+
+// Backup configures the backups of a SQLite database, taken when the TUI
+// starts and daily while it runs, and by commands once a day.
+type Backup struct {
+	// Dir is where backups are kept, a backups directory beside the
+	// database when empty.
+	Dir string `json:"dir"`
+	// KeepDaily and KeepWeekly are how many days and weeks keep their
+	// newest backup; older ones are deleted.
+	KeepDaily  int `json:"keep_daily"`
+	KeepWeekly int `json:"keep_weekly"`
+	// Disabled turns the automatic backups off. The backup command still
+	// takes one.
+	Disabled bool `json:"disabled"`
+}
+
+// Summary configures the weekly summaries written by a language model.
+type Summary struct {
+	// URL is the chat completions endpoint of an OpenAI-compatible API.
+	URL   string `json:"url"`
+	Model string `json:"model"`
+	// Prices add to or override the built-in prices of models, in US
+	// dollars per million tokens.
+	Prices map[string]Price `json:"prices"`
+}
+
+// Price is what a model costs in US dollars per million tokens.
+type Price struct {
+	Prompt     float64 `json:"prompt"`
+	Completion float64 `json:"completion"`
+}
+
+// Duration is a time.Duration that reads and writes as a string like "15m".
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %v", s, err)
+	}
+	d.Duration = v
+	return nil
+}
+
+func Default() Config {
+	return Config{
+		IdleThreshold: Duration{15 * time.Minute},
+		Git: Git{
+			SessionGap: Duration{2 * time.Hour},
+			LeadTime:   Duration{30 * time.Minute},
+		},
+		List: List{
+			Sort:  "start-desc",
+			Group: "none",
+		},
+		Backup: Backup{
+			KeepDaily:  7,
+			KeepWeekly: 4,
+		},
+		Summary: Summary{
+			URL:   "https://api.openai.com/v1/chat/completions",
+			Model: "gpt-4o-mini",
+		},
+		Theme: theme.Auto,
+	}
+}
+
+// Path returns the location of the config file. PROBABLE_MEMORY_CONFIG
+// overrides the default under os.UserConfigDir.
+func Path() (string, error) {
+	if p := os.Getenv("PROBABLE_MEMORY_CONFIG"); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "probable-memory", "config.json"), nil
+}
+
+// Load reads the config file, returning the defaults if it does not exist.
+func Load() (Config, error) {
+	cfg := Default()
+	path, err := Path()
+	if err != nil {
+		return cfg, err
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+// Save writes cfg to the config file, creating its directory if needed.
+func Save(cfg Config) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
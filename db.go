@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Proqpine/probable-memory/config"
+	"github.com/Proqpine/probable-memory/store"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqliteDSN opens a SQLite file in WAL mode so reads don't wait on writes,
+// with foreign keys enforced and a busy timeout so that a lock held by
+// another process (the CLI, a plugin's heartbeat) is waited out briefly
+// before it is reported.
+const sqliteDSN = "file:%s?_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=on"
+
+// defaultDatabase is used when the config does not name a database.
+const defaultDatabase = "activity.db"
+
+// dbTimeout bounds the queries of each TUI command, so a stuck database
+// shows an error instead of hanging the screen.
+const dbTimeout = 10 * time.Second
+
+// isPostgres reports whether database is a PostgreSQL URL rather than a
+// SQLite file.
+func isPostgres(database string) bool {
+	return strings.HasPrefix(database, "postgres://") || strings.HasPrefix(database, "postgresql://")
+}
+
+// openStore opens the database named in the config, unlocking it if it is
+// encrypted. Call close when done with the store.
+func openStore(ctx context.Context, cfg config.Config) (st store.ActivityStore, close func(), err error) {
+	st, close, err = openDatabase(ctx, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	st, err = unlockStore(ctx, st, cfg)
+	if err != nil {
+		close()
+		return nil, nil, err
+	}
+	return st, close, nil
+}
+
+// openDatabase opens the database named in the config as it is stored,
+// without decrypting anything.
+func openDatabase(ctx context.Context, cfg config.Config) (st store.ActivityStore, close func(), err error) {
+	database := cfg.Database
+	if database == "" {
+		database = defaultDatabase
+	}
+	if isPostgres(database) {
+		pool, err := pgxpool.New(ctx, database)
+		if err != nil {
+			return nil, nil, err
+		}
+		return store.NewPostgres(pool), pool.Close, nil
+	}
+	db, err := sql.Open("sqlite3", fmt.Sprintf(sqliteDSN, database))
+	if err != nil {
+		return nil, nil, err
+	}
+	return store.NewSQLite(db), func() { db.Close() }, nil
+}
+
+// dbContext returns the context for one TUI command's queries. It is
+// cancelled when the program quits or after dbTimeout.
+func (m model) dbContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(m.ctx, dbTimeout)
+	return withSource(ctx, sourceTUI), cancel
+}
+
+// isBusy reports whether err means the database was locked or too slow to
+// answer, which is worth trying again.
+func isBusy(err error) bool {
+	var serr sqlite3.Error
+	if errors.As(err, &serr) && (serr.Code == sqlite3.ErrBusy || serr.Code == sqlite3.ErrLocked) {
+		return true
+	}
+	// Postgres: serialization failure, deadlock, lock not available.
+	var perr *pgconn.PgError
+	if errors.As(err, &perr) && (perr.Code == "40001" || perr.Code == "40P01" || perr.Code == "55P03") {
+		return true
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	// Some callers wrap errors with %v, which loses the type.
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") || strings.Contains(msg, "context deadline exceeded")
+}
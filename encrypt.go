@@ -0,0 +1,403 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Proqpine/probable-memory/config"
+	"github.com/Proqpine/probable-memory/fieldcrypt"
+	"github.com/Proqpine/probable-memory/sqlite"
+	"github.com/Proqpine/probable-memory/store"
+	"github.com/Proqpine/probable-memory/syncdir"
+	"golang.org/x/term"
+)
+
+// The passphrase of an encrypted database, and the new one when it is
+// encrypted or rekeyed, are read from these variables when set, and asked
+// for otherwise.
+const (
+	passphraseEnv    = "PROBABLE_MEMORY_PASSPHRASE"
+	newPassphraseEnv = "PROBABLE_MEMORY_NEW_PASSPHRASE"
+)
+
+// unlockStore returns st as it is if the database is not encrypted, or
+// else wrapped to decrypt with the key from the config or passphrase.
+func unlockStore(ctx context.Context, st store.ActivityStore, cfg config.Config) (store.ActivityStore, error) {
+	key, err := currentKey(ctx, st, cfg)
+	if err != nil || key == nil {
+		return st, err
+	}
+	return store.NewEncrypted(st, key), nil
+}
+
+// currentKey returns the key the database is encrypted with, checked
+// against it, or nil if it is not encrypted.
+func currentKey(ctx context.Context, st store.ActivityStore, cfg config.Config) (*fieldcrypt.Key, error) {
+	e, err := st.GetEncryption(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var key *fieldcrypt.Key
+	switch e.Kdf {
+	case fieldcrypt.KDFKeyFile:
+		if cfg.Encryption.KeyFile == "" {
+			return nil, errors.New(`the database is encrypted with a key file: set "encryption": {"key_file": ...} in the config`)
+		}
+		key, err = readKeyFile(cfg.Encryption.KeyFile)
+	case fieldcrypt.KDFArgon2id:
+		var passphrase string
+		passphrase, err = readPassphrase(passphraseEnv, "Passphrase: ", false)
+		if err == nil {
+			key, err = passphraseKey(passphrase, e.Salt)
+		}
+	default:
+		return nil, fmt.Errorf("unknown key derivation %q", e.Kdf)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := key.Check(e.Verifier); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func readKeyFile(path string) (*fieldcrypt.Key, error) {
+	path, err := expandHome(path)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := fieldcrypt.ParseKeyFile(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return key, nil
+}
+
+func passphraseKey(passphrase, salt string) (*fieldcrypt.Key, error) {
+	b, err := base64.StdEncoding.DecodeString(salt)
+	if err != nil {
+		return nil, fmt.Errorf("malformed salt: %v", err)
+	}
+	return fieldcrypt.DeriveKey(passphrase, b)
+}
+
+// readPassphrase returns the passphrase in the environment variable env,
+// or else asks for it on the terminal, twice if confirm is set.
+func readPassphrase(env, prompt string, confirm bool) (string, error) {
+	if p := os.Getenv(env); p != "" {
+		return p, nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("no passphrase: set %s", env)
+	}
+	ask := func(prompt string) (string, error) {
+		fmt.Fprint(os.Stderr, prompt)
+		b, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		return string(b), err
+	}
+	p, err := ask(prompt)
+	if err != nil {
+		return "", err
+	}
+	if p == "" {
+		return "", errors.New("empty passphrase")
+	}
+	if confirm {
+		again, err := ask("Again: ")
+		if err != nil {
+			return "", err
+		}
+		if again != p {
+			return "", errors.New("the passphrases differ")
+		}
+	}
+	return p, nil
+}
+
+// newKey returns a key to encrypt with and the encryption row recording
+// it. With a key file, the key is read from it, or a random one written to
+// it if it does not exist yet; without, it is derived from a new
+// passphrase.
+func newKey(keyFile string) (*fieldcrypt.Key, sqlite.Encryption, error) {
+	var (
+		key *fieldcrypt.Key
+		e   sqlite.Encryption
+		err error
+	)
+	if keyFile != "" {
+		e.Kdf = fieldcrypt.KDFKeyFile
+		key, err = createKeyFile(keyFile)
+	} else {
+		e.Kdf = fieldcrypt.KDFArgon2id
+		var salt []byte
+		salt, err = fieldcrypt.NewSalt()
+		if err != nil {
+			return nil, e, err
+		}
+		e.Salt = base64.StdEncoding.EncodeToString(salt)
+		var passphrase string
+		passphrase, err = readPassphrase(newPassphraseEnv, "New passphrase: ", true)
+		if err == nil {
+			key, err = fieldcrypt.DeriveKey(passphrase, salt)
+		}
+	}
+	if err != nil {
+		return nil, e, err
+	}
+	e.Verifier, err = key.Verifier()
+	return key, e, err
+}
+
+func createKeyFile(path string) (*fieldcrypt.Key, error) {
+	path, err := expandHome(path)
+	if err != nil {
+		return nil, err
+	}
+	b, err := fieldcrypt.NewKeyFile()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return readKeyFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	return fieldcrypt.ParseKeyFile(b)
+}
+
+// reencrypt rewrites every encrypted value in one transaction, from the
+// key from to the key to; a nil key means plain text. next records the new
+// key, or is nil when the database is decrypted. Invoiced activities are
+// unlocked while it runs, as their text changes too.
+func reencrypt(ctx context.Context, raw store.ActivityStore, from, to store.Cipher, next *sqlite.Encryption) error {
+	return raw.InTx(ctx, func(tx store.ActivityStore) error {
+		if _, err := tx.GetEncryption(ctx); errors.Is(err, sql.ErrNoRows) {
+			if next == nil {
+				return nil
+			}
+			if err := tx.InsertEncryption(ctx, sqlite.InsertEncryptionParams{Kdf: next.Kdf, Salt: next.Salt, Verifier: next.Verifier}); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+		if err := tx.SetEncryptionRekeying(ctx, true); err != nil {
+			return err
+		}
+
+		src, dst := tx, tx
+		if from != nil {
+			src = store.NewEncrypted(tx, from)
+		}
+		if to != nil {
+			dst = store.NewEncrypted(tx, to)
+		}
+		activities, err := src.QueryAllActivities(ctx)
+		if err != nil {
+			return err
+		}
+		for _, a := range activities {
+			err := dst.SetActivityText(ctx, sqlite.SetActivityTextParams{ID: a.ID, Description: a.Description, Notes: a.Notes})
+			if err != nil {
+				return err
+			}
+		}
+		history, err := src.QueryActivityHistorySince(ctx, 0)
+		if err != nil {
+			return err
+		}
+		for _, h := range history {
+			err := dst.SetActivityHistoryValues(ctx, sqlite.SetActivityHistoryValuesParams{ID: h.ID, OldValues: h.OldValues, NewValues: h.NewValues})
+			if err != nil {
+				return err
+			}
+		}
+		conflicts, err := src.QuerySyncConflicts(ctx)
+		if err != nil {
+			return err
+		}
+		for _, c := range conflicts {
+			err := dst.SetSyncConflictValues(ctx, sqlite.SetSyncConflictValuesParams{ID: c.ID, Kept: c.Kept, Other: c.Other})
+			if err != nil {
+				return err
+			}
+		}
+
+		summaries, err := src.QueryAllSummaries(ctx)
+		if err != nil {
+			return err
+		}
+		for _, sum := range summaries {
+			if err := dst.SetSummaryContent(ctx, sqlite.SetSummaryContentParams{ID: sum.ID, Content: sum.Content}); err != nil {
+				return err
+			}
+		}
+
+		if next == nil {
+			return tx.DeleteEncryption(ctx)
+		}
+		err = tx.UpdateEncryption(ctx, sqlite.UpdateEncryptionParams{Kdf: next.Kdf, Salt: next.Salt, Verifier: next.Verifier})
+		if err != nil {
+			return err
+		}
+		return tx.SetEncryptionRekeying(ctx, false)
+	})
+}
+
+// rekeyLog rewrites this device's sync log, if it has one, from the key
+// from to the key to, as reencrypt does the database. Other devices'
+// logs are theirs to rewrite.
+func rekeyLog(ctx context.Context, raw store.ActivityStore, cfg config.Config, from, to store.Cipher) error {
+	if cfg.Sync.Dir == "" {
+		return nil
+	}
+	dir, err := syncDir(cfg)
+	if err != nil {
+		return err
+	}
+	local, err := raw.GetSyncLocal(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return dir.Rewrite(local.Device, func(e *syncdir.Entry) error {
+		if err := openEntry(e, from); err != nil {
+			return err
+		}
+		return sealEntry(e, to)
+	})
+}
+
+// compact leaves no copy of the values reencrypt replaced in a SQLite
+// database file or its write-ahead log.
+func compact(ctx context.Context, raw store.ActivityStore) error {
+	if s, ok := raw.(*store.SQLite); ok {
+		return s.Compact(ctx)
+	}
+	return nil
+}
+
+// warnOldBackups points out the backups that still hold descriptions and
+// notes as they were stored before.
+func warnOldBackups(cfg config.Config) {
+	dir, _, err := backupDir(cfg)
+	if err != nil {
+		return
+	}
+	backups, err := dir.List()
+	if err != nil || len(backups) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: backups taken before this still hold descriptions and notes as they were: delete the %s in %s once the new key works\n",
+		plural(len(backups), "backup"), dir)
+}
+
+// encryptionCommand runs encrypt, rekey and decrypt, which work on the
+// database as it is stored rather than through openStore.
+func encryptionCommand(ctx context.Context, cfg config.Config, name string, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	var keyFile string
+	if name != "decrypt" {
+		fs.StringVar(&keyFile, "key-file", "", "file holding the new key, created with a random key if missing")
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("usage: %s [flags]", name)
+	}
+
+	raw, closeStore, err := openDatabase(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %v", err)
+	}
+	defer closeStore()
+	current, err := currentKey(ctx, raw, cfg)
+	if err != nil {
+		return err
+	}
+	var from store.Cipher
+	if current != nil {
+		from = current
+	}
+
+	switch name {
+	case "encrypt":
+		if current != nil {
+			return errors.New("the database is already encrypted: use rekey to change the key")
+		}
+		if keyFile == "" {
+			keyFile = cfg.Encryption.KeyFile
+		}
+	case "rekey", "decrypt":
+		if current == nil {
+			return errors.New("the database is not encrypted")
+		}
+	}
+
+	if name == "decrypt" {
+		if err := reencrypt(ctx, raw, from, nil, nil); err != nil {
+			return fmt.Errorf("failed to decrypt: %v", err)
+		}
+		if err := rekeyLog(ctx, raw, cfg, from, nil); err != nil {
+			return fmt.Errorf("failed to decrypt the sync log: %v", err)
+		}
+		if err := compact(ctx, raw); err != nil {
+			return fmt.Errorf("failed to compact the database: %v", err)
+		}
+		fmt.Println("Decrypted the database")
+		return nil
+	}
+	key, next, err := newKey(keyFile)
+	if err != nil {
+		return err
+	}
+	if err := reencrypt(ctx, raw, from, key, &next); err != nil {
+		return fmt.Errorf("failed to %s: %v", name, err)
+	}
+	if err := rekeyLog(ctx, raw, cfg, from, key); err != nil {
+		return fmt.Errorf("failed to %s the sync log: %v", name, err)
+	}
+	if err := compact(ctx, raw); err != nil {
+		return fmt.Errorf("failed to compact the database: %v", err)
+	}
+	if name == "encrypt" {
+		fmt.Println("Encrypted the database")
+	} else {
+		fmt.Println("Changed the key")
+	}
+	warnOldBackups(cfg)
+	if keyFile != "" && keyFile != cfg.Encryption.KeyFile {
+		fmt.Printf("Set \"encryption\": {\"key_file\": %q} in the config to open it\n", keyFile)
+	}
+	if keyFile == "" && cfg.Encryption.KeyFile != "" {
+		fmt.Println(`Remove "key_file" from the config to be asked for the passphrase`)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Proqpine/probable-memory/config"
+	"github.com/Proqpine/probable-memory/fieldcrypt"
+	"github.com/Proqpine/probable-memory/store"
+)
+
+func TestReencrypt(t *testing.T) {
+	raw := store.NewMemory()
+	cfg := config.Default()
+	cfg.User = "me"
+	cfg.Encryption.KeyFile = filepath.Join(t.TempDir(), "key")
+	ctx, err := currentUser(context.Background(), raw, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := startActivity(ctx, raw, "Call", "Acme merger", "", "confidential", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// notes reads the activity through the store openStore would return,
+	// and checks how it is stored.
+	notes := func(encrypted bool) string {
+		t.Helper()
+		st, err := unlockStore(ctx, raw, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := st.QueryActivityByUUID(ctx, a.UUID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stored, _ := raw.QueryActivityByUUID(ctx, a.UUID)
+		if fieldcrypt.IsEncrypted(stored.Notes) != encrypted || fieldcrypt.IsEncrypted(stored.Description) != encrypted {
+			t.Errorf("stored %q, %q, want encrypted %v", stored.Description, stored.Notes, encrypted)
+		}
+		history, _ := raw.QueryActivityHistory(ctx, a.ID)
+		if len(history) == 0 || fieldcrypt.IsEncrypted(history[0].NewValues.String) != encrypted {
+			t.Errorf("stored history %+v, want encrypted %v", history, encrypted)
+		}
+		return got.Description + ": " + got.Notes
+	}
+
+	key, next, err := newKey(cfg.Encryption.KeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reencrypt(ctx, raw, nil, key, &next); err != nil {
+		t.Fatal(err)
+	}
+	if got := notes(true); got != "Acme merger: confidential" {
+		t.Errorf("with a key file, read %q", got)
+	}
+
+	// Rotate to a passphrase.
+	t.Setenv(newPassphraseEnv, "correct horse")
+	newer, next, err := newKey("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reencrypt(ctx, raw, key, newer, &next); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(passphraseEnv, "battery staple")
+	if _, err := unlockStore(ctx, raw, cfg); !errors.Is(err, fieldcrypt.ErrWrongKey) {
+		t.Errorf("unlocking with the wrong passphrase = %v, want ErrWrongKey", err)
+	}
+	t.Setenv(passphraseEnv, "correct horse")
+	if got := notes(true); got != "Acme merger: confidential" {
+		t.Errorf("with a passphrase, read %q", got)
+	}
+
+	if err := reencrypt(ctx, raw, newer, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := notes(false); got != "Acme merger: confidential" {
+		t.Errorf("after decrypting, read %q", got)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// writeFailedMsg is an errorMsg from a database write that can be run
+// again.
+type writeFailedMsg struct {
+	error error
+	retry tea.Cmd
+}
+
+// retryable wraps a command that writes to the database so that, if it
+// fails, the error banner offers to run it again.
+func retryable(cmd tea.Cmd) tea.Cmd {
+	var run tea.Cmd
+	run = func() tea.Msg {
+		msg := cmd()
+		if e, ok := msg.(errorMsg); ok {
+			return writeFailedMsg{error: e.error, retry: run}
+		}
+		return msg
+	}
+	return run
+}
+
+// showError puts err in the banner above the current screen and logs it.
+func (m *model) showError(err error, retry tea.Cmd) tea.Cmd {
+	m.Error = err
+	m.retry = retry
+	m.Loading = false
+	m.resize()
+	return logError(err)
+}
+
+func (m *model) dismissError() {
+	m.Error = nil
+	m.retry = nil
+	m.resize()
+}
+
+// errorLogPath is errors.log in the user cache directory, such as
+// ~/.cache/probable-memory/errors.log.
+func errorLogPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "probable-memory", "errors.log"), nil
+}
+
+// logError appends err to the error log. Failing to log is not reported:
+// the error is already on screen.
+func logError(err error) tea.Cmd {
+	return func() tea.Msg {
+		path, perr := errorLogPath()
+		if perr != nil {
+			return nil
+		}
+		if perr := os.MkdirAll(filepath.Dir(path), 0o755); perr != nil {
+			return nil
+		}
+		f, perr := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if perr != nil {
+			return nil
+		}
+		defer f.Close()
+		fmt.Fprintf(f, "%s %v\n", time.Now().Format(time.RFC3339), err)
+		return nil
+	}
+}
+
+// errorView is the banner shown while there is an error.
+func (m model) errorView() string {
+	if m.Error == nil {
+		return ""
+	}
+	hint := m.keys.dismissError.Help().Key + ": dismiss"
+	if m.retry != nil {
+		hint = m.keys.retry.Help().Key + ": retry • " + hint
+	}
+	banner := errorStyle.Render("Error: "+m.Error.Error()) + "  " + hintStyle.Render(hint)
+	return lipgloss.NewStyle().Padding(0, 2).Width(max(20, m.width)).Render(banner) + "\n"
+}
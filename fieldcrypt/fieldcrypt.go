@@ -0,0 +1,147 @@
+package fieldcrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Ways a key is made, as recorded with an encrypted database.
+const (
+	KDFArgon2id = "argon2id"
+	KDFKeyFile  = "keyfile"
+)
+
+// prefix marks encrypted values and their format.
+const prefix = "enc:v1:"
+
+// verifierText is encrypted with the key and kept, so that a wrong
+// passphrase is reported instead of producing garbage.
+const verifierText = "probable-memory"
+
+// KeySize is the length of keys in bytes, for AES-256.
+const KeySize = 32
+
+// Argon2id parameters, as recommended in RFC 9106 for memory-constrained
+// use.
+const (
+	argonTime    = 3
+	argonMemory  = 64 * 1024
+	argonThreads = 4
+	saltSize     = 16
+)
+
+var ErrWrongKey = errors.New("wrong passphrase or key")
+
+// Key encrypts and decrypts field values with AES-256-GCM.
+type Key struct {
+	aead cipher.AEAD
+}
+
+func newKey(b []byte) (*Key, error) {
+	block, err := aes.NewCipher(b)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{aead: aead}, nil
+}
+
+// NewSalt returns a random salt for DeriveKey.
+func NewSalt() ([]byte, error) {
+	salt := make([]byte, saltSize)
+	_, err := rand.Read(salt)
+	return salt, err
+}
+
+// DeriveKey stretches a passphrase into a key with Argon2id.
+func DeriveKey(passphrase string, salt []byte) (*Key, error) {
+	if passphrase == "" {
+		return nil, errors.New("empty passphrase")
+	}
+	return newKey(argon2.IDKey([]byte(passphrase), salt, argonTime, argonMemory, argonThreads, KeySize))
+}
+
+// ParseKeyFile reads a key from the contents of a key file: 32 bytes,
+// hex or base64 encoded, or raw.
+func ParseKeyFile(b []byte) (*Key, error) {
+	text := string(bytes.TrimSpace(b))
+	if k, err := hex.DecodeString(text); err == nil && len(k) == KeySize {
+		return newKey(k)
+	}
+	if k, err := base64.StdEncoding.DecodeString(text); err == nil && len(k) == KeySize {
+		return newKey(k)
+	}
+	if len(b) == KeySize {
+		return newKey(b)
+	}
+	return nil, fmt.Errorf("a key file must hold %d bytes, raw, hex or base64", KeySize)
+}
+
+// NewKeyFile returns the contents of a new key file with a random key.
+func NewKeyFile() ([]byte, error) {
+	k := make([]byte, KeySize)
+	if _, err := rand.Read(k); err != nil {
+		return nil, err
+	}
+	return []byte(base64.StdEncoding.EncodeToString(k) + "\n"), nil
+}
+
+// IsEncrypted reports whether s was made by Encrypt.
+func IsEncrypted(s string) bool {
+	return strings.HasPrefix(s, prefix)
+}
+
+// Encrypt seals s under a random nonce. Equal values encrypt differently.
+func (k *Key) Encrypt(s string) (string, error) {
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := k.aead.Seal(nonce, nonce, []byte(s), nil)
+	return prefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value made by Encrypt. Values that are not encrypted,
+// such as those written before the database was, are returned as they are.
+func (k *Key) Decrypt(s string) (string, error) {
+	rest, ok := strings.CutPrefix(s, prefix)
+	if !ok {
+		return s, nil
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(rest)
+	if err != nil || len(sealed) < k.aead.NonceSize() {
+		return "", errors.New("malformed encrypted value")
+	}
+	n := k.aead.NonceSize()
+	plain, err := k.aead.Open(nil, sealed[:n], sealed[n:], nil)
+	if err != nil {
+		return "", ErrWrongKey
+	}
+	return string(plain), nil
+}
+
+// Verifier is kept with an encrypted database to check keys against.
+func (k *Key) Verifier() (string, error) {
+	return k.Encrypt(verifierText)
+}
+
+// Check reports ErrWrongKey unless k made verifier.
+func (k *Key) Check(verifier string) error {
+	s, err := k.Decrypt(verifier)
+	if err != nil || s != verifierText {
+		return ErrWrongKey
+	}
+	return nil
+}
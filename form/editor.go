@@ -0,0 +1,56 @@
+package form
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// editorMsg carries a field's value back from $EDITOR.
+type editorMsg struct {
+	id, key string
+	value   string
+	err     error
+}
+
+// editorCommand is $VISUAL or $EDITOR, falling back to vi. Either may
+// include arguments, such as "code --wait".
+func editorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if args := strings.Fields(os.Getenv(env)); len(args) > 0 {
+			return args
+		}
+	}
+	return []string{"vi"}
+}
+
+// openEditor suspends the program and edits the focused field in the
+// user's editor.
+func (m Model) openEditor() tea.Cmd {
+	f := m.fields[m.focus]
+	id, key := m.ID, f.Key
+	file, err := os.CreateTemp("", "probable-memory-*.md")
+	if err != nil {
+		return func() tea.Msg { return editorMsg{id: id, key: key, err: err} }
+	}
+	path := file.Name()
+	_, err = file.WriteString(f.value())
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return func() tea.Msg { return editorMsg{id: id, key: key, err: err} }
+	}
+	args := append(editorCommand(), path)
+	return tea.ExecProcess(exec.Command(args[0], args[1:]...), func(err error) tea.Msg {
+		defer os.Remove(path)
+		if err != nil {
+			return editorMsg{id: id, key: key, err: err}
+		}
+		b, err := os.ReadFile(path)
+		return editorMsg{id: id, key: key, value: strings.TrimRight(string(b), "\n"), err: err}
+	})
+}
@@ -0,0 +1,408 @@
+// Package form is the TUI's form component: a column of labelled fields
+// with shared navigation, validation and save/cancel handling.
+package form
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type Kind int
+
+const (
+	// Text is a single line of text.
+	Text Kind = iota
+	// Multiline is free text over several lines.
+	Multiline
+	// Duration accepts anything ParseDuration does.
+	Duration
+	// DateTime accepts anything ParseDateTime does.
+	DateTime
+	// Picker is a line of text that completes from Options.
+	Picker
+	// Tags is a comma separated list.
+	Tags
+)
+
+// Field describes one field of a form.
+type Field struct {
+	Key         string
+	Label       string
+	Kind        Kind
+	Placeholder string
+	// Value is the initial value.
+	Value string
+	// Options are the completions offered by a Picker field.
+	Options []string
+	// Limit caps the length of the value; 0 means no limit.
+	Limit    int
+	Required bool
+	// Validate checks the value after the checks for its kind pass.
+	Validate func(string) error
+}
+
+// KeyMap holds the form's bindings. In a Multiline field LineUp, LineDown
+// and Newline move between and break lines, taking precedence over the
+// bindings that move between fields, and Editor opens the field in
+// $EDITOR.
+type KeyMap struct {
+	Next     key.Binding
+	Prev     key.Binding
+	Submit   key.Binding
+	Save     key.Binding
+	Cancel   key.Binding
+	Editor   key.Binding
+	LineUp   key.Binding
+	LineDown key.Binding
+	Newline  key.Binding
+}
+
+type Styles struct {
+	Title        lipgloss.Style
+	Label        lipgloss.Style
+	FocusedLabel lipgloss.Style
+	Error        lipgloss.Style
+	Hint         lipgloss.Style
+
+	// Input styles are used for the text inputs and areas.
+	Prompt      lipgloss.Style
+	Text        lipgloss.Style
+	Placeholder lipgloss.Style
+	Cursor      lipgloss.Style
+}
+
+// SubmitMsg is sent when a form is saved and every field is valid.
+type SubmitMsg struct {
+	ID     string
+	Values Values
+}
+
+// CancelMsg is sent when a form is cancelled.
+type CancelMsg struct {
+	ID string
+}
+
+type field struct {
+	Field
+	input textinput.Model
+	area  textarea.Model
+	err   string
+}
+
+func (f field) value() string {
+	if f.Kind == Multiline {
+		return f.area.Value()
+	}
+	return f.input.Value()
+}
+
+// Model is a form. ID is passed back in SubmitMsg and CancelMsg so a
+// parent with several forms can tell them apart.
+type Model struct {
+	ID    string
+	Title string
+	// Validate checks the form as a whole once every field is valid.
+	Validate func(Values) error
+	// OnLeave is called when focus moves off the field with the given key.
+	OnLeave func(m *Model, key string)
+
+	fields []field
+	focus  int
+	err    string
+	keys   KeyMap
+	styles Styles
+}
+
+const labelWidth = 14
+
+func New(id, title string, fields []Field, keys KeyMap, styles Styles) Model {
+	m := Model{ID: id, Title: title, keys: keys, styles: styles}
+	for _, f := range fields {
+		ff := field{Field: f}
+		if f.Kind == Multiline {
+			a := textarea.New()
+			a.ShowLineNumbers = false
+			a.Prompt = "┃ "
+			a.Placeholder = f.Placeholder
+			a.CharLimit = f.Limit
+			a.MaxHeight = 0
+			a.KeyMap.LinePrevious = keys.LineUp
+			a.KeyMap.LineNext = keys.LineDown
+			a.KeyMap.InsertNewline = keys.Newline
+			a.SetWidth(50)
+			a.SetHeight(4)
+			a.FocusedStyle.Prompt = styles.Prompt
+			a.FocusedStyle.Text = styles.Text
+			a.FocusedStyle.Placeholder = styles.Placeholder
+			a.FocusedStyle.CursorLine = styles.Text
+			a.BlurredStyle.Prompt = styles.Placeholder
+			a.BlurredStyle.Text = styles.Text
+			a.BlurredStyle.Placeholder = styles.Placeholder
+			a.Cursor.Style = styles.Cursor
+			a.SetValue(f.Value)
+			ff.area = a
+		} else {
+			in := textinput.New()
+			in.Prompt = ""
+			in.Placeholder = f.Placeholder
+			in.CharLimit = f.Limit
+			in.Width = 48
+			in.TextStyle = styles.Text
+			in.PlaceholderStyle = styles.Placeholder
+			in.Cursor.Style = styles.Cursor
+			in.CompletionStyle = styles.Placeholder
+			if f.Kind == Picker && len(f.Options) > 0 {
+				in.ShowSuggestions = true
+				in.SetSuggestions(f.Options)
+				in.KeyMap.AcceptSuggestion = key.NewBinding(key.WithKeys("right"))
+			}
+			in.SetValue(f.Value)
+			ff.input = in
+		}
+		m.fields = append(m.fields, ff)
+	}
+	return m
+}
+
+// Focus focuses the first field.
+func (m *Model) Focus() tea.Cmd {
+	return m.setFocus(0)
+}
+
+func (m *Model) setFocus(i int) tea.Cmd {
+	if len(m.fields) == 0 {
+		return nil
+	}
+	if old := m.focus; old != i {
+		if m.fields[old].Kind == Multiline {
+			m.fields[old].area.Blur()
+		} else {
+			m.fields[old].input.Blur()
+		}
+		if m.OnLeave != nil {
+			m.OnLeave(m, m.fields[old].Key)
+		}
+	}
+	m.focus = i
+	f := &m.fields[i]
+	if f.Kind == Multiline {
+		return f.area.Focus()
+	}
+	f.input.CursorEnd()
+	return f.input.Focus()
+}
+
+// Value returns the current value of the field with the given key.
+func (m Model) Value(key string) string {
+	for _, f := range m.fields {
+		if f.Key == key {
+			return f.value()
+		}
+	}
+	return ""
+}
+
+// SetValue replaces the value of the field with the given key.
+func (m *Model) SetValue(key, value string) {
+	for i := range m.fields {
+		f := &m.fields[i]
+		if f.Key != key {
+			continue
+		}
+		if f.Kind == Multiline {
+			f.area.SetValue(value)
+		} else {
+			f.input.SetValue(value)
+		}
+	}
+}
+
+// Values returns every field's value by key.
+func (m Model) Values() Values {
+	v := make(Values, len(m.fields))
+	for _, f := range m.fields {
+		v[f.Key] = f.value()
+	}
+	return v
+}
+
+func (f field) check() error {
+	v := strings.TrimSpace(f.value())
+	if v == "" {
+		if f.Required {
+			return fmt.Errorf("required")
+		}
+		return nil
+	}
+	switch f.Kind {
+	case Duration:
+		d, err := ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		if d < 0 {
+			return fmt.Errorf("must not be negative")
+		}
+	case DateTime:
+		if _, _, err := ParseDateTime(v); err != nil {
+			return err
+		}
+	}
+	if f.Validate != nil {
+		return f.Validate(v)
+	}
+	return nil
+}
+
+// submit validates the form, focusing the first invalid field, and
+// returns the SubmitMsg command if everything is valid.
+func (m *Model) submit() tea.Cmd {
+	m.err = ""
+	first := -1
+	for i := range m.fields {
+		m.fields[i].err = ""
+		if err := m.fields[i].check(); err != nil {
+			m.fields[i].err = err.Error()
+			if first < 0 {
+				first = i
+			}
+		}
+	}
+	if first >= 0 {
+		return m.setFocus(first)
+	}
+	values := m.Values()
+	if m.Validate != nil {
+		if err := m.Validate(values); err != nil {
+			m.err = err.Error()
+			return nil
+		}
+	}
+	id := m.ID
+	return func() tea.Msg { return SubmitMsg{ID: id, Values: values} }
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	if msg, ok := msg.(editorMsg); ok && msg.id == m.ID {
+		if msg.err != nil {
+			m.err = "editor: " + msg.err.Error()
+			return m, nil
+		}
+		m.SetValue(msg.key, msg.value)
+		return m, nil
+	}
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || len(m.fields) == 0 {
+		return m.updateField(msg)
+	}
+	f := m.fields[m.focus]
+	if f.Kind == Multiline && key.Matches(keyMsg, m.keys.LineUp, m.keys.LineDown, m.keys.Newline) {
+		return m.updateField(msg)
+	}
+	var cmd tea.Cmd
+	switch {
+	case key.Matches(keyMsg, m.keys.Cancel):
+		id := m.ID
+		return m, func() tea.Msg { return CancelMsg{ID: id} }
+	case key.Matches(keyMsg, m.keys.Save):
+		cmd = m.submit()
+	case key.Matches(keyMsg, m.keys.Editor) && f.Kind == Multiline:
+		cmd = m.openEditor()
+	case key.Matches(keyMsg, m.keys.Submit):
+		if m.focus == len(m.fields)-1 {
+			cmd = m.submit()
+		} else {
+			cmd = m.setFocus(m.focus + 1)
+		}
+	case key.Matches(keyMsg, m.keys.Next):
+		cmd = m.setFocus((m.focus + 1) % len(m.fields))
+	case key.Matches(keyMsg, m.keys.Prev):
+		cmd = m.setFocus((m.focus + len(m.fields) - 1) % len(m.fields))
+	default:
+		return m.updateField(msg)
+	}
+	return m, cmd
+}
+
+func (m Model) updateField(msg tea.Msg) (Model, tea.Cmd) {
+	if len(m.fields) == 0 {
+		return m, nil
+	}
+	var cmd tea.Cmd
+	f := &m.fields[m.focus]
+	if f.Kind == Multiline {
+		f.area, cmd = f.area.Update(msg)
+	} else {
+		f.input, cmd = f.input.Update(msg)
+	}
+	if _, ok := msg.(tea.KeyMsg); ok {
+		f.err = ""
+	}
+	return m, cmd
+}
+
+func (m Model) View() string {
+	var b strings.Builder
+	b.WriteString(m.styles.Title.Render(m.Title) + "\n\n")
+	for i, f := range m.fields {
+		label := m.styles.Label.Render(fmt.Sprintf("  %-*s", labelWidth, f.Label))
+		if i == m.focus {
+			label = m.styles.FocusedLabel.Render(fmt.Sprintf("› %-*s", labelWidth, f.Label))
+		}
+		if f.Kind == Multiline {
+			b.WriteString(label + "\n")
+			b.WriteString(lipgloss.NewStyle().PaddingLeft(4).Render(f.area.View()) + "\n")
+		} else {
+			b.WriteString(label + " " + f.input.View() + "\n")
+		}
+		if f.err != "" {
+			b.WriteString(strings.Repeat(" ", labelWidth+3) + m.styles.Error.Render(f.err) + "\n")
+		}
+	}
+	if m.err != "" {
+		b.WriteString("\n" + m.styles.Error.Render(m.err) + "\n")
+	}
+	hint := fmt.Sprintf("%s/%s: navigate • %s: next/save • %s: save • %s: cancel",
+		m.keys.Next.Help().Key, m.keys.Prev.Help().Key, m.keys.Submit.Help().Key,
+		m.keys.Save.Help().Key, m.keys.Cancel.Help().Key)
+	if len(m.fields) > 0 && m.fields[m.focus].Kind == Multiline && m.keys.Editor.Enabled() {
+		hint = fmt.Sprintf("%s: open in editor • %s: save • %s: cancel",
+			m.keys.Editor.Help().Key, m.keys.Save.Help().Key, m.keys.Cancel.Help().Key)
+	}
+	b.WriteString("\n" + m.styles.Hint.Render(hint))
+	return b.String()
+}
+
+// Values are a submitted form's values by field key.
+type Values map[string]string
+
+func (v Values) String(key string) string {
+	return strings.TrimSpace(v[key])
+}
+
+// Duration returns the field's duration, or 0 if it is empty.
+func (v Values) Duration(key string) time.Duration {
+	d, _ := ParseDuration(v[key])
+	return d
+}
+
+// Time returns the field's time and whether it was set.
+func (v Values) Time(key string) (time.Time, bool) {
+	if v.String(key) == "" {
+		return time.Time{}, false
+	}
+	t, _, err := ParseDateTime(v[key])
+	return t, err == nil
+}
+
+// List returns the parts of a comma separated field.
+func (v Values) List(key string) []string {
+	return SplitList(v[key])
+}
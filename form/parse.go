@@ -0,0 +1,62 @@
+package form
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseDuration reads a duration written as Go does ("1h30m"), as hours
+// and minutes ("1:30"), or as a number of minutes ("90").
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.Atoi(s); err == nil {
+		return time.Duration(n) * time.Minute, nil
+	}
+	if h, m, ok := strings.Cut(s, ":"); ok {
+		hours, err1 := strconv.Atoi(h)
+		minutes, err2 := strconv.Atoi(m)
+		if err1 != nil || err2 != nil || minutes < 0 || minutes >= 60 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: use 1h30m, 1:30 or minutes", s)
+	}
+	return d, nil
+}
+
+// DateTimeLayout is how datetime fields show their values.
+const DateTimeLayout = "2006-01-02 15:04"
+
+// ParseDateTime reads a local date with an optional time of day
+// ("2024-09-02" or "2024-09-02 14:30"), or RFC 3339. hasClock reports
+// whether a time of day was given.
+func ParseDateTime(s string) (t time.Time, hasClock bool, err error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.ParseInLocation(DateTimeLayout, s, time.Local); err == nil {
+		return t, true, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, true, nil
+	}
+	return time.Time{}, false, fmt.Errorf("invalid date %q: use YYYY-MM-DD or YYYY-MM-DD HH:MM", s)
+}
+
+// SplitList splits a comma separated value into its trimmed, non-empty
+// parts.
+func SplitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,455 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Proqpine/probable-memory/config"
+	"github.com/Proqpine/probable-memory/form"
+	"github.com/Proqpine/probable-memory/sqlite"
+	"github.com/Proqpine/probable-memory/store"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+)
+
+const (
+	addFormID      = "add"
+	editFormID     = "edit"
+	templateFormID = "template"
+	filterFormID   = "filter"
+)
+
+func (k keyMap) formKeyMap() form.KeyMap {
+	return form.KeyMap{
+		Next:     k.formNext,
+		Prev:     k.formPrev,
+		Submit:   k.formSubmit,
+		Save:     k.formSave,
+		Cancel:   k.formCancel,
+		Editor:   k.formEditor,
+		LineUp:   k.formLineUp,
+		LineDown: k.formLineDown,
+		Newline:  k.formNewline,
+	}
+}
+
+func (m model) openForm(f form.Model) (tea.Model, tea.Cmd) {
+	m.form = f
+	m.showingForm = true
+	cmd := m.form.Focus()
+	return m, cmd
+}
+
+// projects lists the projects of the loaded activities for the project
+// pickers.
+func (m model) projects() []string {
+	seen := map[string]bool{}
+	var projects []string
+	for _, a := range m.Activities {
+		if a.Project != "" && !seen[a.Project] {
+			seen[a.Project] = true
+			projects = append(projects, a.Project)
+		}
+	}
+	sort.Strings(projects)
+	return projects
+}
+
+func (m model) addForm() form.Model {
+	names := make([]string, len(m.Config.Templates))
+	for i, t := range m.Config.Templates {
+		names[i] = t.Name
+	}
+	f := form.New(addFormID, "New activity", []form.Field{
+		{Key: "name", Label: "Name", Kind: form.Picker, Options: names, Limit: maxFieldLength, Required: true},
+		{Key: "description", Label: "Description", Limit: maxFieldLength},
+		{Key: "project", Label: "Project", Kind: form.Picker, Options: m.projects(), Limit: maxFieldLength},
+		{Key: "start", Label: "Start", Kind: form.DateTime, Placeholder: "now, less the duration"},
+		{Key: "duration", Label: "Duration", Kind: form.Duration, Placeholder: "1h30m, 1:30 or minutes"},
+		{Key: "notes", Label: "Notes", Kind: form.Multiline, Placeholder: "Markdown"},
+		{Key: "tags", Label: "Tags", Kind: form.Tags, Placeholder: "comma separated"},
+	}, m.keys.formKeyMap(), formStyles())
+	templates := m.Config.Templates
+	f.OnLeave = func(f *form.Model, key string) {
+		if key != "name" {
+			return
+		}
+		for _, t := range templates {
+			if strings.EqualFold(t.Name, strings.TrimSpace(f.Value("name"))) {
+				applyTemplate(f, t)
+				return
+			}
+		}
+	}
+	return f
+}
+
+// applyTemplate fills the fields the user has left empty from t.
+func applyTemplate(f *form.Model, t config.Template) {
+	fill := func(key, value string) {
+		if strings.TrimSpace(f.Value(key)) == "" && value != "" {
+			f.SetValue(key, value)
+		}
+	}
+	fill("description", t.Description)
+	fill("project", t.Project)
+	fill("notes", t.Notes)
+	fill("tags", strings.Join(t.Tags, ", "))
+	if t.Duration.Duration > 0 {
+		fill("duration", t.Duration.String())
+	}
+}
+
+type editFormMsg struct {
+	activityID int64
+	tags       []string
+	segments   []sqlite.TimeSegment
+}
+
+// fetchEditForm loads what the edit form needs beyond the activity itself.
+func (m model) fetchEditForm() tea.Msg {
+	id := m.SelectedActivity.ID
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	tags, err := m.Store.QueryActivityTags(ctx, id)
+	if err != nil {
+		return errorMsg{err}
+	}
+	segments, err := m.Store.QueryTimeSegments(ctx, id)
+	if err != nil {
+		return errorMsg{err}
+	}
+	return editFormMsg{activityID: id, tags: tags, segments: segments}
+}
+
+// errPausedDuration refuses a new duration for an activity that was paused,
+// whose duration is the sum of its segments.
+var errPausedDuration = errors.New("the duration of an activity that was paused is the time between its pauses")
+
+// setsPausedDuration reports whether v changes the duration of a, which has
+// segments, when a was paused.
+func setsPausedDuration(a sqlite.Activity, segments []sqlite.TimeSegment, v form.Values) bool {
+	if len(segments) < 2 || v.String("duration") == "" {
+		return false
+	}
+	return !a.Duration.Valid || int64(v.Duration("duration").Seconds()) != a.Duration.Int64
+}
+
+func (m model) editForm(tags []string, segments []sqlite.TimeSegment) form.Model {
+	a := m.SelectedActivity
+	duration := ""
+	if a.Duration.Valid {
+		duration = (time.Duration(a.Duration.Int64) * time.Second).String()
+	}
+	durationHint := "1h30m, 1:30 or minutes"
+	if isRunning(*a) {
+		durationHint = "empty to keep running"
+	}
+	f := form.New(editFormID, "Edit "+a.ActivityName, []form.Field{
+		{Key: "name", Label: "Name", Value: a.ActivityName, Limit: maxFieldLength, Required: true},
+		{Key: "description", Label: "Description", Value: a.Description, Limit: maxFieldLength},
+		{Key: "project", Label: "Project", Kind: form.Picker, Value: a.Project, Options: m.projects(), Limit: maxFieldLength},
+		{Key: "start", Label: "Start", Kind: form.DateTime, Value: a.StartTime.Local().Format(form.DateTimeLayout), Required: true},
+		{Key: "duration", Label: "Duration", Kind: form.Duration, Value: duration, Placeholder: durationHint, Required: !isRunning(*a)},
+		{Key: "notes", Label: "Notes", Kind: form.Multiline, Value: a.Notes, Placeholder: "Markdown"},
+		{Key: "tags", Label: "Tags", Kind: form.Tags, Value: strings.Join(tags, ", "), Placeholder: "comma separated"},
+	}, m.keys.formKeyMap(), formStyles())
+	old := *a
+	f.Validate = func(v form.Values) error {
+		if setsPausedDuration(old, segments, v) {
+			return errPausedDuration
+		}
+		return nil
+	}
+	return f
+}
+
+func isRunning(a sqlite.Activity) bool {
+	return !a.EndTime.Valid && !a.Duration.Valid
+}
+
+func (m model) templateForm() form.Model {
+	return form.New(templateFormID, "New template", []form.Field{
+		{Key: "name", Label: "Name", Limit: maxFieldLength, Required: true, Placeholder: "also the activity name"},
+		{Key: "description", Label: "Description", Limit: maxFieldLength},
+		{Key: "project", Label: "Project", Kind: form.Picker, Options: m.projects(), Limit: maxFieldLength},
+		{Key: "duration", Label: "Duration", Kind: form.Duration, Placeholder: "1h30m, 1:30 or minutes"},
+		{Key: "notes", Label: "Notes", Kind: form.Multiline, Placeholder: "Markdown"},
+		{Key: "tags", Label: "Tags", Kind: form.Tags, Placeholder: "comma separated"},
+	}, m.keys.formKeyMap(), formStyles())
+}
+
+func (m model) filterForm() form.Model {
+	f := m.filter
+	var from, to string
+	if !f.from.IsZero() {
+		from = f.from.Format(form.DateTimeLayout)
+	}
+	if !f.to.IsZero() {
+		to = f.to.Format(form.DateTimeLayout)
+	}
+	ff := form.New(filterFormID, "Filter activities", []form.Field{
+		{Key: "text", Label: "Text", Value: f.text, Placeholder: "in name, description or notes"},
+		{Key: "project", Label: "Project", Kind: form.Picker, Value: f.project, Options: m.projects()},
+		{Key: "tags", Label: "Tags", Kind: form.Tags, Value: strings.Join(f.tags, ", "), Placeholder: "all of these"},
+		{Key: "from", Label: "From", Kind: form.DateTime, Value: from},
+		{Key: "to", Label: "To", Kind: form.DateTime, Value: to},
+	}, m.keys.formKeyMap(), formStyles())
+	ff.Validate = func(v form.Values) error {
+		filter := newActivityFilter(v)
+		if !filter.from.IsZero() && !filter.to.IsZero() && filter.to.Before(filter.from) {
+			return fmt.Errorf("from is after to")
+		}
+		return nil
+	}
+	return ff
+}
+
+func (m model) submitForm(msg form.SubmitMsg) (tea.Model, tea.Cmd) {
+	v := msg.Values
+	switch msg.ID {
+	case addFormID:
+		return m, retryable(func() tea.Msg { return m.addActivity(v) })
+	case editFormID:
+		return m, retryable(func() tea.Msg { return m.updateActivity(v) })
+	case templateFormID:
+		return m.saveTemplate(v)
+	case filterFormID:
+		m.showingForm = false
+		m.filter = newActivityFilter(v)
+		m.markAnchor = -1
+		m.list.Title = m.listTitle()
+		status := "Filter cleared"
+		if m.filter.active() {
+			status = "Filter applied"
+		}
+		cmd := tea.Batch(m.list.SetItems(m.listItems()), m.list.NewStatusMessage(status))
+		return m, cmd
+	}
+	return m, nil
+}
+
+type activityAddedMsg struct {
+	name    string
+	warning string
+}
+
+func (m model) addActivity(v form.Values) tea.Msg {
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	billable, err := projectBillable(ctx, m.Store, v.String("project"))
+	if err != nil {
+		return errorMsg{err}
+	}
+	duration := v.Duration("duration")
+	start, ok := v.Time("start")
+	if !ok {
+		start = time.Now().Add(-duration)
+	}
+
+	var a sqlite.Activity
+	err = m.Store.InTx(ctx, func(tx store.ActivityStore) error {
+		var err error
+		a, err = tx.InsertActivity(ctx, sqlite.InsertActivityParams{
+			Billable:     billable,
+			StartTime:    start,
+			EndTime:      sql.NullTime{Time: start.Add(duration), Valid: true},
+			ActivityName: v.String("name"),
+			Description:  v.String("description"),
+			Project:      v.String("project"),
+			Notes:        v["notes"],
+			Duration:     sql.NullInt64{Int64: int64(duration.Seconds()), Valid: true},
+			OwnerID:      ownerFrom(ctx),
+			UUID:         uuid.NewString(),
+		})
+		if err != nil {
+			return err
+		}
+		if err := bulkTags(ctx, tx, a, parseTags(v["tags"]), true); err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, nil, &a)
+	})
+	if err != nil {
+		return errorMsg{err}
+	}
+
+	warning, err := budgetWarning(ctx, m.Store, a.Project, time.Now())
+	if err != nil {
+		return errorMsg{err}
+	}
+	return activityAddedMsg{name: a.ActivityName, warning: warning}
+}
+
+type activityUpdatedMsg struct {
+	name string
+}
+
+// updateActivity saves the edit form. The end is the start plus the
+// duration, and a running activity keeps running unless it is given one.
+// The segments move with the start, and the one segment of an activity
+// that was never paused is made to match it. An activity that was paused
+// keeps its duration, which is the sum of its segments.
+func (m model) updateActivity(v form.Values) tea.Msg {
+	if m.SelectedActivity == nil {
+		return errorMsg{error: fmt.Errorf("no activity selected")}
+	}
+	old := *m.SelectedActivity
+	start, _ := v.Time("start")
+	params := sqlite.UpdateActivityParams{
+		ID:           old.ID,
+		StartTime:    start,
+		EndTime:      old.EndTime,
+		ActivityName: v.String("name"),
+		Description:  v.String("description"),
+		Project:      v.String("project"),
+		Notes:        v["notes"],
+		Duration:     old.Duration,
+	}
+	offset := start.Sub(old.StartTime)
+
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	var a sqlite.Activity
+	err := m.Store.InTx(ctx, func(tx store.ActivityStore) error {
+		segments, err := tx.QueryTimeSegments(ctx, old.ID)
+		if err != nil {
+			return err
+		}
+		if setsPausedDuration(old, segments, v) {
+			return errPausedDuration
+		}
+		if len(segments) > 1 {
+			if params.EndTime.Valid {
+				params.EndTime.Time = old.EndTime.Time.Add(offset)
+			}
+			err = shiftSegments(ctx, tx, segments, offset)
+		} else {
+			if v.String("duration") != "" {
+				duration := v.Duration("duration")
+				params.Duration = sql.NullInt64{Int64: int64(duration.Seconds()), Valid: true}
+				params.EndTime = sql.NullTime{Time: start.Add(duration), Valid: true}
+			}
+			if len(segments) == 1 {
+				err = tx.UpdateTimeSegment(ctx, sqlite.UpdateTimeSegmentParams{
+					StartTime: start,
+					EndTime:   params.EndTime,
+					ID:        segments[0].ID,
+				})
+			}
+		}
+		if err != nil {
+			return err
+		}
+		a, err = tx.UpdateActivity(ctx, params)
+		if err != nil {
+			return fmt.Errorf("failed to update activity: %v", err)
+		}
+		if err := tx.DeleteActivityTags(ctx, a.ID); err != nil {
+			return err
+		}
+		if err := bulkTags(ctx, tx, a, parseTags(v["tags"]), true); err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, &old, &a)
+	})
+	if err != nil {
+		return errorMsg{err}
+	}
+	return activityUpdatedMsg{name: a.ActivityName}
+}
+
+type templateSavedMsg struct {
+	name string
+}
+
+// saveTemplate adds the template, replacing one with the same name.
+func (m model) saveTemplate(v form.Values) (tea.Model, tea.Cmd) {
+	t := config.Template{
+		Name:        v.String("name"),
+		Description: v.String("description"),
+		Project:     v.String("project"),
+		Notes:       v["notes"],
+		Tags:        parseTags(v["tags"]),
+		Duration:    config.Duration{Duration: v.Duration("duration")},
+	}
+	templates := make([]config.Template, 0, len(m.Config.Templates)+1)
+	for _, old := range m.Config.Templates {
+		if !strings.EqualFold(old.Name, t.Name) {
+			templates = append(templates, old)
+		}
+	}
+	m.Config.Templates = append(templates, t)
+	cfg := m.Config
+	return m, retryable(func() tea.Msg {
+		if err := config.Save(cfg); err != nil {
+			return errorMsg{fmt.Errorf("failed to save config: %v", err)}
+		}
+		return templateSavedMsg{name: t.Name}
+	})
+}
+
+// activityFilter narrows the list to activities matching every field that
+// is set.
+type activityFilter struct {
+	text     string
+	project  string
+	tags     []string
+	from, to time.Time
+}
+
+func newActivityFilter(v form.Values) activityFilter {
+	f := activityFilter{
+		text:    strings.ToLower(v.String("text")),
+		project: v.String("project"),
+		tags:    parseTags(v["tags"]),
+	}
+	f.from, _ = v.Time("from")
+	if to, ok := v.Time("to"); ok {
+		// A date on its own covers the whole day.
+		if _, hasClock, _ := form.ParseDateTime(v["to"]); !hasClock {
+			to = to.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		f.to = to
+	}
+	return f
+}
+
+func (f activityFilter) active() bool {
+	return f.text != "" || f.project != "" || len(f.tags) > 0 || !f.from.IsZero() || !f.to.IsZero()
+}
+
+func (f activityFilter) matches(a sqlite.Activity, tags []string) bool {
+	if f.text != "" {
+		text := strings.ToLower(a.ActivityName + "\n" + a.Description + "\n" + a.Notes)
+		if !strings.Contains(text, f.text) {
+			return false
+		}
+	}
+	if f.project != "" && !strings.EqualFold(a.Project, f.project) {
+		return false
+	}
+	for _, want := range f.tags {
+		found := false
+		for _, t := range tags {
+			if t == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !f.from.IsZero() && a.StartTime.Before(f.from) {
+		return false
+	}
+	if !f.to.IsZero() && a.StartTime.After(f.to) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,118 @@
+package gitlog
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Commit struct {
+	Repo    string
+	Hash    string
+	Time    time.Time
+	Subject string
+}
+
+// Log lists the commits in repo by author in [since, until), oldest first.
+// author is matched literally against the name and email, anywhere in them.
+func Log(repo, author string, since, until time.Time) ([]Commit, error) {
+	cmd := exec.Command("git", "-C", repo, "log", "--all", "--no-merges",
+		"--fixed-strings", "--author="+author,
+		"--since="+since.Format(time.RFC3339),
+		"--until="+until.Format(time.RFC3339),
+		"--format=%H%x1f%ct%x1f%s")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log in %s: %v: %s", repo, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var commits []Commit
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.SplitN(line, "\x1f", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		ts, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		commits = append(commits, Commit{
+			Repo:    repo,
+			Hash:    fields[0],
+			Time:    time.Unix(ts, 0),
+			Subject: fields[2],
+		})
+	}
+	sort.Slice(commits, func(i, j int) bool { return commits[i].Time.Before(commits[j].Time) })
+	return commits, nil
+}
+
+// Author returns the user.email configured for repo, for when no author is
+// set explicitly.
+func Author(repo string) (string, error) {
+	out, err := exec.Command("git", "-C", repo, "config", "user.email").Output()
+	if err != nil {
+		return "", fmt.Errorf("no git author configured for %s", repo)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Block is a run of commits in one repository close enough together to be
+// treated as a single working session.
+type Block struct {
+	Repo    string
+	Start   time.Time
+	End     time.Time
+	Commits []Commit
+}
+
+// Project names the block after its repository directory.
+func (b Block) Project() string {
+	return filepath.Base(filepath.Clean(b.Repo))
+}
+
+// Subjects joins the commit subjects, oldest first.
+func (b Block) Subjects() string {
+	subjects := make([]string, len(b.Commits))
+	for i, c := range b.Commits {
+		subjects[i] = c.Subject
+	}
+	return strings.Join(subjects, "; ")
+}
+
+func (b Block) Duration() time.Duration {
+	return b.End.Sub(b.Start)
+}
+
+// Cluster groups the commits of one repository into blocks, starting a new
+// block whenever two commits are more than gap apart. Work before the first
+// commit of a block is not visible in git, so each block starts lead before
+// it.
+func Cluster(commits []Commit, gap, lead time.Duration) []Block {
+	var blocks []Block
+	for _, c := range commits {
+		n := len(blocks)
+		if n > 0 && c.Time.Sub(blocks[n-1].End) <= gap {
+			blocks[n-1].End = c.Time
+			blocks[n-1].Commits = append(blocks[n-1].Commits, c)
+			continue
+		}
+		blocks = append(blocks, Block{
+			Repo:    c.Repo,
+			Start:   c.Time.Add(-lead),
+			End:     c.Time,
+			Commits: []Commit{c},
+		})
+	}
+	return blocks
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Proqpine/probable-memory/goals"
+	"github.com/Proqpine/probable-memory/sqlite"
+	"github.com/Proqpine/probable-memory/store"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// activityDuration is the time recorded for a, counting a running activity
+// up to now.
+func activityDuration(ctx context.Context, q store.ActivityStore, a sqlite.Activity, now time.Time) (time.Duration, error) {
+	if a.Duration.Valid {
+		return time.Duration(a.Duration.Int64) * time.Second, nil
+	}
+	if a.EndTime.Valid {
+		return a.EndTime.Time.Sub(a.StartTime), nil
+	}
+	segments, err := q.QueryTimeSegments(ctx, a.ID)
+	if err != nil {
+		return 0, err
+	}
+	if len(segments) == 0 {
+		return now.Sub(a.StartTime), nil
+	}
+	return segmentsDuration(segments, now), nil
+}
+
+// trackedByProject sums the time of activities started in [start, end) per
+// project.
+func trackedByProject(ctx context.Context, q store.ActivityStore, start, end, now time.Time) (map[string]time.Duration, error) {
+	activities, err := q.QueryActivitiesBetween(ctx, sqlite.QueryActivitiesBetweenParams{
+		PeriodStart: start,
+		PeriodEnd:   end,
+		OwnerID:     userFrom(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+	tracked := map[string]time.Duration{}
+	for _, a := range activities {
+		d, err := activityDuration(ctx, q, a, now)
+		if err != nil {
+			return nil, err
+		}
+		tracked[a.Project] += d
+	}
+	return tracked, nil
+}
+
+func goalProgress(ctx context.Context, q store.ActivityStore, now time.Time) ([]goals.Progress, error) {
+	rows, err := q.QueryGoals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gs := make([]goals.Goal, len(rows))
+	for i, r := range rows {
+		gs[i] = goals.Goal{
+			Project: r.Project,
+			Period:  r.Period,
+			Min:     time.Duration(r.MinMinutes.Int64) * time.Minute,
+			Max:     time.Duration(r.MaxMinutes.Int64) * time.Minute,
+		}
+	}
+	return goals.Evaluate(gs, now, func(start, end time.Time) (map[string]time.Duration, error) {
+		return trackedByProject(ctx, q, start, end, now)
+	})
+}
+
+// budgetWarning describes the budgets that time on project already
+// exceeds, or returns "" if there are none.
+func budgetWarning(ctx context.Context, q store.ActivityStore, project string, now time.Time) (string, error) {
+	progress, err := goalProgress(ctx, q, now)
+	if err != nil {
+		return "", err
+	}
+	var over []string
+	for _, p := range progress {
+		if p.Over() && (p.Project == "" || p.Project == project) {
+			over = append(over, fmt.Sprintf("%s is %s for the %s", p.Name(), p.Status(), p.Period))
+		}
+	}
+	return strings.Join(over, "; "), nil
+}
+
+func goalCommand(ctx context.Context, q store.ActivityStore, args []string) error {
+	fs := flag.NewFlagSet("goal", flag.ContinueOnError)
+	project := fs.String("project", "", "project the goal applies to; all projects if empty")
+	period := fs.String("period", goals.Week, "period: day, week or month")
+	minimum := fs.Duration("min", 0, "minimum time per period, e.g. 10h")
+	maximum := fs.Duration("max", 0, "maximum time per period, e.g. 20h")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if !goals.ValidPeriod(*period) {
+		return fmt.Errorf("invalid period %q: use day, week or month", *period)
+	}
+	if *minimum == 0 && *maximum == 0 {
+		err := q.DeleteGoal(ctx, sqlite.DeleteGoalParams{Project: *project, Period: *period})
+		if err != nil {
+			return err
+		}
+		fmt.Println("Removed goal")
+		return nil
+	}
+	if *maximum > 0 && *minimum > *maximum {
+		return fmt.Errorf("minimum %s is above maximum %s", *minimum, *maximum)
+	}
+	_, err := q.UpsertGoal(ctx, sqlite.UpsertGoalParams{
+		Project:    *project,
+		Period:     *period,
+		MinMinutes: sql.NullInt64{Int64: int64(minimum.Minutes()), Valid: *minimum > 0},
+		MaxMinutes: sql.NullInt64{Int64: int64(maximum.Minutes()), Valid: *maximum > 0},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save goal: %v", err)
+	}
+	fmt.Println("Saved goal")
+	return nil
+}
+
+func goalsCommand(ctx context.Context, q store.ActivityStore) error {
+	progress, err := goalProgress(ctx, q, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(progress) == 0 {
+		fmt.Println("No goals set. Add one with: goal --period week --max 40h")
+		return nil
+	}
+	fmt.Printf("%-24s %-6s %8s %8s %8s  %s\n", "GOAL", "PERIOD", "TRACKED", "MIN", "MAX", "STATUS")
+	for _, p := range progress {
+		fmt.Printf("%-24s %-6s %8s %8s %8s  %s\n",
+			truncateName(p.Name(), 24), p.Period, goals.FormatHours(p.Tracked),
+			boundView(p.Min), boundView(p.Max), p.Status())
+	}
+	return nil
+}
+
+func boundView(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return goals.FormatHours(d)
+}
+
+func truncateName(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+type goalsMsg struct {
+	progress []goals.Progress
+}
+
+func (m model) fetchGoals() tea.Msg {
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	progress, err := goalProgress(ctx, m.Store, time.Now())
+	if err != nil {
+		return errorMsg{err}
+	}
+	return goalsMsg{progress: progress}
+}
+
+// goalsView renders a progress bar per goal for the list header.
+func (m model) goalsView() string {
+	if len(m.goals) == 0 {
+		return ""
+	}
+	var lines []string
+	for _, p := range m.goals {
+		line := fmt.Sprintf("%-20s %-5s %s %s / %s  %s",
+			truncateName(p.Name(), 20), p.Period, p.Bar(20),
+			goals.FormatHours(p.Tracked), goals.FormatHours(p.Target()), p.Status())
+		if p.Over() {
+			line = overStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
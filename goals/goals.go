@@ -0,0 +1,131 @@
+package goals
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	Day   = "day"
+	Week  = "week"
+	Month = "month"
+)
+
+// Goal is a minimum and/or maximum amount of time per period, for one
+// project or, when Project is empty, for all projects together. A zero Min
+// or Max means there is no such bound.
+type Goal struct {
+	Project string
+	Period  string
+	Min     time.Duration
+	Max     time.Duration
+}
+
+func (g Goal) Name() string {
+	if g.Project == "" {
+		return "All projects"
+	}
+	return g.Project
+}
+
+func ValidPeriod(period string) bool {
+	return period == Day || period == Week || period == Month
+}
+
+// Bounds returns the period containing now as [start, end). Weeks start on
+// Monday.
+func Bounds(period string, now time.Time) (time.Time, time.Time) {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch period {
+	case Week:
+		offset := (int(day.Weekday()) + 6) % 7
+		start := day.AddDate(0, 0, -offset)
+		return start, start.AddDate(0, 0, 7)
+	case Month:
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		return start, start.AddDate(0, 1, 0)
+	default:
+		return day, day.AddDate(0, 0, 1)
+	}
+}
+
+// Progress is the time tracked towards a goal in its current period.
+type Progress struct {
+	Goal
+	Tracked time.Duration
+}
+
+func (p Progress) Over() bool {
+	return p.Max > 0 && p.Tracked > p.Max
+}
+
+// Target is the bound progress is measured against: the maximum if there
+// is one, otherwise the minimum.
+func (p Progress) Target() time.Duration {
+	if p.Max > 0 {
+		return p.Max
+	}
+	return p.Min
+}
+
+// Status describes how much time is left or over.
+func (p Progress) Status() string {
+	switch {
+	case p.Over():
+		return fmt.Sprintf("%s over budget", FormatHours(p.Tracked-p.Max))
+	case p.Min > 0 && p.Tracked < p.Min:
+		return fmt.Sprintf("%s to reach minimum", FormatHours(p.Min-p.Tracked))
+	case p.Max > 0:
+		return fmt.Sprintf("%s remaining", FormatHours(p.Max-p.Tracked))
+	default:
+		return "minimum reached"
+	}
+}
+
+// Bar draws a text progress bar of the given width towards Target.
+func (p Progress) Bar(width int) string {
+	target := p.Target()
+	filled := width
+	if target > 0 && p.Tracked < target {
+		filled = int(int64(width) * int64(p.Tracked) / int64(target))
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+// FormatHours renders d as hours and minutes, e.g. "12:30".
+func FormatHours(d time.Duration) string {
+	d = d.Truncate(time.Minute)
+	return fmt.Sprintf("%d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// Evaluate measures each goal against tracked time for its period, to the
+// minute. tracked is called with the period bounds and returns time per
+// project.
+func Evaluate(goals []Goal, now time.Time, tracked func(start, end time.Time) (map[string]time.Duration, error)) ([]Progress, error) {
+	cache := map[string]map[string]time.Duration{}
+	var progress []Progress
+	for _, g := range goals {
+		byProject, ok := cache[g.Period]
+		if !ok {
+			start, end := Bounds(g.Period, now)
+			var err error
+			byProject, err = tracked(start, end)
+			if err != nil {
+				return nil, err
+			}
+			cache[g.Period] = byProject
+		}
+		p := Progress{Goal: g}
+		if g.Project == "" {
+			for _, d := range byProject {
+				p.Tracked += d
+			}
+		} else {
+			p.Tracked = byProject[g.Project]
+		}
+		p.Tracked = p.Tracked.Truncate(time.Minute)
+		progress = append(progress, p)
+	}
+	return progress, nil
+}
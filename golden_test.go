@@ -0,0 +1,338 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Proqpine/probable-memory/config"
+	"github.com/Proqpine/probable-memory/sqlite"
+	"github.com/Proqpine/probable-memory/store"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	// Views must not depend on the terminal or time zone running the tests.
+	lipgloss.SetColorProfile(termenv.Ascii)
+	lipgloss.SetHasDarkBackground(true)
+	time.Local = time.UTC
+	os.Exit(m.Run())
+}
+
+// cmdTimeout is how long the harness waits for a command. Database
+// commands against the memory store return at once; ticks for spinners,
+// cursor blinks and status messages do not, and are dropped, which keeps
+// each frame still.
+const cmdTimeout = 50 * time.Millisecond
+
+// harness drives a model the way tea.Program would, running commands
+// synchronously and feeding their messages back in.
+type harness struct {
+	t *testing.T
+	m tea.Model
+}
+
+func newHarness(t *testing.T, st store.ActivityStore, width, height int) *harness {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+	t.Setenv("PROBABLE_MEMORY_CONFIG", filepath.Join(dir, "config.json"))
+
+	cfg := config.Default()
+	cfg.Theme = "dark"
+	cfg.User = "tester"
+	t0, err := loadTheme(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applyTheme(t0)
+	keys, err := loadKeyMap(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	ctx, err = currentUser(ctx, st, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := &harness{t: t, m: initialModel(ctx, st, cfg, keys)}
+	h.run(h.m.Init())
+	h.send(tea.WindowSizeMsg{Width: width, Height: height})
+	return h
+}
+
+// send delivers msg and everything its commands lead to.
+func (h *harness) send(msg tea.Msg) {
+	h.t.Helper()
+	var cmd tea.Cmd
+	h.m, cmd = h.m.Update(msg)
+	h.run(cmd)
+}
+
+func (h *harness) run(cmd tea.Cmd) {
+	h.t.Helper()
+	for _, msg := range collect(cmd) {
+		h.send(msg)
+	}
+}
+
+// collect runs cmd, flattening batches and sequences, and returns the
+// messages that arrive in time, in the order the commands were given.
+func collect(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	done := make(chan tea.Msg, 1)
+	go func() { done <- cmd() }()
+	var msg tea.Msg
+	select {
+	case msg = <-done:
+	case <-time.After(cmdTimeout):
+		return nil
+	}
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		return collectAll(batch)
+	}
+	// tea.Sequence returns an unexported []tea.Cmd.
+	if v := reflect.ValueOf(msg); v.Kind() == reflect.Slice && v.Type().Elem() == reflect.TypeOf(tea.Cmd(nil)) {
+		return collectAll(v.Interface().([]tea.Cmd))
+	}
+	if msg == nil {
+		return nil
+	}
+	return []tea.Msg{msg}
+}
+
+func collectAll(cmds []tea.Cmd) []tea.Msg {
+	results := make([]chan []tea.Msg, len(cmds))
+	for i, cmd := range cmds {
+		results[i] = make(chan []tea.Msg, 1)
+		go func() { results[i] <- collect(cmd) }()
+	}
+	var msgs []tea.Msg
+	for _, r := range results {
+		msgs = append(msgs, <-r...)
+	}
+	return msgs
+}
+
+var keyTypes = map[string]tea.KeyType{
+	"enter":     tea.KeyEnter,
+	"tab":       tea.KeyTab,
+	"shift+tab": tea.KeyShiftTab,
+	"esc":       tea.KeyEsc,
+	"up":        tea.KeyUp,
+	"down":      tea.KeyDown,
+	"backspace": tea.KeyBackspace,
+	"ctrl+s":    tea.KeyCtrlS,
+	"ctrl+r":    tea.KeyCtrlR,
+	"ctrl+x":    tea.KeyCtrlX,
+	"ctrl+u":    tea.KeyCtrlU,
+}
+
+// press sends each key in turn: a name from keyTypes, or a single rune.
+func (h *harness) press(keys ...string) {
+	h.t.Helper()
+	for _, k := range keys {
+		if t, ok := keyTypes[k]; ok {
+			h.send(tea.KeyMsg{Type: t})
+		} else {
+			h.send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		}
+	}
+}
+
+// typeText sends s one rune at a time.
+func (h *harness) typeText(s string) {
+	h.t.Helper()
+	for _, r := range s {
+		h.send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+// golden compares the current view to testdata/<name>.golden, or rewrites
+// the file when the tests are run with -update.
+func (h *harness) golden(name string) {
+	h.t.Helper()
+	got := h.m.View()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			h.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			h.t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		h.t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if got != string(want) {
+		h.t.Errorf("view does not match %s (run go test -update to accept it)\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+var testDay = time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+
+// seededStore holds a few finished activities, the latest first in the
+// default sort. They have no owner until the harness's user claims them.
+func seededStore(t *testing.T) *store.Memory {
+	t.Helper()
+	st := store.NewMemory()
+	ctx := context.Background()
+	for i, a := range []struct {
+		name, description, project, notes string
+		duration                          time.Duration
+		tags                              []string
+	}{
+		{"Write docs", "README and usage", "docs", "# Outline\n\n- install\n- usage", time.Hour, []string{"writing"}},
+		{"Fix sync bug", "Race on startup", "bolt", "", 90 * time.Minute, []string{"bug", "review"}},
+		{"Standup", "Daily standup", "team", "", 15 * time.Minute, nil},
+	} {
+		start := testDay.Add(time.Duration(i) * 2 * time.Hour)
+		row, err := st.InsertActivity(ctx, sqlite.InsertActivityParams{
+			StartTime:    start,
+			EndTime:      sql.NullTime{Time: start.Add(a.duration), Valid: true},
+			Duration:     sql.NullInt64{Int64: int64(a.duration.Seconds()), Valid: true},
+			ActivityName: a.name,
+			Description:  a.description,
+			Project:      a.project,
+			Notes:        a.notes,
+			UUID:         fmt.Sprintf("00000000-0000-4000-8000-%012d", i),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tag := range a.tags {
+			if err := st.AddActivityTag(ctx, sqlite.AddActivityTagParams{ActivityID: row.ID, Tag: tag}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return st
+}
+
+// failingStore fails every transaction with err while it is set.
+type failingStore struct {
+	store.ActivityStore
+	err error
+}
+
+func (s *failingStore) InTx(ctx context.Context, fn func(store.ActivityStore) error) error {
+	if s.err != nil {
+		return s.err
+	}
+	return s.ActivityStore.InTx(ctx, fn)
+}
+
+func TestListGolden(t *testing.T) {
+	h := newHarness(t, seededStore(t), 80, 24)
+	h.golden("list")
+	h.press("down")
+	h.golden("list-cursor")
+}
+
+func TestAddGolden(t *testing.T) {
+	h := newHarness(t, seededStore(t), 80, 30)
+	h.press("a")
+	h.golden("add-empty")
+	h.typeText("Review PR")
+	h.press("tab")
+	h.typeText("Sync fixes")
+	h.press("tab")
+	h.typeText("bolt")
+	h.press("tab")
+	h.typeText("2026-10-19 16:00")
+	h.press("tab")
+	h.typeText("45m")
+	h.press("tab", "tab")
+	h.typeText("review")
+	h.golden("add-filled")
+	h.press("ctrl+s")
+	h.golden("add-saved")
+}
+
+func TestAddValidationGolden(t *testing.T) {
+	h := newHarness(t, seededStore(t), 80, 30)
+	h.press("a", "tab", "tab", "tab")
+	h.typeText("yesterday")
+	h.press("ctrl+s")
+	h.golden("add-invalid")
+	h.press("esc")
+	h.golden("add-cancelled")
+}
+
+func TestEditGolden(t *testing.T) {
+	h := newHarness(t, seededStore(t), 80, 30)
+	h.press("down", "e")
+	h.golden("edit-form")
+	h.press("ctrl+u")
+	h.typeText("Fix startup race")
+	h.press("ctrl+s")
+	h.golden("edit-saved")
+}
+
+func TestViewGolden(t *testing.T) {
+	h := newHarness(t, seededStore(t), 80, 30)
+	h.press("G", "v")
+	h.golden("view")
+	h.send(tea.WindowSizeMsg{Width: 60, Height: 20})
+	h.golden("view-resized")
+	h.press("esc")
+	h.golden("view-closed")
+}
+
+func TestFilterGolden(t *testing.T) {
+	h := newHarness(t, seededStore(t), 80, 24)
+	h.press("F", "tab")
+	h.typeText("bolt")
+	h.golden("filter-form")
+	h.press("ctrl+s")
+	h.golden("filter-applied")
+	h.press("F", "ctrl+s")
+	h.golden("filter-cleared")
+}
+
+func TestErrorGolden(t *testing.T) {
+	st := &failingStore{ActivityStore: seededStore(t)}
+	h := newHarness(t, st, 80, 30)
+	st.err = errors.New("database is locked")
+	h.press("a")
+	h.typeText("Retro")
+	h.press("tab", "tab", "tab")
+	h.typeText("2026-10-19 17:00")
+	h.press("tab")
+	h.typeText("30m")
+	h.press("ctrl+s")
+	h.golden("error-banner")
+	path, err := errorLogPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(path); !strings.Contains(string(b), "database is locked") {
+		t.Errorf("%s = %q, want the error", path, b)
+	}
+	h.press("ctrl+x")
+	h.golden("error-dismissed")
+	h.press("ctrl+s")
+	st.err = nil
+	h.press("ctrl+r")
+	h.golden("error-retried")
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Proqpine/probable-memory/sqlite"
+	"github.com/Proqpine/probable-memory/store"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Sources of a change, as recorded in activity_history.
+const (
+	sourceTUI    = "tui"
+	sourceCLI    = "cli"
+	sourceAPI    = "api"
+	sourceImport = "import"
+	sourceSync   = "sync"
+)
+
+type sourceKey struct{}
+
+// withSource tags ctx with where the changes made under it come from.
+func withSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, sourceKey{}, source)
+}
+
+func sourceFrom(ctx context.Context) string {
+	if s, ok := ctx.Value(sourceKey{}).(string); ok {
+		return s
+	}
+	return sourceCLI
+}
+
+// activitySnapshot is the JSON form of an activity stored in its history.
+// Heartbeats are left out: they are bookkeeping, not edits.
+type activitySnapshot struct {
+	ID           int64      `json:"id"`
+	UUID         string     `json:"uuid,omitempty"`
+	StartTime    time.Time  `json:"start_time"`
+	EndTime      *time.Time `json:"end_time"`
+	Duration     *int64     `json:"duration"`
+	ActivityName string     `json:"activity_name"`
+	Description  string     `json:"description"`
+	Project      string     `json:"project"`
+	Notes        string     `json:"notes"`
+	Billable     bool       `json:"billable"`
+	InvoiceID    *int64     `json:"invoice_id"`
+}
+
+func newSnapshot(a sqlite.Activity) activitySnapshot {
+	s := activitySnapshot{
+		ID:           a.ID,
+		UUID:         a.UUID,
+		StartTime:    a.StartTime,
+		ActivityName: a.ActivityName,
+		Description:  a.Description,
+		Project:      a.Project,
+		Notes:        a.Notes,
+		Billable:     a.Billable,
+	}
+	if a.EndTime.Valid {
+		s.EndTime = &a.EndTime.Time
+	}
+	if a.Duration.Valid {
+		s.Duration = &a.Duration.Int64
+	}
+	if a.InvoiceID.Valid {
+		s.InvoiceID = &a.InvoiceID.Int64
+	}
+	return s
+}
+
+// changes lists the fields that differ between two snapshots.
+func (s activitySnapshot) changes(next activitySnapshot) []string {
+	var out []string
+	field := func(name, from, to string) {
+		if from != to {
+			out = append(out, fmt.Sprintf("%s: %q → %q", name, from, to))
+		}
+	}
+	field("name", s.ActivityName, next.ActivityName)
+	field("description", s.Description, next.Description)
+	field("project", s.Project, next.Project)
+	field("notes", s.Notes, next.Notes)
+	field("start", s.StartTime.Format(time.RFC3339), next.StartTime.Format(time.RFC3339))
+	field("end", formatOptionalTime(s.EndTime), formatOptionalTime(next.EndTime))
+	field("duration", formatOptionalInt(s.Duration), formatOptionalInt(next.Duration))
+	field("billable", fmt.Sprint(s.Billable), fmt.Sprint(next.Billable))
+	field("invoice", formatOptionalInt(s.InvoiceID), formatOptionalInt(next.InvoiceID))
+	return out
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func formatOptionalInt(n *int64) string {
+	if n == nil {
+		return ""
+	}
+	return fmt.Sprint(*n)
+}
+
+func snapshotJSON(a *sqlite.Activity) (sql.NullString, error) {
+	if a == nil {
+		return sql.NullString{}, nil
+	}
+	b, err := json.Marshal(newSnapshot(*a))
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+// recordChange adds an activity_history entry for a write. old is nil for
+// inserts and next is nil for deletes. Updates that change nothing are not
+// recorded.
+func recordChange(ctx context.Context, q store.ActivityStore, old, next *sqlite.Activity) error {
+	action, a := "update", old
+	switch {
+	case old == nil:
+		action, a = "insert", next
+	case next == nil:
+		action = "delete"
+	case len(newSnapshot(*old).changes(newSnapshot(*next))) == 0:
+		return nil
+	}
+	oldValues, err := snapshotJSON(old)
+	if err != nil {
+		return err
+	}
+	newValues, err := snapshotJSON(next)
+	if err != nil {
+		return err
+	}
+	return q.InsertActivityHistory(ctx, sqlite.InsertActivityHistoryParams{
+		ActivityID: a.ID,
+		Action:     action,
+		Source:     sourceFrom(ctx),
+		OldValues:  oldValues,
+		NewValues:  newValues,
+		ChangedAt:  time.Now(),
+	})
+}
+
+// historyEntry is an activity_history row with its snapshots decoded.
+type historyEntry struct {
+	sqlite.ActivityHistory
+	old  *activitySnapshot
+	next *activitySnapshot
+}
+
+func decodeSnapshot(s sql.NullString) (*activitySnapshot, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+	var snap activitySnapshot
+	if err := json.Unmarshal([]byte(s.String), &snap); err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+func (e historyEntry) summary() string {
+	switch {
+	case e.old == nil:
+		return "created"
+	case e.next == nil:
+		return "deleted"
+	}
+	changes := e.old.changes(*e.next)
+	if len(changes) == 0 {
+		return "no changes"
+	}
+	return strings.Join(changes, ", ")
+}
+
+type historyMsg struct {
+	activityID int64
+	entries    []historyEntry
+}
+
+func (m model) fetchHistory() tea.Msg {
+	id := m.SelectedActivity.ID
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	rows, err := m.Store.QueryActivityHistory(ctx, id)
+	if err != nil {
+		return errorMsg{err}
+	}
+	entries := make([]historyEntry, len(rows))
+	for i, r := range rows {
+		entries[i].ActivityHistory = r
+		if entries[i].old, err = decodeSnapshot(r.OldValues); err != nil {
+			return errorMsg{err}
+		}
+		if entries[i].next, err = decodeSnapshot(r.NewValues); err != nil {
+			return errorMsg{err}
+		}
+	}
+	return historyMsg{activityID: id, entries: entries}
+}
+
+// applySnapshot sets the fields of a to those of snap. The invoice is
+// left alone.
+func applySnapshot(ctx context.Context, q store.ActivityStore, a sqlite.Activity, snap activitySnapshot) (sqlite.Activity, error) {
+	params := sqlite.UpdateActivityParams{
+		StartTime:    snap.StartTime,
+		ActivityName: snap.ActivityName,
+		Description:  snap.Description,
+		Project:      snap.Project,
+		Notes:        snap.Notes,
+		ID:           a.ID,
+	}
+	if snap.EndTime != nil {
+		params.EndTime = sql.NullTime{Time: *snap.EndTime, Valid: true}
+	}
+	if snap.Duration != nil {
+		params.Duration = sql.NullInt64{Int64: *snap.Duration, Valid: true}
+	}
+	next, err := q.UpdateActivity(ctx, params)
+	if err != nil {
+		return next, err
+	}
+	if next.Billable != snap.Billable {
+		return q.SetActivityBillable(ctx, sqlite.SetActivityBillableParams{Billable: snap.Billable, ID: a.ID})
+	}
+	return next, nil
+}
+
+type activityRevertedMsg struct {
+	activity sqlite.Activity
+}
+
+// revertActivity restores the selected activity to the state recorded by
+// the chosen history entry. The revert is itself recorded as an update.
+func (m model) revertActivity() tea.Msg {
+	entry := m.history[m.historyIndex]
+	if entry.next == nil {
+		return errorMsg{fmt.Errorf("cannot revert to a deleted version")}
+	}
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	old := *m.SelectedActivity
+	var a sqlite.Activity
+	err := m.Store.InTx(ctx, func(tx store.ActivityStore) error {
+		var err error
+		a, err = applySnapshot(ctx, tx, old, *entry.next)
+		if err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, &old, &a)
+	})
+	if err != nil {
+		return errorMsg{fmt.Errorf("failed to revert activity: %v", err)}
+	}
+	return activityRevertedMsg{activity: a}
+}
+
+func (m model) updateHistory(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.back):
+		m.viewingHistory = false
+		return m, nil
+	case key.Matches(msg, m.keys.selectUp):
+		m.historyIndex = max(0, m.historyIndex-1)
+	case key.Matches(msg, m.keys.selectDown):
+		m.historyIndex = min(len(m.history)-1, m.historyIndex+1)
+	case key.Matches(msg, m.keys.revert):
+		if len(m.history) > 0 {
+			return m, retryable(m.revertActivity)
+		}
+	}
+	return m, nil
+}
+
+func (m model) historyView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("History: "+m.SelectedActivity.ActivityName) + "\n\n")
+	if len(m.history) == 0 {
+		b.WriteString("No recorded changes.\n")
+	}
+	for i, e := range m.history {
+		cursor := "  "
+		if i == m.historyIndex {
+			cursor = inputStyle.Render("> ")
+		}
+		fmt.Fprintf(&b, "%s%s  %-6s %-6s %s\n", cursor,
+			e.ChangedAt.Format("2006-01-02 15:04:05"), e.Action, e.Source, e.summary())
+	}
+	b.WriteString("\n" + continueStyle.Render(helpLine(m.keys.selectUp, m.keys.selectDown, m.keys.revert, m.keys.back)))
+	return appStyle.Render(b.String())
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Proqpine/probable-memory/sqlite"
+	"github.com/Proqpine/probable-memory/store"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type runningActivityMsg struct {
+	activity *sqlite.Activity
+	segments []sqlite.TimeSegment
+}
+
+func (m model) fetchRunningActivity() tea.Msg {
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	a, err := m.Store.QueryRunningActivity(ctx, userFrom(ctx))
+	if errors.Is(err, sql.ErrNoRows) {
+		return runningActivityMsg{}
+	}
+	if err != nil {
+		return errorMsg{err}
+	}
+	segments, err := m.Store.QueryTimeSegments(ctx, a.ID)
+	if err != nil {
+		return errorMsg{err}
+	}
+	return runningActivityMsg{activity: &a, segments: segments}
+}
+
+// isIdle reports whether the running activity has gone without input or a
+// heartbeat for longer than the configured threshold.
+func (m model) isIdle() bool {
+	if m.running == nil || isPaused(m.runningSegments) || m.Config.IdleThreshold.Duration <= 0 {
+		return false
+	}
+	return time.Since(lastSeen(*m.running)) > m.Config.IdleThreshold.Duration
+}
+
+// idleCheckMsg is the running activity as the store has it, read before
+// asking about idle time.
+type idleCheckMsg runningActivityMsg
+
+// checkIdle reads the running activity again, since heartbeats from the
+// heartbeat command and editor plugins only reach the store.
+func (m model) checkIdle() tea.Msg {
+	msg := m.fetchRunningActivity()
+	if running, ok := msg.(runningActivityMsg); ok {
+		return idleCheckMsg(running)
+	}
+	return msg
+}
+
+func (m model) touchRunning() tea.Msg {
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	err := m.Store.TouchActivity(ctx, sqlite.TouchActivityParams{
+		HeartbeatAt: m.running.HeartbeatAt,
+		ID:          m.running.ID,
+	})
+	if err != nil {
+		return errorMsg{err}
+	}
+	return nil
+}
+
+func (m model) updateIdlePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.idleKeep):
+		a := *m.running
+		a.HeartbeatAt = sql.NullTime{Time: time.Now(), Valid: true}
+		m.running = &a
+		m.idlePrompt = false
+		return m, m.touchRunning
+	case key.Matches(msg, m.keys.idleDiscard):
+		return m, retryable(m.resolveIdle(false))
+	case key.Matches(msg, m.keys.idleSplit):
+		return m, retryable(m.resolveIdle(true))
+	case key.Matches(msg, m.keys.forceQuit):
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+type idleResolvedMsg struct{}
+
+// resolveIdle closes the running activity at the moment it went idle. When
+// split is set, the idle time becomes a gap between two segments instead
+// and the activity keeps running.
+func (m model) resolveIdle(split bool) tea.Cmd {
+	a := *m.running
+	return func() tea.Msg {
+		ctx, cancel := m.dbContext()
+		defer cancel()
+		if !split {
+			if _, err := stopActivity(ctx, m.Store, a, lastSeen(a)); err != nil {
+				return errorMsg{fmt.Errorf("failed to stop idle activity: %v", err)}
+			}
+			return idleResolvedMsg{}
+		}
+		err := m.Store.InTx(ctx, func(tx store.ActivityStore) error {
+			if err := pauseActivity(ctx, tx, a, lastSeen(a)); err != nil {
+				return err
+			}
+			return resumeActivity(ctx, tx, a, time.Now())
+		})
+		if err != nil {
+			return errorMsg{fmt.Errorf("failed to split idle time: %v", err)}
+		}
+		return idleResolvedMsg{}
+	}
+}
+
+func (m model) idlePromptView() string {
+	a := m.running
+	seen := lastSeen(*a)
+	idle := time.Since(seen).Truncate(time.Minute)
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Idle time detected") + "\n\n")
+	fmt.Fprintf(&b, "%q has had no activity since %s (%s ago).\n\n",
+		a.ActivityName, seen.Format("Mon 15:04"), idle)
+	discard := m.keys.idleDiscard
+	discard = withDesc(discard, discard.Help().Desc+" at "+seen.Format("15:04"))
+	for _, k := range []key.Binding{m.keys.idleKeep, discard, m.keys.idleSplit} {
+		if k.Enabled() {
+			b.WriteString(helpLine(k) + "\n")
+		}
+	}
+	return appStyle.Render(b.String())
+}
@@ -0,0 +1,160 @@
+package invoice
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rounding describes how each activity's duration is rounded before it is
+// billed, e.g. "up to 15 minutes".
+type Rounding struct {
+	Minutes int64
+	Mode    string
+}
+
+const (
+	RoundUp      = "up"
+	RoundDown    = "down"
+	RoundNearest = "nearest"
+)
+
+// Apply converts a duration in seconds to billed minutes.
+func (r Rounding) Apply(seconds int64) int64 {
+	step := r.Minutes * 60
+	if step <= 0 {
+		step = 60
+	}
+	var n int64
+	switch r.Mode {
+	case RoundDown:
+		n = seconds / step
+	case RoundNearest:
+		n = (seconds + step/2) / step
+	default:
+		n = (seconds + step - 1) / step
+	}
+	return n * step / 60
+}
+
+func (r Rounding) String() string {
+	if r.Minutes <= 1 {
+		return "to the minute"
+	}
+	switch r.Mode {
+	case RoundDown:
+		return fmt.Sprintf("down to %d minutes", r.Minutes)
+	case RoundNearest:
+		return fmt.Sprintf("to the nearest %d minutes", r.Minutes)
+	default:
+		return fmt.Sprintf("up to %d minutes", r.Minutes)
+	}
+}
+
+func ParseRounding(every time.Duration, mode string) (Rounding, error) {
+	switch mode {
+	case RoundUp, RoundDown, RoundNearest:
+	default:
+		return Rounding{}, fmt.Errorf("invalid rounding mode %q: use up, down or nearest", mode)
+	}
+	if every < time.Minute || every%time.Minute != 0 {
+		return Rounding{}, fmt.Errorf("rounding must be a whole number of minutes, got %s", every)
+	}
+	return Rounding{Minutes: int64(every / time.Minute), Mode: mode}, nil
+}
+
+// Item is one billed activity.
+type Item struct {
+	Date        time.Time
+	Activity    string
+	Description string
+	Minutes     int64
+}
+
+// Line groups the items of one project at its hourly rate, in minor units
+// of the invoice currency.
+type Line struct {
+	Project    string
+	HourlyRate int64
+	Items      []Item
+}
+
+func (l Line) Minutes() int64 {
+	var total int64
+	for _, it := range l.Items {
+		total += it.Minutes
+	}
+	return total
+}
+
+// Amount is the line total, rounded to the nearest minor unit.
+func (l Line) Amount() int64 {
+	return (l.Minutes()*l.HourlyRate + 30) / 60
+}
+
+type Invoice struct {
+	Number      string
+	Client      string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	IssuedAt    time.Time
+	Currency    string
+	Rounding    Rounding
+	Lines       []Line
+}
+
+func (inv Invoice) Total() int64 {
+	var total int64
+	for _, l := range inv.Lines {
+		total += l.Amount()
+	}
+	return total
+}
+
+// FormatMoney renders an amount in minor units, e.g. 12050 EUR as "120.50 EUR".
+func FormatMoney(amount int64, currency string) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%02d %s", sign, amount/100, amount%100, currency)
+}
+
+// ParseMoney reads an amount such as "120" or "120.5" into minor units.
+func ParseMoney(s string) (int64, error) {
+	whole, frac, _ := strings.Cut(strings.TrimSpace(s), ".")
+	if len(frac) > 2 {
+		return 0, fmt.Errorf("invalid amount %q: at most two decimals", s)
+	}
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	var f int64
+	if frac != "" {
+		f, err = strconv.ParseInt(frac+strings.Repeat("0", 2-len(frac)), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid amount %q", s)
+		}
+	}
+	return w*100 + f, nil
+}
+
+func formatHours(minutes int64) string {
+	return fmt.Sprintf("%d:%02d", minutes/60, minutes%60)
+}
+
+// Render produces the invoice as "markdown", "html" or "text".
+func Render(inv Invoice, format string) (string, error) {
+	switch format {
+	case "markdown", "md":
+		return Markdown(inv), nil
+	case "html":
+		return HTML(inv), nil
+	case "text", "txt":
+		return Text(inv), nil
+	}
+	return "", fmt.Errorf("unknown invoice format %q: use markdown, html or text", format)
+}
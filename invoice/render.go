@@ -0,0 +1,120 @@
+package invoice
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+const dateFormat = "2006-01-02"
+
+// period formats the billed range. PeriodEnd is exclusive, so the last day
+// shown is the one before it.
+func (inv Invoice) period() string {
+	return fmt.Sprintf("%s – %s",
+		inv.PeriodStart.Format(dateFormat),
+		inv.PeriodEnd.AddDate(0, 0, -1).Format(dateFormat))
+}
+
+func Markdown(inv Invoice) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Invoice %s\n\n", inv.Number)
+	fmt.Fprintf(&b, "- **Client:** %s\n", inv.Client)
+	fmt.Fprintf(&b, "- **Period:** %s\n", inv.period())
+	fmt.Fprintf(&b, "- **Issued:** %s\n", inv.IssuedAt.Format(dateFormat))
+	fmt.Fprintf(&b, "- **Rounding:** %s\n", inv.Rounding)
+
+	for _, l := range inv.Lines {
+		fmt.Fprintf(&b, "\n## %s\n\n", l.Project)
+		b.WriteString("| Date | Activity | Description | Hours |\n")
+		b.WriteString("|------|----------|-------------|------:|\n")
+		for _, it := range l.Items {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n",
+				it.Date.Format(dateFormat), markdownCell(it.Activity), markdownCell(it.Description), formatHours(it.Minutes))
+		}
+		fmt.Fprintf(&b, "\n%s h × %s = **%s**\n",
+			formatHours(l.Minutes()), FormatMoney(l.HourlyRate, inv.Currency), FormatMoney(l.Amount(), inv.Currency))
+	}
+
+	fmt.Fprintf(&b, "\n**Total: %s**\n", FormatMoney(inv.Total(), inv.Currency))
+	return b.String()
+}
+
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+func HTML(inv Invoice) string {
+	var b strings.Builder
+	e := html.EscapeString
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<title>Invoice %s</title>\n", e(inv.Number))
+	b.WriteString(`<style>
+body { font-family: sans-serif; max-width: 50em; margin: 2em auto; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 0.5em; }
+th, td { border-bottom: 1px solid #ddd; padding: 0.3em 0.5em; text-align: left; }
+td.num, th.num { text-align: right; }
+.subtotal, .total { text-align: right; }
+.total { font-size: 1.2em; font-weight: bold; }
+</style>
+</head>
+<body>
+`)
+	fmt.Fprintf(&b, "<h1>Invoice %s</h1>\n", e(inv.Number))
+	b.WriteString("<dl>\n")
+	fmt.Fprintf(&b, "<dt>Client</dt><dd>%s</dd>\n", e(inv.Client))
+	fmt.Fprintf(&b, "<dt>Period</dt><dd>%s</dd>\n", e(inv.period()))
+	fmt.Fprintf(&b, "<dt>Issued</dt><dd>%s</dd>\n", inv.IssuedAt.Format(dateFormat))
+	fmt.Fprintf(&b, "<dt>Rounding</dt><dd>%s</dd>\n", e(inv.Rounding.String()))
+	b.WriteString("</dl>\n")
+
+	for _, l := range inv.Lines {
+		fmt.Fprintf(&b, "<h2>%s</h2>\n", e(l.Project))
+		b.WriteString("<table>\n<tr><th>Date</th><th>Activity</th><th>Description</th><th class=\"num\">Hours</th></tr>\n")
+		for _, it := range l.Items {
+			fmt.Fprintf(&b, "<tr><td>%s</td><td>%s</td><td>%s</td><td class=\"num\">%s</td></tr>\n",
+				it.Date.Format(dateFormat), e(it.Activity), e(it.Description), formatHours(it.Minutes))
+		}
+		b.WriteString("</table>\n")
+		fmt.Fprintf(&b, "<p class=\"subtotal\">%s h × %s = <strong>%s</strong></p>\n",
+			formatHours(l.Minutes()), e(FormatMoney(l.HourlyRate, inv.Currency)), e(FormatMoney(l.Amount(), inv.Currency)))
+	}
+
+	fmt.Fprintf(&b, "<p class=\"total\">Total: %s</p>\n", e(FormatMoney(inv.Total(), inv.Currency)))
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+func Text(inv Invoice) string {
+	const width = 72
+	var b strings.Builder
+	fmt.Fprintf(&b, "INVOICE %s\n", inv.Number)
+	b.WriteString(strings.Repeat("=", width) + "\n")
+	fmt.Fprintf(&b, "Client:   %s\n", inv.Client)
+	fmt.Fprintf(&b, "Period:   %s\n", inv.period())
+	fmt.Fprintf(&b, "Issued:   %s\n", inv.IssuedAt.Format(dateFormat))
+	fmt.Fprintf(&b, "Rounding: %s\n", inv.Rounding)
+
+	for _, l := range inv.Lines {
+		fmt.Fprintf(&b, "\n%s\n%s\n", l.Project, strings.Repeat("-", width))
+		for _, it := range l.Items {
+			fmt.Fprintf(&b, "%-10s  %-50s %8s\n",
+				it.Date.Format(dateFormat), truncate(it.Activity, 50), formatHours(it.Minutes))
+		}
+		subtotal := fmt.Sprintf("%s h x %s = %s",
+			formatHours(l.Minutes()), FormatMoney(l.HourlyRate, inv.Currency), FormatMoney(l.Amount(), inv.Currency))
+		fmt.Fprintf(&b, "%*s\n", width, subtotal)
+	}
+
+	b.WriteString("\n" + strings.Repeat("=", width) + "\n")
+	fmt.Fprintf(&b, "%*s\n", width, "TOTAL "+FormatMoney(inv.Total(), inv.Currency))
+	return b.String()
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
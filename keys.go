@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/viewport"
+)
+
+// namedBinding is a binding as it is called in the config file. Bindings
+// in the same scope are active at the same time, so a key may only appear
+// once per scope. A binding shared by several panes lists all of their
+// scopes, and global bindings are active alongside every scope.
+type namedBinding struct {
+	name    string
+	scope   string
+	binding *key.Binding
+}
+
+func (k *keyMap) named() []namedBinding {
+	return []namedBinding{
+		{"toggle-spinner", "list", &k.toggleSpinner},
+		{"toggle-title-bar", "list", &k.toggleTitleBar},
+		{"toggle-status-bar", "list", &k.toggleStatusBar},
+		{"toggle-pagination", "list", &k.togglePagination},
+		{"toggle-help-menu", "list", &k.toggleHelpMenu},
+		{"add", "list", &k.insertItem},
+		{"view", "list", &k.viewItem},
+		{"edit", "list", &k.editItem},
+		{"pause", "list", &k.pauseTimer},
+		{"toggle-billable", "list", &k.toggleBillable},
+		{"timesheet", "list", &k.showTimesheet},
+		{"summary", "list", &k.showSummary},
+		{"sync", "list", &k.sync},
+		{"conflicts", "list", &k.showConflicts},
+		{"mark", "list", &k.toggleMark},
+		{"mark-range", "list", &k.markRange},
+		{"mark-all", "list", &k.markAll},
+		{"clear-marks", "list", &k.clearMarks},
+		{"bulk-edit", "list", &k.bulkEdit},
+		{"cycle-sort", "list", &k.cycleSort},
+		{"cycle-group", "list", &k.cycleGroup},
+		{"new-template", "list", &k.newTemplate},
+		{"filter-form", "list", &k.filterForm},
+		{"cursor-up", "list", &k.cursorUp},
+		{"cursor-down", "list", &k.cursorDown},
+		{"next-page", "list", &k.nextPage},
+		{"prev-page", "list", &k.prevPage},
+		{"go-to-start", "list", &k.goToStart},
+		{"go-to-end", "list", &k.goToEnd},
+		{"filter", "list", &k.filter},
+		{"help", "list", &k.showHelp},
+		{"quit", "list", &k.quit},
+		{"form-next", "form", &k.formNext},
+		{"form-prev", "form", &k.formPrev},
+		{"form-submit", "form", &k.formSubmit},
+		{"form-save", "form multiline", &k.formSave},
+		{"form-editor", "form multiline", &k.formEditor},
+		{"form-cancel", "form multiline", &k.formCancel},
+		{"form-line-up", "multiline", &k.formLineUp},
+		{"form-line-down", "multiline", &k.formLineDown},
+		{"form-newline", "multiline", &k.formNewline},
+		{"scroll-up", "view", &k.scrollUp},
+		{"scroll-down", "view", &k.scrollDown},
+		{"page-up", "view", &k.pageUp},
+		{"page-down", "view", &k.pageDown},
+		{"half-page-up", "view", &k.halfPageUp},
+		{"half-page-down", "view", &k.halfPageDown},
+		{"close", "view", &k.closeView},
+		{"history", "view", &k.showHistory},
+		{"back", "timesheet summary history conflicts bulk suggest", &k.back},
+		{"select-up", "history conflicts", &k.selectUp},
+		{"select-down", "history conflicts", &k.selectDown},
+		{"prev-week", "timesheet summary", &k.prevWeek},
+		{"next-week", "timesheet summary", &k.nextWeek},
+		{"copy-markdown", "timesheet", &k.copyMarkdown},
+		{"copy-html", "timesheet", &k.copyHTML},
+		{"regenerate", "summary", &k.regenerate},
+		{"revert", "history", &k.revert},
+		{"keep", "conflicts", &k.keepConflict},
+		{"use-other", "conflicts", &k.useOther},
+		{"bulk-project", "bulk", &k.bulkProject},
+		{"bulk-add-tags", "bulk", &k.bulkAddTags},
+		{"bulk-remove-tags", "bulk", &k.bulkRemoveTags},
+		{"bulk-billable", "bulk", &k.bulkBillable},
+		{"bulk-not-billable", "bulk", &k.bulkNotBillable},
+		{"bulk-shift", "bulk", &k.bulkShift},
+		{"bulk-export", "bulk", &k.bulkExport},
+		{"bulk-delete", "bulk", &k.bulkDelete},
+		{"confirm-yes", "confirm", &k.confirmYes},
+		{"confirm-no", "confirm", &k.confirmNo},
+		{"idle-keep", "idle", &k.idleKeep},
+		{"idle-discard", "idle", &k.idleDiscard},
+		{"idle-split", "idle", &k.idleSplit},
+		{"accept", "suggest", &k.accept},
+		{"edit-suggestion", "suggest", &k.editSuggestion},
+		{"reject", "suggest", &k.reject},
+		{"dismiss-error", "global", &k.dismissError},
+		{"retry", "global", &k.retry},
+		{"force-quit", "global", &k.forceQuit},
+	}
+}
+
+// keyNames are the help labels for keys whose names are awkward to read.
+var keyNames = map[string]string{
+	" ":     "space",
+	"up":    "↑",
+	"down":  "↓",
+	"left":  "←",
+	"right": "→",
+}
+
+// loadKeyMap returns the default bindings with the overrides from the
+// config applied. An empty list of keys disables a binding. Unknown names
+// and keys bound twice within a scope are errors.
+func loadKeyMap(overrides map[string][]string) (keyMap, error) {
+	keys := newKeyMap()
+	named := keys.named()
+	byName := make(map[string]*key.Binding, len(named))
+	for _, n := range named {
+		byName[n.name] = n.binding
+	}
+
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ks := overrides[name]
+		b, ok := byName[name]
+		if !ok {
+			return keys, fmt.Errorf("unknown key binding %q", name)
+		}
+		if len(ks) == 0 {
+			// The list re-enables its bindings as it changes state, so
+			// drop the keys as well.
+			b.SetKeys()
+			b.SetEnabled(false)
+			continue
+		}
+		labels := make([]string, len(ks))
+		for i, k := range ks {
+			labels[i] = k
+			if l, ok := keyNames[k]; ok {
+				labels[i] = l
+			}
+		}
+		b.SetKeys(ks...)
+		b.SetHelp(strings.Join(labels, "/"), b.Help().Desc)
+	}
+
+	var scopes []string
+	for _, n := range named {
+		for _, scope := range strings.Fields(n.scope) {
+			if scope != "global" && !slices.Contains(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	var conflicts []string
+	bound := map[string]string{}
+	for _, n := range named {
+		if !n.binding.Enabled() {
+			continue
+		}
+		in := strings.Fields(n.scope)
+		if n.scope == "global" {
+			in = scopes
+		}
+		for _, k := range n.binding.Keys() {
+			for _, scope := range in {
+				slot := scope + "\x00" + k
+				if other, ok := bound[slot]; ok {
+					conflicts = append(conflicts, fmt.Sprintf("%q is bound to both %s and %s in the %s keys", k, other, n.name, scope))
+					continue
+				}
+				bound[slot] = n.name
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		conflicts = slices.Compact(conflicts)
+		return keys, fmt.Errorf("conflicting key bindings: %s", strings.Join(conflicts, "; "))
+	}
+	return keys, nil
+}
+
+// listKeyMap hands the navigation bindings and force quit to the list,
+// keeping its own bindings for filtering.
+func (k keyMap) listKeyMap() list.KeyMap {
+	km := list.DefaultKeyMap()
+	km.CursorUp = k.cursorUp
+	km.CursorDown = k.cursorDown
+	km.NextPage = k.nextPage
+	km.PrevPage = k.prevPage
+	km.GoToStart = k.goToStart
+	km.GoToEnd = k.goToEnd
+	km.Filter = k.filter
+	km.ShowFullHelp = k.showHelp
+	km.CloseFullHelp = k.showHelp
+	km.CloseFullHelp.SetHelp(k.showHelp.Help().Key, "close help")
+	km.Quit = k.quit
+	km.ForceQuit = k.forceQuit
+	return km
+}
+
+func (k keyMap) viewportKeyMap() viewport.KeyMap {
+	return viewport.KeyMap{
+		PageDown:     k.pageDown,
+		PageUp:       k.pageUp,
+		HalfPageUp:   k.halfPageUp,
+		HalfPageDown: k.halfPageDown,
+		Up:           k.scrollUp,
+		Down:         k.scrollDown,
+	}
+}
+
+// helpLine joins bindings into a line of help, leaving out disabled ones.
+func helpLine(bindings ...key.Binding) string {
+	var parts []string
+	for _, b := range bindings {
+		if b.Enabled() {
+			parts = append(parts, b.Help().Key+": "+b.Help().Desc)
+		}
+	}
+	return strings.Join(parts, " • ")
+}
+
+// withDesc is b described for a screen where it does something more
+// specific than its default help says.
+func withDesc(b key.Binding, desc string) key.Binding {
+	b.SetHelp(b.Help().Key, desc)
+	return b
+}
@@ -1,68 +1,79 @@
 package main
 
 // https://www.alexedwards.net/blog/introduction-to-using-sql-databases-in-go
-// https://stackoverflow.com/questions/32746858/how-to-represent-postgresql-interval-in-go
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
-	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Proqpine/probable-memory/config"
+	"github.com/Proqpine/probable-memory/form"
+	"github.com/Proqpine/probable-memory/goals"
 	"github.com/Proqpine/probable-memory/sqlite"
-	"github.com/Proqpine/probable-memory/src"
+	"github.com/Proqpine/probable-memory/store"
+	"github.com/Proqpine/probable-memory/timesheet"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	_ "github.com/mattn/go-sqlite3"
-)
-
-const (
-	hotPink  = lipgloss.Color("#FF06B7")
-	darkGray = lipgloss.Color("#767676")
-)
-
-var (
-	appStyle   = lipgloss.NewStyle().Padding(1, 2)
-	titleStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FFFDF5")).
-			Background(lipgloss.Color("#25A065")).
-			Padding(0, 1)
-	statusMessage = lipgloss.NewStyle().
-			Foreground(lipgloss.AdaptiveColor{Light: "#04B575", Dark: "#04B575"}).
-			Render()
-	infoStyle = func() lipgloss.Style {
-		b := lipgloss.RoundedBorder()
-		b.Left = "┤"
-		return titleStyle.BorderStyle(b)
-	}()
-	inputStyle    = lipgloss.NewStyle().Foreground(hotPink)
-	continueStyle = lipgloss.NewStyle().Foreground(darkGray)
 )
 
 type model struct {
 	list                  list.Model
-	Queries               *sqlite.Queries
+	ctx                   context.Context
+	Store                 store.ActivityStore
+	Config                config.Config
 	Activities            []sqlite.Activity
 	SelectedActivity      *sqlite.Activity
-	WeeklyProgressSummary *string
+	WeeklyProgressSummary string
 	IsGeneratingSummary   bool
 	Error                 error
+	retry                 tea.Cmd
 	Loading               bool
 	keys                  keyMap
-	addingActivity        bool
-	inputs                []textinput.Model
-	inputIndex            int
+	form                  form.Model
+	showingForm           bool
+	filter                activityFilter
+	tags                  map[int64][]string
 	viewport              viewport.Model
 	viewingActivity       bool
-	editingActivity       bool
-	editInputs            []textinput.Model
-	editInputIndex        int
+	notes                 *notesRenderer
+	running               *sqlite.Activity
+	runningSegments       []sqlite.TimeSegment
+	selectedSegments      []sqlite.TimeSegment
+	idlePrompt            bool
+	goals                 []goals.Progress
+	width                 int
+	height                int
+	viewingTimesheet      bool
+	timesheetWeek         time.Time
+	timesheet             timesheet.Timesheet
+	timesheetStatus       string
+	viewingHistory        bool
+	history               []historyEntry
+	historyIndex          int
+	selectedTags          []string
+	marked                map[int64]bool
+	markAnchor            int
+	bulkMenu              bool
+	bulkAction            bulkAction
+	bulkInput             textinput.Model
+	bulkErr               string
+	viewingConflicts      bool
+	conflicts             []syncConflict
+	conflictIndex         int
+	viewingSummary        bool
+	summaryWeek           time.Time
+	summaryStatus         string
+	summaryRun            int
+	summaryEvents         <-chan tea.Msg
+	cancelSummary         context.CancelFunc
 }
 
 type keyMap struct {
@@ -74,14 +85,133 @@ type keyMap struct {
 	insertItem       key.Binding
 	viewItem         key.Binding
 	editItem         key.Binding
+	pauseTimer       key.Binding
+	toggleBillable   key.Binding
+	showTimesheet    key.Binding
+	showSummary      key.Binding
+	toggleMark       key.Binding
+	markRange        key.Binding
+	markAll          key.Binding
+	clearMarks       key.Binding
+	bulkEdit         key.Binding
+	cycleSort        key.Binding
+	cycleGroup       key.Binding
+	newTemplate      key.Binding
+	filterForm       key.Binding
+	sync             key.Binding
+	showConflicts    key.Binding
+
+	// Navigation handed to the list, the forms and the activity viewport.
+	cursorUp     key.Binding
+	cursorDown   key.Binding
+	nextPage     key.Binding
+	prevPage     key.Binding
+	goToStart    key.Binding
+	goToEnd      key.Binding
+	filter       key.Binding
+	showHelp     key.Binding
+	quit         key.Binding
+	formNext     key.Binding
+	formPrev     key.Binding
+	formSubmit   key.Binding
+	formSave     key.Binding
+	formEditor   key.Binding
+	formCancel   key.Binding
+	formLineUp   key.Binding
+	formLineDown key.Binding
+	formNewline  key.Binding
+	scrollUp     key.Binding
+	scrollDown   key.Binding
+	pageUp       key.Binding
+	pageDown     key.Binding
+	halfPageUp   key.Binding
+	halfPageDown key.Binding
+	closeView    key.Binding
+	showHistory  key.Binding
+
+	// The timesheet, summary, history, conflicts and bulk edit panes.
+	back            key.Binding
+	selectUp        key.Binding
+	selectDown      key.Binding
+	prevWeek        key.Binding
+	nextWeek        key.Binding
+	copyMarkdown    key.Binding
+	copyHTML        key.Binding
+	regenerate      key.Binding
+	revert          key.Binding
+	keepConflict    key.Binding
+	useOther        key.Binding
+	bulkProject     key.Binding
+	bulkAddTags     key.Binding
+	bulkRemoveTags  key.Binding
+	bulkBillable    key.Binding
+	bulkNotBillable key.Binding
+	bulkShift       key.Binding
+	bulkExport      key.Binding
+	bulkDelete      key.Binding
+	confirmYes      key.Binding
+	confirmNo       key.Binding
+
+	// The idle prompt and the suggest review.
+	idleKeep       key.Binding
+	idleDiscard    key.Binding
+	idleSplit      key.Binding
+	accept         key.Binding
+	editSuggestion key.Binding
+	reject         key.Binding
+
+	// Available on every screen while an error is shown.
+	dismissError key.Binding
+	retry        key.Binding
+	forceQuit    key.Binding
 }
 
 func main() {
-	src.SummariseActivities()
-	dbConnection := setupDBConnection()
-	defer dbConnection.Close()
-	db := sqlite.New(dbConnection)
-	p := tea.NewProgram(initialModel(db))
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:]); err != nil {
+			var code exitCode
+			if errors.As(err, &code) {
+				os.Exit(int(code))
+			}
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("Error loading config: %v", err)
+		os.Exit(1)
+	}
+	keys, err := loadKeyMap(cfg.Keys)
+	if err != nil {
+		fmt.Printf("Error loading config: %v", err)
+		os.Exit(1)
+	}
+	t, err := loadTheme(cfg)
+	if err != nil {
+		fmt.Printf("Error loading config: %v", err)
+		os.Exit(1)
+	}
+	applyTheme(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	st, closeStore, err := openStore(ctx, cfg)
+	if err != nil {
+		fmt.Printf("Error opening database: %v", err)
+		os.Exit(1)
+	}
+	defer closeStore()
+	ctx, err = currentUser(ctx, st, cfg)
+	if err != nil {
+		fmt.Printf("Error loading user: %v", err)
+		os.Exit(1)
+	}
+	// Cancelled on the way out, before the store is closed, so that
+	// queries still running are abandoned.
+	defer cancel()
+	p := tea.NewProgram(initialModel(ctx, st, cfg, keys), tea.WithContext(ctx))
+	go runBackups(ctx, cfg, func(err error) { p.Send(errorMsg{err}) })
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Error running program: %v", err)
 		os.Exit(1)
@@ -90,6 +220,66 @@ func main() {
 
 func newKeyMap() keyMap {
 	return keyMap{
+		cycleSort: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "change sort"),
+		),
+		cycleGroup: key.NewBinding(
+			key.WithKeys("O"),
+			key.WithHelp("O", "change grouping"),
+		),
+		newTemplate: key.NewBinding(
+			key.WithKeys("N"),
+			key.WithHelp("N", "new template"),
+		),
+		filterForm: key.NewBinding(
+			key.WithKeys("F"),
+			key.WithHelp("F", "filter by fields"),
+		),
+		toggleMark: key.NewBinding(
+			key.WithKeys(" "),
+			key.WithHelp("space", "mark"),
+		),
+		markRange: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "mark range"),
+		),
+		markAll: key.NewBinding(
+			key.WithKeys("ctrl+a"),
+			key.WithHelp("ctrl+a", "mark all shown"),
+		),
+		clearMarks: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "clear marks"),
+		),
+		bulkEdit: key.NewBinding(
+			key.WithKeys("B"),
+			key.WithHelp("B", "bulk edit marked"),
+		),
+		showTimesheet: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "timesheet"),
+		),
+		showSummary: key.NewBinding(
+			key.WithKeys("W"),
+			key.WithHelp("W", "summarise week"),
+		),
+		sync: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "sync"),
+		),
+		showConflicts: key.NewBinding(
+			key.WithKeys("Y"),
+			key.WithHelp("Y", "sync conflicts"),
+		),
+		toggleBillable: key.NewBinding(
+			key.WithKeys("b"),
+			key.WithHelp("b", "toggle billable"),
+		),
+		pauseTimer: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "pause/resume timer"),
+		),
 		editItem: key.NewBinding(
 			key.WithKeys("e"),
 			key.WithHelp("e", "edit item"),
@@ -122,6 +312,230 @@ func newKeyMap() keyMap {
 			key.WithKeys("H"),
 			key.WithHelp("H", "toggle help"),
 		),
+		cursorUp: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "up"),
+		),
+		cursorDown: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "down"),
+		),
+		nextPage: key.NewBinding(
+			key.WithKeys("right", "l", "pgdown", "f", "d"),
+			key.WithHelp("→/l/pgdn", "next page"),
+		),
+		prevPage: key.NewBinding(
+			key.WithKeys("left", "h", "pgup", "u"),
+			key.WithHelp("←/h/pgup", "prev page"),
+		),
+		goToStart: key.NewBinding(
+			key.WithKeys("home", "g"),
+			key.WithHelp("g/home", "go to start"),
+		),
+		goToEnd: key.NewBinding(
+			key.WithKeys("end", "G"),
+			key.WithHelp("G/end", "go to end"),
+		),
+		filter: key.NewBinding(
+			key.WithKeys("/"),
+			key.WithHelp("/", "filter"),
+		),
+		showHelp: key.NewBinding(
+			key.WithKeys("?"),
+			key.WithHelp("?", "more"),
+		),
+		quit: key.NewBinding(
+			key.WithKeys("q", "esc"),
+			key.WithHelp("q", "quit"),
+		),
+		formNext: key.NewBinding(
+			key.WithKeys("down", "tab"),
+			key.WithHelp("↓/tab", "next field"),
+		),
+		formPrev: key.NewBinding(
+			key.WithKeys("up", "shift+tab"),
+			key.WithHelp("↑/shift+tab", "previous field"),
+		),
+		formSubmit: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "next field/save"),
+		),
+		formSave: key.NewBinding(
+			key.WithKeys("ctrl+s"),
+			key.WithHelp("ctrl+s", "save"),
+		),
+		dismissError: key.NewBinding(
+			key.WithKeys("ctrl+x"),
+			key.WithHelp("ctrl+x", "dismiss error"),
+		),
+		retry: key.NewBinding(
+			key.WithKeys("ctrl+r"),
+			key.WithHelp("ctrl+r", "retry"),
+		),
+		formEditor: key.NewBinding(
+			key.WithKeys("ctrl+o"),
+			key.WithHelp("ctrl+o", "open in $EDITOR"),
+		),
+		formCancel: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "cancel"),
+		),
+		formLineUp: key.NewBinding(
+			key.WithKeys("up"),
+			key.WithHelp("↑", "line up"),
+		),
+		formLineDown: key.NewBinding(
+			key.WithKeys("down"),
+			key.WithHelp("↓", "line down"),
+		),
+		formNewline: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "new line"),
+		),
+		scrollUp: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "up"),
+		),
+		scrollDown: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "down"),
+		),
+		pageUp: key.NewBinding(
+			key.WithKeys("pgup", "b"),
+			key.WithHelp("b/pgup", "page up"),
+		),
+		pageDown: key.NewBinding(
+			key.WithKeys("pgdown", " ", "f"),
+			key.WithHelp("f/pgdn", "page down"),
+		),
+		halfPageUp: key.NewBinding(
+			key.WithKeys("u", "ctrl+u"),
+			key.WithHelp("u", "½ page up"),
+		),
+		halfPageDown: key.NewBinding(
+			key.WithKeys("d", "ctrl+d"),
+			key.WithHelp("d", "½ page down"),
+		),
+		closeView: key.NewBinding(
+			key.WithKeys("q", "esc"),
+			key.WithHelp("esc", "back"),
+		),
+		showHistory: key.NewBinding(
+			key.WithKeys("h"),
+			key.WithHelp("h", "history"),
+		),
+		back: key.NewBinding(
+			key.WithKeys("esc", "q"),
+			key.WithHelp("esc", "back"),
+		),
+		selectUp: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "up"),
+		),
+		selectDown: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "down"),
+		),
+		prevWeek: key.NewBinding(
+			key.WithKeys("left", "h"),
+			key.WithHelp("←/h", "previous week"),
+		),
+		nextWeek: key.NewBinding(
+			key.WithKeys("right", "l"),
+			key.WithHelp("→/l", "next week"),
+		),
+		copyMarkdown: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "copy Markdown"),
+		),
+		copyHTML: key.NewBinding(
+			key.WithKeys("C"),
+			key.WithHelp("C", "copy HTML"),
+		),
+		regenerate: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "write a new one"),
+		),
+		revert: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "revert to selected version"),
+		),
+		keepConflict: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "keep"),
+		),
+		useOther: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "use other"),
+		),
+		bulkProject: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "change project"),
+		),
+		bulkAddTags: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "add tags"),
+		),
+		bulkRemoveTags: key.NewBinding(
+			key.WithKeys("T"),
+			key.WithHelp("T", "remove tags"),
+		),
+		bulkBillable: key.NewBinding(
+			key.WithKeys("b"),
+			key.WithHelp("b", "mark billable"),
+		),
+		bulkNotBillable: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "mark not billable"),
+		),
+		bulkShift: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "shift times"),
+		),
+		bulkExport: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "export to CSV"),
+		),
+		bulkDelete: key.NewBinding(
+			key.WithKeys("d"),
+			key.WithHelp("d", "delete"),
+		),
+		confirmYes: key.NewBinding(
+			key.WithKeys("y", "Y"),
+			key.WithHelp("y", "delete"),
+		),
+		confirmNo: key.NewBinding(
+			key.WithKeys("n", "N", "esc"),
+			key.WithHelp("n", "cancel"),
+		),
+		idleKeep: key.NewBinding(
+			key.WithKeys("k"),
+			key.WithHelp("k", "keep the idle time"),
+		),
+		idleDiscard: key.NewBinding(
+			key.WithKeys("d"),
+			key.WithHelp("d", "discard it and stop the activity"),
+		),
+		idleSplit: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "split it off and keep tracking from now"),
+		),
+		accept: key.NewBinding(
+			key.WithKeys("a", "enter"),
+			key.WithHelp("a", "accept"),
+		),
+		editSuggestion: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "edit"),
+		),
+		reject: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "reject"),
+		),
+		forceQuit: key.NewBinding(
+			key.WithKeys("ctrl+c"),
+			key.WithHelp("ctrl+c", "quit"),
+		),
 	}
 }
 
@@ -139,23 +553,45 @@ type errorMsg struct {
 
 type fetchActivitiesMsg struct {
 	activities []sqlite.Activity
+	tags       map[int64][]string
 }
 
 func (m model) Init() tea.Cmd {
-	return m.fetchActivities
+	return tea.Batch(m.fetchActivities, m.fetchRunningActivity, m.fetchGoals)
 }
 
-func initialModel(queries *sqlite.Queries) model {
-	keys := newKeyMap()
-	l := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+func initialModel(ctx context.Context, st store.ActivityStore, cfg config.Config, keys keyMap) model {
+	marked := map[int64]bool{}
+	delegate := list.NewDefaultDelegate()
+	delegate.Styles = itemStyles()
+	l := list.New([]list.Item{}, markDelegate{delegate, marked}, 0, 0)
 	l.Title = "Activities"
-	l.Styles.Title = titleStyle
+	l.Styles = listStyles()
+	l.Help.Styles = helpStyles()
+	l.FilterInput.PromptStyle = l.Styles.FilterPrompt
+	l.FilterInput.Cursor.Style = l.Styles.FilterCursor
+	l.KeyMap = keys.listKeyMap()
 	l.AdditionalFullHelpKeys = func() []key.Binding {
 		return []key.Binding{
 			keys.toggleSpinner,
 			keys.insertItem,
 			keys.viewItem,
 			keys.editItem,
+			keys.pauseTimer,
+			keys.toggleBillable,
+			keys.showTimesheet,
+			keys.showSummary,
+			keys.sync,
+			keys.showConflicts,
+			keys.toggleMark,
+			keys.markRange,
+			keys.markAll,
+			keys.clearMarks,
+			keys.bulkEdit,
+			keys.cycleSort,
+			keys.cycleGroup,
+			keys.newTemplate,
+			keys.filterForm,
 			keys.toggleTitleBar,
 			keys.toggleStatusBar,
 			keys.togglePagination,
@@ -164,57 +600,45 @@ func initialModel(queries *sqlite.Queries) model {
 	}
 	m := model{
 		list:             l,
-		Queries:          queries,
+		ctx:              ctx,
+		Store:            st,
+		Config:           cfg,
 		Activities:       []sqlite.Activity{},
 		Loading:          true,
 		keys:             keys,
-		addingActivity:   false,
-		inputs:           make([]textinput.Model, 5),
 		SelectedActivity: nil,
 		viewport:         viewport.New(80, 20),
 		viewingActivity:  false,
-		editingActivity:  false,
-		editInputs:       make([]textinput.Model, 5),
-		editInputIndex:   0,
-	}
-	for i := range m.editInputs {
-		t := textinput.New()
-		switch i {
-		case 0:
-			t.Placeholder = "Activity Name"
-		case 1:
-			t.Placeholder = "Description"
-		case 2:
-			t.Placeholder = "Project"
-		case 3:
-			t.Placeholder = "Notes"
-		case 4:
-			t.Placeholder = "Duration (in seconds)"
-		}
-		m.editInputs[i] = t
+		notes:            &notesRenderer{},
+		marked:           marked,
+		markAnchor:       -1,
+		bulkInput:        newBulkInput(),
 	}
-	for i := range m.inputs {
-		t := textinput.New()
-		switch i {
-		case 0:
-			t.Placeholder = "Activity Name"
-		case 1:
-			t.Placeholder = "Description"
-		case 2:
-			t.Placeholder = "Project"
-		case 3:
-			t.Placeholder = "Notes"
-		case 4:
-			t.Placeholder = "Duration (in seconds)"
-		}
-		m.inputs[i] = t
-	}
-	m.inputs[0].Focus()
+	m.viewport.KeyMap = keys.viewportKeyMap()
 
 	return m
 }
 
+// Update checks the running activity for idle time on user input, recording
+// a heartbeat when it is still active, before handing the message on.
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var heartbeat tea.Cmd
+	if _, ok := msg.(tea.KeyMsg); ok && m.running != nil && !m.idlePrompt {
+		if m.isIdle() {
+			return m, m.checkIdle
+		}
+		if time.Since(lastSeen(*m.running)) > heartbeatInterval {
+			a := *m.running
+			a.HeartbeatAt = sql.NullTime{Time: time.Now(), Valid: true}
+			m.running = &a
+			heartbeat = m.touchRunning
+		}
+	}
+	next, cmd := m.update(msg)
+	return next, tea.Batch(heartbeat, cmd)
+}
+
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var (
 		cmd  tea.Cmd
 		cmds []tea.Cmd
@@ -222,64 +646,95 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		h, v := appStyle.GetFrameSize()
-		if m.viewingActivity {
-			m.viewport.Width = msg.Width
-			m.viewport.Height = msg.Height
-		} else {
-			m.list.SetSize(msg.Width-h, msg.Height-v)
-		}
+		// The list is resized even when hidden, so that it fits when the
+		// activity view is closed.
+		m.width, m.height = msg.Width, msg.Height
+		m.resize()
 
 	case tea.KeyMsg:
-		if m.editingActivity {
-			switch msg.String() {
-			case "up":
-				m.editInputIndex = max(0, m.editInputIndex-1)
-				m.editInputs[m.editInputIndex].Focus()
-				return m, nil
-			case "down":
-				m.editInputIndex = min(len(m.editInputs)-1, m.editInputIndex+1)
-				m.editInputs[m.editInputIndex].Focus()
-				return m, nil
-			case "esc":
-				m.editingActivity = false
-				m.editInputIndex = 0
+		if m.Error != nil {
+			switch {
+			case key.Matches(msg, m.keys.dismissError):
+				m.dismissError()
 				return m, nil
+			case key.Matches(msg, m.keys.retry) && m.retry != nil:
+				retry := m.retry
+				m.dismissError()
+				return m, retry
 			}
-			if m.editInputIndex == len(m.editInputs)-1 && msg.String() == "enter" {
-				return m, m.updateActivity
-			}
+		}
+		if m.idlePrompt {
+			return m.updateIdlePrompt(msg)
+		}
+		if m.viewingTimesheet {
+			return m.updateTimesheet(msg)
+		}
+		if m.viewingConflicts {
+			return m.updateConflicts(msg)
+		}
+		if m.viewingSummary {
+			return m.updateSummary(msg)
+		}
+		if m.bulkMenu {
+			return m.updateBulk(msg)
+		}
+		if m.showingForm {
 			var cmd tea.Cmd
-			m.editInputs[m.editInputIndex], cmd = m.editInputs[m.editInputIndex].Update(msg)
+			m.form, cmd = m.form.Update(msg)
 			return m, cmd
+		}
+		if m.viewingHistory {
+			return m.updateHistory(msg)
 		} else if m.viewingActivity {
-			switch msg.String() {
-			case "q", "esc":
+			switch {
+			case key.Matches(msg, m.keys.closeView):
 				m.viewingActivity = false
 				m.SelectedActivity = nil
 				return m, nil
+			case key.Matches(msg, m.keys.showHistory):
+				m.viewingHistory = true
+				m.history = nil
+				m.historyIndex = 0
+				return m, m.fetchHistory
 			}
 			var cmd tea.Cmd
 			m.viewport, cmd = m.viewport.Update(msg)
 			return m, cmd
-		} else if m.addingActivity {
-			switch msg.String() {
-			case "enter":
-				if m.inputIndex == len(m.inputs)-1 {
-					return m, m.addActivity
-				}
-				m.inputIndex++
-				m.inputs[m.inputIndex].Focus()
+		} else if m.list.FilterState() != list.Filtering {
+			switch {
+			case key.Matches(msg, m.keys.toggleMark):
+				m.toggleMark()
 				return m, nil
-			case "esc":
-				m.addingActivity = false
+
+			case key.Matches(msg, m.keys.markRange):
+				m.markRange()
 				return m, nil
-			}
-			var cmd tea.Cmd
-			m.inputs[m.inputIndex], cmd = m.inputs[m.inputIndex].Update(msg)
-			return m, cmd
-		} else {
-			switch {
+
+			case key.Matches(msg, m.keys.markAll):
+				m.markAll()
+				return m, nil
+
+			case key.Matches(msg, m.keys.clearMarks):
+				m.clearMarks()
+				return m, nil
+
+			case key.Matches(msg, m.keys.bulkEdit):
+				if len(m.markedActivities()) == 0 {
+					cmd := m.list.NewStatusMessage("No activities are marked")
+					return m, cmd
+				}
+				m.bulkMenu = true
+				m.bulkAction = bulkNone
+				return m, nil
+
+			case key.Matches(msg, m.keys.cycleSort):
+				m.markAnchor = -1
+				return m.cycleSort()
+
+			case key.Matches(msg, m.keys.cycleGroup):
+				m.markAnchor = -1
+				return m.cycleGroup()
+
 			case key.Matches(msg, m.keys.toggleSpinner):
 				cmd := m.list.ToggleSpinner()
 				return m, cmd
@@ -304,198 +759,285 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 
 			case key.Matches(msg, m.keys.insertItem):
-				m.addingActivity = true
-				return m, nil
+				return m.openForm(m.addForm())
+
+			case key.Matches(msg, m.keys.newTemplate):
+				return m.openForm(m.templateForm())
+
+			case key.Matches(msg, m.keys.filterForm):
+				return m.openForm(m.filterForm())
+
+			case key.Matches(msg, m.keys.pauseTimer):
+				if m.running == nil {
+					cmd := m.list.NewStatusMessage("No activity is running")
+					return m, cmd
+				}
+				return m, retryable(m.togglePause)
 
 			case key.Matches(msg, m.keys.viewItem):
 				if i, ok := m.list.SelectedItem().(item); ok {
 					m.viewingActivity = true
 					m.SelectedActivity = &i.activity
-					m.viewport.SetContent(m.activityView())
-					return m, nil
+					m.selectedSegments = nil
+					m.selectedTags = nil
+					m.resizeViewport()
+					return m, tea.Batch(m.fetchSelectedSegments, m.fetchSelectedTags)
+				}
+			case key.Matches(msg, m.keys.showTimesheet):
+				m.viewingTimesheet = true
+				m.timesheetWeek = timesheet.WeekStart(time.Now())
+				return m, m.fetchTimesheet
+
+			case key.Matches(msg, m.keys.showSummary):
+				m.viewingSummary = true
+				m.summaryWeek = timesheet.WeekStart(time.Now())
+				return m.generateSummary(false)
+
+			case key.Matches(msg, m.keys.sync):
+				if m.Config.Sync.Dir == "" {
+					cmd := m.list.NewStatusMessage("No sync directory configured")
+					return m, cmd
+				}
+				return m, retryable(m.runSync)
+
+			case key.Matches(msg, m.keys.showConflicts):
+				return m.openConflicts()
+
+			case key.Matches(msg, m.keys.toggleBillable):
+				if i, ok := m.list.SelectedItem().(item); ok {
+					if i.activity.InvoiceID.Valid {
+						cmd := m.list.NewStatusMessage("Invoiced activities are locked")
+						return m, cmd
+					}
+					m.SelectedActivity = &i.activity
+					return m, retryable(m.toggleBillable)
 				}
 			case key.Matches(msg, m.keys.editItem):
 				if i, ok := m.list.SelectedItem().(item); ok {
-					m.viewingActivity = true
+					if i.activity.InvoiceID.Valid {
+						cmd := m.list.NewStatusMessage("Invoiced activities are locked")
+						return m, cmd
+					}
 					m.SelectedActivity = &i.activity
-					m.editingActivity = true
-					m.populateEditInputs()
-					m.editInputs[0].Focus()
-					return m, nil
+					return m, m.fetchEditForm
 				}
 			}
 		}
 
+	case form.SubmitMsg:
+		return m.submitForm(msg)
+
+	case form.CancelMsg:
+		m.showingForm = false
+		if msg.ID == editFormID {
+			m.SelectedActivity = nil
+		}
+		return m, nil
+
+	case editFormMsg:
+		if m.SelectedActivity != nil && m.SelectedActivity.ID == msg.activityID {
+			return m.openForm(m.editForm(msg.tags, msg.segments))
+		}
+		return m, nil
+
+	case templateSavedMsg:
+		m.showingForm = false
+		cmd := m.list.NewStatusMessage("Saved template " + msg.name)
+		return m, cmd
+
 	case activityAddedMsg:
-		m.addingActivity = false
-		for i := range m.inputs {
-			m.inputs[i].Reset()
+		m.showingForm = false
+		status := "Added " + msg.name
+		if msg.warning != "" {
+			status += ". Over budget: " + msg.warning
 		}
-		m.inputIndex = 0
-		return m, m.fetchActivities
+		cmd := tea.Batch(m.fetchActivities, m.fetchGoals, m.list.NewStatusMessage(status))
+		return m, cmd
 
 	case fetchActivitiesMsg:
 		m.Activities = msg.activities
+		m.tags = msg.tags
 		m.Loading = false
-		items := make([]list.Item, len(m.Activities))
-		for i, a := range m.Activities {
-			items[i] = item{activity: a}
+		items := m.listItems()
+		m.pruneMarks()
+		m.list.Title = m.listTitle()
+		cmd := m.list.SetItems(items)
+		return m, cmd
+
+	case bulkAppliedMsg:
+		m.bulkMenu = false
+		m.bulkAction = bulkNone
+		m.bulkInput.Blur()
+		if !msg.keep {
+			m.clearMarks()
 		}
-		m.list.SetItems(items)
+		cmd := tea.Batch(m.fetchActivities, m.fetchRunningActivity, m.fetchGoals, m.list.NewStatusMessage(msg.status))
+		return m, cmd
+
+	case runningActivityMsg:
+		m.running = msg.activity
+		m.runningSegments = msg.segments
+		m.list.Title = m.listTitle()
 		return m, nil
 
-	case errorMsg:
-		m.Error = msg.error
-		m.Loading = false
+	case idleCheckMsg:
+		m.running = msg.activity
+		m.runningSegments = msg.segments
+		m.idlePrompt = m.isIdle()
 		return m, nil
 
-	case activityUpdatedMsg:
-		m.editingActivity = false
-		m.viewingActivity = false
+	case idleResolvedMsg:
+		m.idlePrompt = false
+		return m, tea.Batch(m.fetchActivities, m.fetchRunningActivity, m.fetchGoals)
+
+	case syncedMsg:
+		cmds := []tea.Cmd{m.fetchActivities, m.fetchRunningActivity, m.fetchGoals, m.list.NewStatusMessage(msg.result.String())}
+		if msg.result.conflicts > 0 {
+			var open tea.Cmd
+			m, open = m.openConflicts()
+			cmds = append(cmds, open)
+		}
+		return m, tea.Batch(cmds...)
+
+	case conflictsMsg:
+		m.conflicts = msg.conflicts
+		m.conflictIndex = min(m.conflictIndex, max(0, len(m.conflicts)-1))
+		return m, nil
+
+	case conflictResolvedMsg:
+		return m, tea.Batch(m.fetchConflicts, m.fetchActivities, m.fetchGoals)
+
+	case timesheetMsg:
+		m.timesheet = msg.timesheet
+		return m, nil
+
+	case copiedMsg:
+		m.timesheetStatus = fmt.Sprintf("Copied %s to clipboard", msg.what)
+		return m, nil
+
+	case summaryTokenMsg:
+		if msg.run != m.summaryRun || !m.IsGeneratingSummary {
+			return m, nil
+		}
+		m.WeeklyProgressSummary += msg.token
+		return m, waitForSummary(m.summaryEvents)
+
+	case summaryDoneMsg:
+		return m.summaryDone(msg)
+
+	case goalsMsg:
+		m.goals = msg.progress
+		m.resizeList()
+		return m, nil
+
+	case timerToggledMsg:
+		status := "Resumed"
+		if msg.paused {
+			status = "Paused"
+		}
+		cmd := tea.Batch(m.fetchRunningActivity, m.list.NewStatusMessage(status))
+		return m, cmd
+
+	case billableToggledMsg:
 		m.SelectedActivity = nil
-		for i := range m.editInputs {
-			m.editInputs[i].Reset()
+		status := "Marked not billable"
+		if msg.billable {
+			status = "Marked billable"
 		}
-		m.editInputIndex = 0
-		return m, m.fetchActivities
+		cmd := tea.Batch(m.fetchActivities, m.list.NewStatusMessage(status))
+		return m, cmd
 
-	}
+	case historyMsg:
+		if m.SelectedActivity != nil && m.SelectedActivity.ID == msg.activityID {
+			m.history = msg.entries
+			m.historyIndex = min(m.historyIndex, max(0, len(m.history)-1))
+		}
+		return m, nil
 
-	m.list, cmd = m.list.Update(msg)
-	cmds = append(cmds, cmd)
+	case activityRevertedMsg:
+		m.SelectedActivity = &msg.activity
+		m.historyIndex = 0
+		m.viewport.SetContent(m.activityView())
+		return m, tea.Batch(m.fetchHistory, m.fetchActivities, m.fetchGoals)
 
-	return m, tea.Batch(cmds...)
-}
+	case tagsMsg:
+		if m.SelectedActivity != nil && m.SelectedActivity.ID == msg.activityID {
+			m.selectedTags = msg.tags
+			m.viewport.SetContent(m.activityView())
+		}
+		return m, nil
 
-func (m model) updateActivity() tea.Msg {
-	if m.SelectedActivity == nil {
-		return errorMsg{error: fmt.Errorf("no activity selected")}
-	}
+	case segmentsMsg:
+		if m.SelectedActivity != nil && m.SelectedActivity.ID == msg.activityID {
+			m.selectedSegments = msg.segments
+			m.viewport.SetContent(m.activityView())
+		}
+		return m, nil
 
-	duration, err := strconv.ParseInt(m.editInputs[4].Value(), 10, 64)
-	if err != nil {
-		return errorMsg{error: fmt.Errorf("invalid duration: %v", err)}
-	}
+	case errorMsg:
+		var retry tea.Cmd
+		if isBusy(msg.error) {
+			// Reads are not retried one by one; reload what the list shows.
+			retry = tea.Batch(m.fetchActivities, m.fetchRunningActivity, m.fetchGoals)
+		}
+		return m, m.showError(msg.error, retry)
+
+	case writeFailedMsg:
+		return m, m.showError(msg.error, msg.retry)
+
+	case activityUpdatedMsg:
+		m.showingForm = false
+		m.SelectedActivity = nil
+		cmd := tea.Batch(m.fetchActivities, m.fetchGoals, m.list.NewStatusMessage("Saved "+msg.name))
+		return m, cmd
 
-	updatedActivity := sqlite.UpdateActivityParams{
-		ID:           m.SelectedActivity.ID,
-		ActivityName: m.editInputs[0].Value(),
-		Description:  m.editInputs[1].Value(),
-		Project:      m.editInputs[2].Value(),
-		Notes:        m.editInputs[3].Value(),
-		Duration:     sql.NullInt64{Int64: duration, Valid: true},
 	}
 
-	_, err = m.Queries.UpdateActivity(context.Background(), updatedActivity)
-	if err != nil {
-		return errorMsg{error: fmt.Errorf("failed to update activity: %v", err)}
+	if m.showingForm {
+		// Cursor blinks and the like.
+		m.form, cmd = m.form.Update(msg)
+		cmds = append(cmds, cmd)
 	}
+	m.list, cmd = m.list.Update(msg)
+	cmds = append(cmds, cmd)
 
-	return activityUpdatedMsg{}
+	return m, tea.Batch(cmds...)
 }
 
-type activityUpdatedMsg struct{}
-
 func (m model) View() string {
 	if m.Loading {
 		return "Loading activities..."
 	}
-	if m.Error != nil {
-		return fmt.Sprintf("Error: %v", m.Error)
+	return m.errorView() + m.screenView()
+}
+
+// screenView is the current screen, below any error banner.
+func (m model) screenView() string {
+	if m.idlePrompt {
+		return m.idlePromptView()
 	}
-	if m.addingActivity {
-		return m.addActivityView()
+	if m.viewingTimesheet {
+		return m.timesheetView()
 	}
-	if m.editingActivity {
-		return m.editActivityView()
+	if m.viewingConflicts {
+		return m.conflictsView()
 	}
-	if m.viewingActivity {
-		return fmt.Sprintf("%s\n%s\n%s", m.headerView(), m.viewport.View(), m.footerView())
+	if m.viewingSummary {
+		return m.summaryView()
 	}
-	return appStyle.Render(m.list.View())
-}
-
-func setupDBConnection() *sql.DB {
-	db, err := sql.Open("sqlite3", "activity.db")
-	if err != nil {
-		panic(err)
+	if m.bulkMenu {
+		return m.bulkView()
 	}
-	return db
-}
-
-func (m *model) populateEditInputs() {
-	if m.SelectedActivity == nil {
-		return
+	if m.showingForm {
+		return appStyle.Render(m.form.View())
 	}
-
-	m.editInputs[0].SetValue(m.SelectedActivity.ActivityName)
-	m.editInputs[1].SetValue(m.SelectedActivity.Description)
-	m.editInputs[2].SetValue(m.SelectedActivity.Project)
-	m.editInputs[3].SetValue(m.SelectedActivity.Notes)
-	m.editInputs[4].SetValue(fmt.Sprintf("%d", m.SelectedActivity.Duration.Int64))
-}
-
-func (m model) addActivityView() string {
-	var s string
-	for i := range m.inputs {
-		s += m.inputs[i].View() + "\n"
+	if m.viewingHistory {
+		return m.historyView()
 	}
-	return fmt.Sprintf(
-		"Adding new activity\n\n%s\n\n(esc to cancel)",
-		s,
-	)
-}
-
-func (m model) editActivityView() string {
-	// Define styles
-	titleStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#FAFAFA")).
-		Background(lipgloss.Color("#7D56F4")).
-		Padding(0, 1).
-		Bold(true)
-
-	inputStyle := lipgloss.NewStyle().
-		Border(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color("#7D56F4")).
-		Padding(0).
-		Width(50)
-
-	focusedStyle := inputStyle.
-		BorderForeground(lipgloss.Color("#FF00FF"))
-
-	infoStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#888888")).
-		Italic(true)
-
-	// Create the view
-	var b strings.Builder
-
-	// Title
-	b.WriteString(titleStyle.Render("Editing Activity") + "\n\n")
-
-	// Inputs
-	labels := []string{"Activity Name", "Description", "Project", "Notes", "Duration (seconds)"}
-	for i, input := range m.editInputs {
-		// Label
-		b.WriteString(lipgloss.NewStyle().Bold(true).Render(labels[i]) + "\n")
-
-		// Input field
-		style := inputStyle
-		if i == m.editInputIndex {
-			style = focusedStyle
-		}
-		b.WriteString(style.Render(input.View()) + "\n\n")
+	if m.viewingActivity {
+		return fmt.Sprintf("%s\n%s\n%s", m.headerView(), m.viewport.View(), m.footerView())
 	}
-
-	// Instructions
-	instructions := lipgloss.JoinHorizontal(lipgloss.Center,
-		infoStyle.Render("↑/↓: Navigate • "),
-		infoStyle.Render("Enter: Save • "),
-		infoStyle.Render("Esc: Cancel"),
-	)
-	b.WriteString(instructions)
-
-	return lipgloss.NewStyle().Padding(1, 2).Render(b.String())
+	return appStyle.Render(m.goalsView() + m.list.View())
 }
 
 func min(a, b int) int {
@@ -523,7 +1065,8 @@ Description: %s
 
 Project: %s
 
-Notes: %s
+Notes:
+%s
 
 Duration: %d seconds
 
@@ -531,35 +1074,51 @@ Start Time: %s
 
 End Time: %s
 
-(press 'e' to edit, esc to go back)`,
+Billable: %s
+%s%s
+(press '%s' to edit, '%s' for history, %s to go back)`,
 		a.Description,
 		a.Project,
-		a.Notes,
+		m.notes.render(a.Notes, m.viewport.Width-4),
 		a.Duration.Int64,
 		a.StartTime.Format(time.RFC3339),
 		a.EndTime.Time.Format(time.RFC3339),
+		billableView(*a),
+		tagsView(m.selectedTags),
+		segmentsView(m.selectedSegments),
+		m.keys.editItem.Help().Key,
+		m.keys.showHistory.Help().Key,
+		m.keys.closeView.Help().Key,
 	))
 }
 
-type activityAddedMsg struct{}
-
-func (m model) addActivity() tea.Msg {
-	activity := sqlite.InsertActivityParams{
-		StartTime:    time.Now(),
-		EndTime:      sql.NullTime{Time: time.Now(), Valid: true},
-		ActivityName: m.inputs[0].Value(),
-		Description:  m.inputs[1].Value(),
-		Project:      m.inputs[2].Value(),
-		Notes:        m.inputs[3].Value(),
+// resize fits the list and, if it is open, the activity view to the
+// window.
+func (m *model) resize() {
+	m.resizeList()
+	if m.viewingActivity {
+		m.resizeViewport()
 	}
-	duration, _ := time.ParseDuration(m.inputs[4].Value() + "s")
-	activity.Duration = sql.NullInt64{Int64: int64(duration.Seconds()), Valid: true}
+}
 
-	_, err := m.Queries.InsertActivity(context.Background(), activity)
-	if err != nil {
-		return errorMsg{err}
+// resizeList fits the list below the goals header.
+func (m *model) resizeList() {
+	h, v := appStyle.GetFrameSize()
+	header := lipgloss.Height(m.errorView()+m.goalsView()) - 1
+	m.list.SetSize(m.width-h, m.height-v-header)
+}
+
+// resizeViewport fits the activity view between its header and footer and
+// rewraps the activity to the new width. Before the first WindowSizeMsg the
+// viewport keeps its initial size.
+func (m *model) resizeViewport() {
+	if m.width > 0 {
+		m.viewport.Width = m.width
+		banner := lipgloss.Height(m.errorView()) - 1
+		chrome := lipgloss.Height(m.headerView()) + lipgloss.Height(m.footerView())
+		m.viewport.Height = max(0, m.height-banner-chrome)
 	}
-	return activityAddedMsg{}
+	m.viewport.SetContent(m.activityView())
 }
 
 func (m model) headerView() string {
@@ -575,14 +1134,19 @@ func (m model) footerView() string {
 }
 
 func (m model) fetchActivities() tea.Msg {
-	activities, err := m.Queries.QueryActivities(context.Background())
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	activities, err := m.Store.QueryActivities(ctx, userFrom(ctx))
+	if err != nil {
+		return errorMsg{err}
+	}
+	rows, err := m.Store.QueryAllActivityTags(ctx, userFrom(ctx))
 	if err != nil {
 		return errorMsg{err}
 	}
-	items := make([]list.Item, len(activities))
-	for i, a := range activities {
-		items[i] = item{activity: a}
+	tags := map[int64][]string{}
+	for _, r := range rows {
+		tags[r.ActivityID] = append(tags[r.ActivityID], r.Tag)
 	}
-	m.list.SetItems(items)
-	return fetchActivitiesMsg{activities: activities}
+	return fetchActivitiesMsg{activities: activities, tags: tags}
 }
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+create table if not exists time_segments(
+    id integer primary key,
+    activity_id integer not null references activities(id) on delete cascade,
+    start_time timestamp not null,
+    end_time timestamp
+);
+create index if not exists time_segments_activity_id on time_segments(activity_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+drop table if exists time_segments;
+-- +goose StatementEnd
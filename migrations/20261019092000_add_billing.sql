@@ -0,0 +1,58 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+create table if not exists clients(
+    name varchar(255) primary key,
+    hourly_rate integer,
+    currency varchar(3) not null default 'EUR'
+);
+create table if not exists projects(
+    name varchar(255) primary key,
+    client varchar(255) references clients(name),
+    billable boolean not null default false,
+    hourly_rate integer,
+    currency varchar(3)
+);
+create table if not exists invoices(
+    id integer primary key,
+    number varchar(32) not null unique,
+    year integer not null,
+    sequence integer not null,
+    client varchar(255) not null references clients(name),
+    period_start timestamp not null,
+    period_end timestamp not null,
+    issued_at timestamp not null,
+    currency varchar(3) not null,
+    rounding_minutes integer not null,
+    rounding_mode varchar(16) not null,
+    total integer not null
+);
+create table if not exists invoice_lines(
+    id integer primary key,
+    invoice_id integer not null references invoices(id) on delete cascade,
+    project varchar(255) not null,
+    minutes integer not null,
+    hourly_rate integer not null,
+    amount integer not null
+);
+alter table activities add column billable boolean not null default false;
+alter table activities add column invoice_id integer references invoices(id);
+create trigger if not exists activities_invoiced_lock
+before update on activities
+when old.invoice_id is not null
+begin
+    select raise(abort, 'activity is invoiced and locked');
+end;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+drop trigger if exists activities_invoiced_lock;
+alter table activities drop column invoice_id;
+alter table activities drop column billable;
+drop table if exists invoice_lines;
+drop table if exists invoices;
+drop table if exists projects;
+drop table if exists clients;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+create table if not exists goals(
+    project varchar(255) not null default '',
+    period varchar(8) not null check (period in ('day', 'week', 'month')),
+    min_minutes integer,
+    max_minutes integer,
+    primary key (project, period)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+drop table if exists goals;
+-- +goose StatementEnd
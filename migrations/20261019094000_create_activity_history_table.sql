@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+create table if not exists activity_history(
+    id integer primary key,
+    activity_id integer not null,
+    action varchar(16) not null check (action in ('insert', 'update', 'delete')),
+    source varchar(16) not null check (source in ('tui', 'cli', 'api', 'import')),
+    old_values text,
+    new_values text,
+    changed_at timestamp not null
+);
+create index if not exists activity_history_activity_id on activity_history(activity_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+drop table if exists activity_history;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+create table if not exists activity_tags(
+    activity_id integer not null references activities(id) on delete cascade,
+    tag varchar(64) not null,
+    primary key (activity_id, tag)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+drop table if exists activity_tags;
+-- +goose StatementEnd
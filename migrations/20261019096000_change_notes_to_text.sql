@@ -0,0 +1,66 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- SQLite cannot change a column's type, so the table is rebuilt. This also
+-- gives id the integer type the first migration misspelt. Foreign keys are
+-- switched off first, which only works outside a transaction: with them on,
+-- dropping activities would cascade to its time segments and tags.
+pragma foreign_keys = off;
+begin;
+create table activities_new(
+    id integer primary key,
+    start_time timestamp not null,
+    end_time timestamp,
+    duration integer,
+    activity_name varchar(255) not null,
+    description varchar(255) not null,
+    project varchar(255) not null,
+    notes text not null,
+    heartbeat_at timestamp,
+    billable boolean not null default false,
+    invoice_id integer references invoices(id)
+);
+insert into activities_new select id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id from activities;
+drop table activities;
+alter table activities_new rename to activities;
+create trigger if not exists activities_invoiced_lock
+before update on activities
+when old.invoice_id is not null
+begin
+    select raise(abort, 'activity is invoiced and locked');
+end;
+commit;
+pragma foreign_keys = on;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+pragma foreign_keys = off;
+begin;
+create table activities_old(
+    id integer primary key,
+    start_time timestamp not null,
+    end_time timestamp,
+    duration integer,
+    activity_name varchar(255) not null,
+    description varchar(255) not null,
+    project varchar(255) not null,
+    notes varchar(255) not null,
+    heartbeat_at timestamp,
+    billable boolean not null default false,
+    invoice_id integer references invoices(id)
+);
+insert into activities_old select id, start_time, end_time, duration, activity_name, description, project, substr(notes, 1, 255), heartbeat_at, billable, invoice_id from activities;
+drop table activities;
+alter table activities_old rename to activities;
+create trigger if not exists activities_invoiced_lock
+before update on activities
+when old.invoice_id is not null
+begin
+    select raise(abort, 'activity is invoiced and locked');
+end;
+commit;
+pragma foreign_keys = on;
+-- +goose StatementEnd
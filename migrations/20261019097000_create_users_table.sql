@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+create table if not exists users(
+    id integer primary key,
+    name varchar(255) not null unique
+);
+-- Activities from before there were users have no owner until the first
+-- user to open the database claims them.
+alter table activities add column owner_id integer references users(id);
+create index if not exists activities_owner_id on activities(owner_id);
+-- Giving an invoiced activity an owner is not an edit, so the lock now
+-- covers every column but owner_id.
+drop trigger if exists activities_invoiced_lock;
+create trigger if not exists activities_invoiced_lock
+before update of start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id on activities
+when old.invoice_id is not null
+begin
+    select raise(abort, 'activity is invoiced and locked');
+end;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+drop trigger if exists activities_invoiced_lock;
+create trigger if not exists activities_invoiced_lock
+before update on activities
+when old.invoice_id is not null
+begin
+    select raise(abort, 'activity is invoiced and locked');
+end;
+drop index if exists activities_owner_id;
+alter table activities drop column owner_id;
+drop table if exists users;
+-- +goose StatementEnd
@@ -0,0 +1,80 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- Every activity gets a random version 4 UUID, which is how devices that
+-- sync through a shared directory refer to it.
+alter table activities add column uuid varchar(36) not null default '';
+update activities set uuid = lower(
+    hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' ||
+    substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))
+);
+create unique index if not exists activities_uuid on activities(uuid);
+
+-- Changes applied from other devices are recorded with the source sync.
+alter table activity_history rename to activity_history_old;
+create table activity_history(
+    id integer primary key,
+    activity_id integer not null,
+    action varchar(16) not null check (action in ('insert', 'update', 'delete')),
+    source varchar(16) not null check (source in ('tui', 'cli', 'api', 'import', 'sync')),
+    old_values text,
+    new_values text,
+    changed_at timestamp not null
+);
+insert into activity_history select * from activity_history_old;
+drop table activity_history_old;
+create index if not exists activity_history_activity_id on activity_history(activity_id);
+
+-- This device, and the last history entry it has written to its log.
+create table if not exists sync_local(
+    device varchar(36) not null,
+    exported_history_id integer not null
+);
+-- How many entries of each other device's log have been applied.
+create table if not exists sync_devices(
+    device varchar(36) primary key,
+    applied integer not null
+);
+-- The change that last set each field of each activity.
+create table if not exists sync_clocks(
+    activity_uuid varchar(36) not null,
+    field varchar(16) not null,
+    changed_at timestamp not null,
+    device varchar(36) not null,
+    seq integer not null,
+    primary key (activity_uuid, field)
+);
+-- Concurrent changes to a field, kept until the user picks a value.
+create table if not exists sync_conflicts(
+    id integer primary key,
+    activity_uuid varchar(36) not null,
+    field varchar(16) not null,
+    kept text not null,
+    other text not null,
+    created_at timestamp not null
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+drop table if exists sync_conflicts;
+drop table if exists sync_clocks;
+drop table if exists sync_devices;
+drop table if exists sync_local;
+alter table activity_history rename to activity_history_new;
+create table activity_history(
+    id integer primary key,
+    activity_id integer not null,
+    action varchar(16) not null check (action in ('insert', 'update', 'delete')),
+    source varchar(16) not null check (source in ('tui', 'cli', 'api', 'import')),
+    old_values text,
+    new_values text,
+    changed_at timestamp not null
+);
+insert into activity_history select * from activity_history_new where source != 'sync';
+drop table activity_history_new;
+create index if not exists activity_history_activity_id on activity_history(activity_id);
+drop index if exists activities_uuid;
+alter table activities drop column uuid;
+-- +goose StatementEnd
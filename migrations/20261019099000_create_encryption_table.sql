@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- A database is encrypted when this table has its one row: how the key is
+-- made, the salt for a passphrase, and a value encrypted with the key to
+-- check it against. While rekeying, invoiced activities may be rewritten.
+create table if not exists encryption(
+    kdf varchar(16) not null check (kdf in ('argon2id', 'keyfile')),
+    salt text not null,
+    verifier text not null,
+    rekeying boolean not null default false
+);
+drop trigger if exists activities_invoiced_lock;
+create trigger if not exists activities_invoiced_lock
+before update of start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id on activities
+when old.invoice_id is not null and not exists (select 1 from encryption where rekeying)
+begin
+    select raise(abort, 'activity is invoiced and locked');
+end;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+drop trigger if exists activities_invoiced_lock;
+create trigger if not exists activities_invoiced_lock
+before update of start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id on activities
+when old.invoice_id is not null
+begin
+    select raise(abort, 'activity is invoiced and locked');
+end;
+drop table if exists encryption;
+-- +goose StatementEnd
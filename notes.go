@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+)
+
+// notesRenderer renders notes as Markdown. Building a renderer loads its
+// style and queries the terminal background, so the one for the last width
+// is kept for the next render.
+type notesRenderer struct {
+	width    int
+	renderer *glamour.TermRenderer
+}
+
+// render wraps notes to width, falling back to the plain text if rendering
+// fails.
+func (n *notesRenderer) render(notes string, width int) string {
+	if strings.TrimSpace(notes) == "" {
+		return ""
+	}
+	width = max(20, width)
+	if n.renderer == nil || n.width != width {
+		r, err := glamour.NewTermRenderer(
+			glamour.WithAutoStyle(),
+			glamour.WithWordWrap(width),
+		)
+		if err != nil {
+			return notes
+		}
+		n.renderer, n.width = r, width
+	}
+	out, err := n.renderer.Render(notes)
+	if err != nil {
+		return notes
+	}
+	return strings.Trim(out, "\n")
+}
@@ -0,0 +1,461 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: activities.sql
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const claimActivities = `-- name: ClaimActivities :exec
+update activities set owner_id = $1::bigint where owner_id is null
+`
+
+func (q *Queries) ClaimActivities(ctx context.Context, ownerID int64) error {
+	_, err := q.db.Exec(ctx, claimActivities, ownerID)
+	return err
+}
+
+const deleteActivity = `-- name: DeleteActivity :exec
+delete from activities where id = $1
+`
+
+func (q *Queries) DeleteActivity(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, deleteActivity, id)
+	return err
+}
+
+const insertActivity = `-- name: InsertActivity :one
+insert into activities (start_time, end_time, duration, activity_name, description, project, notes, billable, owner_id, uuid) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) returning id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid
+`
+
+type InsertActivityParams struct {
+	StartTime    time.Time
+	EndTime      sql.NullTime
+	Duration     pgtype.Interval
+	ActivityName string
+	Description  string
+	Project      string
+	Notes        string
+	Billable     bool
+	OwnerID      sql.NullInt64
+	UUID         string
+}
+
+func (q *Queries) InsertActivity(ctx context.Context, arg InsertActivityParams) (Activity, error) {
+	row := q.db.QueryRow(ctx, insertActivity,
+		arg.StartTime,
+		arg.EndTime,
+		arg.Duration,
+		arg.ActivityName,
+		arg.Description,
+		arg.Project,
+		arg.Notes,
+		arg.Billable,
+		arg.OwnerID,
+		arg.UUID,
+	)
+	var i Activity
+	err := row.Scan(
+		&i.ID,
+		&i.StartTime,
+		&i.EndTime,
+		&i.Duration,
+		&i.ActivityName,
+		&i.Description,
+		&i.Project,
+		&i.Notes,
+		&i.HeartbeatAt,
+		&i.Billable,
+		&i.InvoiceID,
+		&i.OwnerID,
+		&i.UUID,
+	)
+	return i, err
+}
+
+const queryActivities = `-- name: QueryActivities :many
+select id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid from activities where owner_id = $1::bigint order by id
+`
+
+func (q *Queries) QueryActivities(ctx context.Context, ownerID int64) ([]Activity, error) {
+	rows, err := q.db.Query(ctx, queryActivities, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Activity
+	for rows.Next() {
+		var i Activity
+		if err := rows.Scan(
+			&i.ID,
+			&i.StartTime,
+			&i.EndTime,
+			&i.Duration,
+			&i.ActivityName,
+			&i.Description,
+			&i.Project,
+			&i.Notes,
+			&i.HeartbeatAt,
+			&i.Billable,
+			&i.InvoiceID,
+			&i.OwnerID,
+			&i.UUID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const queryActivitiesBetween = `-- name: QueryActivitiesBetween :many
+select id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid from activities
+where owner_id = $1::bigint
+  and start_time >= $2
+  and start_time < $3
+order by start_time, id
+`
+
+type QueryActivitiesBetweenParams struct {
+	OwnerID     int64
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+}
+
+func (q *Queries) QueryActivitiesBetween(ctx context.Context, arg QueryActivitiesBetweenParams) ([]Activity, error) {
+	rows, err := q.db.Query(ctx, queryActivitiesBetween, arg.OwnerID, arg.PeriodStart, arg.PeriodEnd)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Activity
+	for rows.Next() {
+		var i Activity
+		if err := rows.Scan(
+			&i.ID,
+			&i.StartTime,
+			&i.EndTime,
+			&i.Duration,
+			&i.ActivityName,
+			&i.Description,
+			&i.Project,
+			&i.Notes,
+			&i.HeartbeatAt,
+			&i.Billable,
+			&i.InvoiceID,
+			&i.OwnerID,
+			&i.UUID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const queryActivityByProject = `-- name: QueryActivityByProject :one
+select id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid from activities where project = $1 and owner_id = $2::bigint order by id limit 1
+`
+
+type QueryActivityByProjectParams struct {
+	Project string
+	OwnerID int64
+}
+
+func (q *Queries) QueryActivityByProject(ctx context.Context, arg QueryActivityByProjectParams) (Activity, error) {
+	row := q.db.QueryRow(ctx, queryActivityByProject, arg.Project, arg.OwnerID)
+	var i Activity
+	err := row.Scan(
+		&i.ID,
+		&i.StartTime,
+		&i.EndTime,
+		&i.Duration,
+		&i.ActivityName,
+		&i.Description,
+		&i.Project,
+		&i.Notes,
+		&i.HeartbeatAt,
+		&i.Billable,
+		&i.InvoiceID,
+		&i.OwnerID,
+		&i.UUID,
+	)
+	return i, err
+}
+
+const queryActivityByUUID = `-- name: QueryActivityByUUID :one
+select id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid from activities where uuid = $1
+`
+
+func (q *Queries) QueryActivityByUUID(ctx context.Context, uuid string) (Activity, error) {
+	row := q.db.QueryRow(ctx, queryActivityByUUID, uuid)
+	var i Activity
+	err := row.Scan(
+		&i.ID,
+		&i.StartTime,
+		&i.EndTime,
+		&i.Duration,
+		&i.ActivityName,
+		&i.Description,
+		&i.Project,
+		&i.Notes,
+		&i.HeartbeatAt,
+		&i.Billable,
+		&i.InvoiceID,
+		&i.OwnerID,
+		&i.UUID,
+	)
+	return i, err
+}
+
+const queryRunningActivity = `-- name: QueryRunningActivity :one
+select id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid from activities
+where owner_id = $1::bigint
+  and end_time is null
+  and duration is null
+order by start_time desc
+limit 1
+`
+
+func (q *Queries) QueryRunningActivity(ctx context.Context, ownerID int64) (Activity, error) {
+	row := q.db.QueryRow(ctx, queryRunningActivity, ownerID)
+	var i Activity
+	err := row.Scan(
+		&i.ID,
+		&i.StartTime,
+		&i.EndTime,
+		&i.Duration,
+		&i.ActivityName,
+		&i.Description,
+		&i.Project,
+		&i.Notes,
+		&i.HeartbeatAt,
+		&i.Billable,
+		&i.InvoiceID,
+		&i.OwnerID,
+		&i.UUID,
+	)
+	return i, err
+}
+
+const queryTeamActivitiesBetween = `-- name: QueryTeamActivitiesBetween :many
+select id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid from activities
+where start_time >= $1
+  and start_time < $2
+order by start_time, id
+`
+
+type QueryTeamActivitiesBetweenParams struct {
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+}
+
+func (q *Queries) QueryTeamActivitiesBetween(ctx context.Context, arg QueryTeamActivitiesBetweenParams) ([]Activity, error) {
+	rows, err := q.db.Query(ctx, queryTeamActivitiesBetween, arg.PeriodStart, arg.PeriodEnd)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Activity
+	for rows.Next() {
+		var i Activity
+		if err := rows.Scan(
+			&i.ID,
+			&i.StartTime,
+			&i.EndTime,
+			&i.Duration,
+			&i.ActivityName,
+			&i.Description,
+			&i.Project,
+			&i.Notes,
+			&i.HeartbeatAt,
+			&i.Billable,
+			&i.InvoiceID,
+			&i.OwnerID,
+			&i.UUID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setActivityProject = `-- name: SetActivityProject :one
+update activities set project = $1 where id = $2 returning id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid
+`
+
+type SetActivityProjectParams struct {
+	Project string
+	ID      int64
+}
+
+func (q *Queries) SetActivityProject(ctx context.Context, arg SetActivityProjectParams) (Activity, error) {
+	row := q.db.QueryRow(ctx, setActivityProject, arg.Project, arg.ID)
+	var i Activity
+	err := row.Scan(
+		&i.ID,
+		&i.StartTime,
+		&i.EndTime,
+		&i.Duration,
+		&i.ActivityName,
+		&i.Description,
+		&i.Project,
+		&i.Notes,
+		&i.HeartbeatAt,
+		&i.Billable,
+		&i.InvoiceID,
+		&i.OwnerID,
+		&i.UUID,
+	)
+	return i, err
+}
+
+const setActivityUUID = `-- name: SetActivityUUID :one
+update activities set uuid = $1 where id = $2 returning id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid
+`
+
+type SetActivityUUIDParams struct {
+	UUID string
+	ID   int64
+}
+
+func (q *Queries) SetActivityUUID(ctx context.Context, arg SetActivityUUIDParams) (Activity, error) {
+	row := q.db.QueryRow(ctx, setActivityUUID, arg.UUID, arg.ID)
+	var i Activity
+	err := row.Scan(
+		&i.ID,
+		&i.StartTime,
+		&i.EndTime,
+		&i.Duration,
+		&i.ActivityName,
+		&i.Description,
+		&i.Project,
+		&i.Notes,
+		&i.HeartbeatAt,
+		&i.Billable,
+		&i.InvoiceID,
+		&i.OwnerID,
+		&i.UUID,
+	)
+	return i, err
+}
+
+const stopActivity = `-- name: StopActivity :one
+update activities
+set end_time = $1,
+    duration = $2
+where id = $3
+returning id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid
+`
+
+type StopActivityParams struct {
+	EndTime  sql.NullTime
+	Duration pgtype.Interval
+	ID       int64
+}
+
+func (q *Queries) StopActivity(ctx context.Context, arg StopActivityParams) (Activity, error) {
+	row := q.db.QueryRow(ctx, stopActivity, arg.EndTime, arg.Duration, arg.ID)
+	var i Activity
+	err := row.Scan(
+		&i.ID,
+		&i.StartTime,
+		&i.EndTime,
+		&i.Duration,
+		&i.ActivityName,
+		&i.Description,
+		&i.Project,
+		&i.Notes,
+		&i.HeartbeatAt,
+		&i.Billable,
+		&i.InvoiceID,
+		&i.OwnerID,
+		&i.UUID,
+	)
+	return i, err
+}
+
+const touchActivity = `-- name: TouchActivity :exec
+update activities set heartbeat_at = $1 where id = $2
+`
+
+type TouchActivityParams struct {
+	HeartbeatAt sql.NullTime
+	ID          int64
+}
+
+func (q *Queries) TouchActivity(ctx context.Context, arg TouchActivityParams) error {
+	_, err := q.db.Exec(ctx, touchActivity, arg.HeartbeatAt, arg.ID)
+	return err
+}
+
+const updateActivity = `-- name: UpdateActivity :one
+update activities
+set start_time = $1,
+    end_time = $2,
+    duration = $3,
+    activity_name = $4,
+    description = $5,
+    project = $6,
+    notes = $7
+where id = $8
+returning id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid
+`
+
+type UpdateActivityParams struct {
+	StartTime    time.Time
+	EndTime      sql.NullTime
+	Duration     pgtype.Interval
+	ActivityName string
+	Description  string
+	Project      string
+	Notes        string
+	ID           int64
+}
+
+func (q *Queries) UpdateActivity(ctx context.Context, arg UpdateActivityParams) (Activity, error) {
+	row := q.db.QueryRow(ctx, updateActivity,
+		arg.StartTime,
+		arg.EndTime,
+		arg.Duration,
+		arg.ActivityName,
+		arg.Description,
+		arg.Project,
+		arg.Notes,
+		arg.ID,
+	)
+	var i Activity
+	err := row.Scan(
+		&i.ID,
+		&i.StartTime,
+		&i.EndTime,
+		&i.Duration,
+		&i.ActivityName,
+		&i.Description,
+		&i.Project,
+		&i.Notes,
+		&i.HeartbeatAt,
+		&i.Billable,
+		&i.InvoiceID,
+		&i.OwnerID,
+		&i.UUID,
+	)
+	return i, err
+}
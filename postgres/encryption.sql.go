@@ -0,0 +1,158 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: encryption.sql
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+)
+
+const deleteEncryption = `-- name: DeleteEncryption :exec
+delete from encryption
+`
+
+func (q *Queries) DeleteEncryption(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteEncryption)
+	return err
+}
+
+const getEncryption = `-- name: GetEncryption :one
+select kdf, salt, verifier, rekeying from encryption limit 1
+`
+
+func (q *Queries) GetEncryption(ctx context.Context) (Encryption, error) {
+	row := q.db.QueryRow(ctx, getEncryption)
+	var i Encryption
+	err := row.Scan(
+		&i.Kdf,
+		&i.Salt,
+		&i.Verifier,
+		&i.Rekeying,
+	)
+	return i, err
+}
+
+const insertEncryption = `-- name: InsertEncryption :exec
+insert into encryption (kdf, salt, verifier) values ($1, $2, $3)
+`
+
+type InsertEncryptionParams struct {
+	Kdf      string
+	Salt     string
+	Verifier string
+}
+
+func (q *Queries) InsertEncryption(ctx context.Context, arg InsertEncryptionParams) error {
+	_, err := q.db.Exec(ctx, insertEncryption, arg.Kdf, arg.Salt, arg.Verifier)
+	return err
+}
+
+const queryAllActivities = `-- name: QueryAllActivities :many
+select id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid from activities order by id
+`
+
+func (q *Queries) QueryAllActivities(ctx context.Context) ([]Activity, error) {
+	rows, err := q.db.Query(ctx, queryAllActivities)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Activity
+	for rows.Next() {
+		var i Activity
+		if err := rows.Scan(
+			&i.ID,
+			&i.StartTime,
+			&i.EndTime,
+			&i.Duration,
+			&i.ActivityName,
+			&i.Description,
+			&i.Project,
+			&i.Notes,
+			&i.HeartbeatAt,
+			&i.Billable,
+			&i.InvoiceID,
+			&i.OwnerID,
+			&i.UUID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setActivityHistoryValues = `-- name: SetActivityHistoryValues :exec
+update activity_history set old_values = $1, new_values = $2 where id = $3
+`
+
+type SetActivityHistoryValuesParams struct {
+	OldValues sql.NullString
+	NewValues sql.NullString
+	ID        int64
+}
+
+func (q *Queries) SetActivityHistoryValues(ctx context.Context, arg SetActivityHistoryValuesParams) error {
+	_, err := q.db.Exec(ctx, setActivityHistoryValues, arg.OldValues, arg.NewValues, arg.ID)
+	return err
+}
+
+const setActivityText = `-- name: SetActivityText :exec
+update activities set description = $1, notes = $2 where id = $3
+`
+
+type SetActivityTextParams struct {
+	Description string
+	Notes       string
+	ID          int64
+}
+
+func (q *Queries) SetActivityText(ctx context.Context, arg SetActivityTextParams) error {
+	_, err := q.db.Exec(ctx, setActivityText, arg.Description, arg.Notes, arg.ID)
+	return err
+}
+
+const setEncryptionRekeying = `-- name: SetEncryptionRekeying :exec
+update encryption set rekeying = $1
+`
+
+func (q *Queries) SetEncryptionRekeying(ctx context.Context, rekeying bool) error {
+	_, err := q.db.Exec(ctx, setEncryptionRekeying, rekeying)
+	return err
+}
+
+const setSyncConflictValues = `-- name: SetSyncConflictValues :exec
+update sync_conflicts set kept = $1, other = $2 where id = $3
+`
+
+type SetSyncConflictValuesParams struct {
+	Kept  string
+	Other string
+	ID    int64
+}
+
+func (q *Queries) SetSyncConflictValues(ctx context.Context, arg SetSyncConflictValuesParams) error {
+	_, err := q.db.Exec(ctx, setSyncConflictValues, arg.Kept, arg.Other, arg.ID)
+	return err
+}
+
+const updateEncryption = `-- name: UpdateEncryption :exec
+update encryption set kdf = $1, salt = $2, verifier = $3
+`
+
+type UpdateEncryptionParams struct {
+	Kdf      string
+	Salt     string
+	Verifier string
+}
+
+func (q *Queries) UpdateEncryption(ctx context.Context, arg UpdateEncryptionParams) error {
+	_, err := q.db.Exec(ctx, updateEncryption, arg.Kdf, arg.Salt, arg.Verifier)
+	return err
+}
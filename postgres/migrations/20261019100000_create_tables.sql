@@ -0,0 +1,109 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- The SQLite schema as it stands after its migrations, for a shared
+-- database. Durations are intervals and times are timestamptz.
+create table if not exists clients(
+    name varchar(255) primary key,
+    hourly_rate bigint,
+    currency varchar(3) not null default 'EUR'
+);
+create table if not exists projects(
+    name varchar(255) primary key,
+    client varchar(255) references clients(name),
+    billable boolean not null default false,
+    hourly_rate bigint,
+    currency varchar(3)
+);
+create table if not exists invoices(
+    id bigint generated by default as identity primary key,
+    number varchar(32) not null unique,
+    year bigint not null,
+    sequence bigint not null,
+    client varchar(255) not null references clients(name),
+    period_start timestamptz not null,
+    period_end timestamptz not null,
+    issued_at timestamptz not null,
+    currency varchar(3) not null,
+    rounding_minutes bigint not null,
+    rounding_mode varchar(16) not null,
+    total bigint not null
+);
+create table if not exists invoice_lines(
+    id bigint generated by default as identity primary key,
+    invoice_id bigint not null references invoices(id) on delete cascade,
+    project varchar(255) not null,
+    minutes bigint not null,
+    hourly_rate bigint not null,
+    amount bigint not null
+);
+create table if not exists activities(
+    id bigint generated by default as identity primary key,
+    start_time timestamptz not null,
+    end_time timestamptz,
+    duration interval,
+    activity_name varchar(255) not null,
+    description varchar(255) not null,
+    project varchar(255) not null,
+    notes text not null,
+    heartbeat_at timestamptz,
+    billable boolean not null default false,
+    invoice_id bigint references invoices(id)
+);
+create table if not exists time_segments(
+    id bigint generated by default as identity primary key,
+    activity_id bigint not null references activities(id) on delete cascade,
+    start_time timestamptz not null,
+    end_time timestamptz
+);
+create index if not exists time_segments_activity_id on time_segments(activity_id);
+create table if not exists goals(
+    project varchar(255) not null default '',
+    period varchar(8) not null check (period in ('day', 'week', 'month')),
+    min_minutes bigint,
+    max_minutes bigint,
+    primary key (project, period)
+);
+create table if not exists activity_history(
+    id bigint generated by default as identity primary key,
+    activity_id bigint not null,
+    action varchar(16) not null check (action in ('insert', 'update', 'delete')),
+    source varchar(16) not null check (source in ('tui', 'cli', 'api', 'import')),
+    old_values text,
+    new_values text,
+    changed_at timestamptz not null
+);
+create index if not exists activity_history_activity_id on activity_history(activity_id);
+create table if not exists activity_tags(
+    activity_id bigint not null references activities(id) on delete cascade,
+    tag varchar(64) not null,
+    primary key (activity_id, tag)
+);
+
+create or replace function activities_invoiced_lock() returns trigger as $$
+begin
+    raise exception 'activity is invoiced and locked';
+end;
+$$ language plpgsql;
+
+create trigger activities_invoiced_lock
+before update on activities
+for each row when (old.invoice_id is not null)
+execute function activities_invoiced_lock();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+drop trigger if exists activities_invoiced_lock on activities;
+drop function if exists activities_invoiced_lock();
+drop table if exists activity_tags;
+drop table if exists activity_history;
+drop table if exists goals;
+drop table if exists time_segments;
+drop table if exists activities;
+drop table if exists invoice_lines;
+drop table if exists invoices;
+drop table if exists projects;
+drop table if exists clients;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+create table if not exists users(
+    id bigint generated by default as identity primary key,
+    name varchar(255) not null unique
+);
+-- Activities from before there were users have no owner until the first
+-- user to open the database claims them.
+alter table activities add column owner_id bigint references users(id);
+create index if not exists activities_owner_id on activities(owner_id);
+-- Giving an invoiced activity an owner is not an edit, so the lock now
+-- covers every column but owner_id.
+drop trigger if exists activities_invoiced_lock on activities;
+create trigger activities_invoiced_lock
+before update of start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id on activities
+for each row when (old.invoice_id is not null)
+execute function activities_invoiced_lock();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+drop trigger if exists activities_invoiced_lock on activities;
+create trigger activities_invoiced_lock
+before update on activities
+for each row when (old.invoice_id is not null)
+execute function activities_invoiced_lock();
+drop index if exists activities_owner_id;
+alter table activities drop column owner_id;
+drop table if exists users;
+-- +goose StatementEnd
@@ -0,0 +1,57 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- Every activity gets a random UUID, which is how devices that sync
+-- through a shared directory refer to it.
+alter table activities add column uuid varchar(36) not null default gen_random_uuid()::text;
+create unique index if not exists activities_uuid on activities(uuid);
+
+-- Changes applied from other devices are recorded with the source sync.
+alter table activity_history drop constraint if exists activity_history_source_check;
+alter table activity_history add constraint activity_history_source_check
+    check (source in ('tui', 'cli', 'api', 'import', 'sync'));
+
+-- This device, and the last history entry it has written to its log.
+create table if not exists sync_local(
+    device varchar(36) not null,
+    exported_history_id bigint not null
+);
+-- How many entries of each other device's log have been applied.
+create table if not exists sync_devices(
+    device varchar(36) primary key,
+    applied bigint not null
+);
+-- The change that last set each field of each activity.
+create table if not exists sync_clocks(
+    activity_uuid varchar(36) not null,
+    field varchar(16) not null,
+    changed_at timestamptz not null,
+    device varchar(36) not null,
+    seq bigint not null,
+    primary key (activity_uuid, field)
+);
+-- Concurrent changes to a field, kept until the user picks a value.
+create table if not exists sync_conflicts(
+    id bigint generated by default as identity primary key,
+    activity_uuid varchar(36) not null,
+    field varchar(16) not null,
+    kept text not null,
+    other text not null,
+    created_at timestamptz not null
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+drop table if exists sync_conflicts;
+drop table if exists sync_clocks;
+drop table if exists sync_devices;
+drop table if exists sync_local;
+delete from activity_history where source = 'sync';
+alter table activity_history drop constraint if exists activity_history_source_check;
+alter table activity_history add constraint activity_history_source_check
+    check (source in ('tui', 'cli', 'api', 'import'));
+drop index if exists activities_uuid;
+alter table activities drop column uuid;
+-- +goose StatementEnd
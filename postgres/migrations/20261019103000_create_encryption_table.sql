@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- A database is encrypted when this table has its one row: how the key is
+-- made, the salt for a passphrase, and a value encrypted with the key to
+-- check it against. While rekeying, invoiced activities may be rewritten.
+create table if not exists encryption(
+    kdf varchar(16) not null check (kdf in ('argon2id', 'keyfile')),
+    salt text not null,
+    verifier text not null,
+    rekeying boolean not null default false
+);
+-- Encrypted descriptions are longer than the plain ones.
+alter table activities alter column description type text;
+
+create or replace function activities_invoiced_lock() returns trigger as $$
+begin
+    if exists (select 1 from encryption where rekeying) then
+        return new;
+    end if;
+    raise exception 'activity is invoiced and locked';
+end;
+$$ language plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+create or replace function activities_invoiced_lock() returns trigger as $$
+begin
+    raise exception 'activity is invoiced and locked';
+end;
+$$ language plpgsql;
+alter table activities alter column description type varchar(255);
+drop table if exists encryption;
+-- +goose StatementEnd
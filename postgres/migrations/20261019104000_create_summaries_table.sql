@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- Summaries written by a language model, reused while the activities they
+-- were written from and the prompt stay the same.
+create table if not exists summaries(
+    id bigint generated by default as identity primary key,
+    period_start timestamptz not null,
+    period_end timestamptz not null,
+    input_hash varchar(64) not null,
+    prompt_version bigint not null,
+    model varchar(64) not null,
+    content text not null,
+    created_at timestamptz not null,
+    unique (period_start, period_end, input_hash, prompt_version)
+);
+-- Every call made to a language model, with the tokens it used and its
+-- estimated cost in millionths of a US dollar, null for models without a
+-- known price.
+create table if not exists llm_calls(
+    id bigint generated by default as identity primary key,
+    model varchar(64) not null,
+    prompt_tokens bigint not null,
+    completion_tokens bigint not null,
+    cost_micros bigint,
+    created_at timestamptz not null
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+drop table if exists llm_calls;
+drop table if exists summaries;
+-- +goose StatementEnd
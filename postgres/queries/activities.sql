@@ -0,0 +1,66 @@
+-- name: QueryActivities :many
+select * from activities where owner_id = sqlc.arg(owner_id)::bigint order by id;
+
+-- name: QueryActivityByProject :one
+select * from activities where project = $1 and owner_id = sqlc.arg(owner_id)::bigint order by id limit 1;
+
+-- name: InsertActivity :one
+insert into activities (start_time, end_time, duration, activity_name, description, project, notes, billable, owner_id, uuid) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) returning *;
+
+-- name: UpdateActivity :one
+update activities
+set start_time = $1,
+    end_time = $2,
+    duration = $3,
+    activity_name = $4,
+    description = $5,
+    project = $6,
+    notes = $7
+where id = $8
+returning *;
+
+-- name: QueryRunningActivity :one
+select * from activities
+where owner_id = sqlc.arg(owner_id)::bigint
+  and end_time is null
+  and duration is null
+order by start_time desc
+limit 1;
+
+-- name: StopActivity :one
+update activities
+set end_time = $1,
+    duration = $2
+where id = $3
+returning *;
+
+-- name: TouchActivity :exec
+update activities set heartbeat_at = $1 where id = $2;
+
+-- name: QueryActivitiesBetween :many
+select * from activities
+where owner_id = sqlc.arg(owner_id)::bigint
+  and start_time >= sqlc.arg(period_start)
+  and start_time < sqlc.arg(period_end)
+order by start_time, id;
+
+-- name: QueryTeamActivitiesBetween :many
+select * from activities
+where start_time >= sqlc.arg(period_start)
+  and start_time < sqlc.arg(period_end)
+order by start_time, id;
+
+-- name: SetActivityProject :one
+update activities set project = $1 where id = $2 returning *;
+
+-- name: DeleteActivity :exec
+delete from activities where id = $1;
+
+-- name: ClaimActivities :exec
+update activities set owner_id = sqlc.arg(owner_id)::bigint where owner_id is null;
+
+-- name: QueryActivityByUUID :one
+select * from activities where uuid = $1;
+
+-- name: SetActivityUUID :one
+update activities set uuid = $1 where id = $2 returning *;
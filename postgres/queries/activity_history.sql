@@ -0,0 +1,11 @@
+-- name: InsertActivityHistory :exec
+insert into activity_history (activity_id, action, source, old_values, new_values, changed_at) values ($1, $2, $3, $4, $5, $6);
+
+-- name: QueryActivityHistory :many
+select * from activity_history where activity_id = $1 order by changed_at desc, id desc;
+
+-- name: QueryActivityHistorySince :many
+select * from activity_history where id > $1 order by id;
+
+-- name: GetLastActivityHistoryID :one
+select coalesce(max(id), 0)::bigint from activity_history;
@@ -0,0 +1,17 @@
+-- name: QueryActivityTags :many
+select tag from activity_tags where activity_id = $1 order by tag;
+
+-- name: AddActivityTag :exec
+insert into activity_tags (activity_id, tag) values ($1, $2) on conflict do nothing;
+
+-- name: RemoveActivityTag :exec
+delete from activity_tags where activity_id = $1 and tag = $2;
+
+-- name: DeleteActivityTags :exec
+delete from activity_tags where activity_id = $1;
+
+-- name: QueryAllActivityTags :many
+select activity_tags.activity_id, activity_tags.tag from activity_tags
+join activities on activities.id = activity_tags.activity_id
+where activities.owner_id = sqlc.arg(owner_id)::bigint
+order by activity_tags.activity_id, activity_tags.tag;
@@ -0,0 +1,48 @@
+-- name: UpsertClient :one
+insert into clients (name, hourly_rate, currency) values ($1, $2, $3)
+on conflict (name) do update set hourly_rate = excluded.hourly_rate, currency = excluded.currency
+returning *;
+
+-- name: GetClient :one
+select * from clients where name = $1;
+
+-- name: UpsertProject :one
+insert into projects (name, client, billable, hourly_rate, currency) values ($1, $2, $3, $4, $5)
+on conflict (name) do update set client = excluded.client,
+    billable = excluded.billable,
+    hourly_rate = excluded.hourly_rate,
+    currency = excluded.currency
+returning *;
+
+-- name: GetProject :one
+select * from projects where name = $1;
+
+-- name: QueryProjectsByClient :many
+select * from projects where client = $1 order by name;
+
+-- name: SetActivityBillable :one
+update activities set billable = $1 where id = $2 returning *;
+
+-- name: QueryBillableActivities :many
+select * from activities
+where project = $1
+  and billable
+  and invoice_id is null
+  and end_time is not null
+  and start_time >= sqlc.arg(period_start)
+  and start_time < sqlc.arg(period_end)
+order by start_time, id;
+
+-- name: NextInvoiceSequence :one
+select (coalesce(max(sequence), 0) + 1)::bigint from invoices where year = $1;
+
+-- name: InsertInvoice :one
+insert into invoices (number, year, sequence, client, period_start, period_end, issued_at, currency, rounding_minutes, rounding_mode, total)
+values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+returning *;
+
+-- name: InsertInvoiceLine :exec
+insert into invoice_lines (invoice_id, project, minutes, hourly_rate, amount) values ($1, $2, $3, $4, $5);
+
+-- name: SetActivityInvoice :one
+update activities set invoice_id = $1 where id = $2 returning *;
@@ -0,0 +1,26 @@
+-- name: GetEncryption :one
+select * from encryption limit 1;
+
+-- name: InsertEncryption :exec
+insert into encryption (kdf, salt, verifier) values ($1, $2, $3);
+
+-- name: UpdateEncryption :exec
+update encryption set kdf = $1, salt = $2, verifier = $3;
+
+-- name: DeleteEncryption :exec
+delete from encryption;
+
+-- name: SetEncryptionRekeying :exec
+update encryption set rekeying = $1;
+
+-- name: QueryAllActivities :many
+select * from activities order by id;
+
+-- name: SetActivityText :exec
+update activities set description = $1, notes = $2 where id = $3;
+
+-- name: SetActivityHistoryValues :exec
+update activity_history set old_values = $1, new_values = $2 where id = $3;
+
+-- name: SetSyncConflictValues :exec
+update sync_conflicts set kept = $1, other = $2 where id = $3;
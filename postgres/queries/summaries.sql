@@ -0,0 +1,27 @@
+-- name: GetSummary :one
+select * from summaries
+where period_start = $1
+  and period_end = $2
+  and input_hash = $3
+  and prompt_version = $4;
+
+-- name: UpsertSummary :one
+insert into summaries (period_start, period_end, input_hash, prompt_version, model, content, created_at)
+values ($1, $2, $3, $4, $5, $6, $7)
+on conflict (period_start, period_end, input_hash, prompt_version) do update
+set model = excluded.model,
+    content = excluded.content,
+    created_at = excluded.created_at
+returning *;
+
+-- name: QueryAllSummaries :many
+select * from summaries order by id;
+
+-- name: SetSummaryContent :exec
+update summaries set content = $1 where id = $2;
+
+-- name: InsertLLMCall :exec
+insert into llm_calls (model, prompt_tokens, completion_tokens, cost_micros, created_at) values ($1, $2, $3, $4, $5);
+
+-- name: QueryLLMCalls :many
+select * from llm_calls order by created_at, id;
@@ -0,0 +1,34 @@
+-- name: GetSyncLocal :one
+select * from sync_local limit 1;
+
+-- name: InsertSyncLocal :exec
+insert into sync_local (device, exported_history_id) values ($1, $2);
+
+-- name: SetSyncExported :exec
+update sync_local set exported_history_id = $1;
+
+-- name: QuerySyncDevices :many
+select * from sync_devices order by device;
+
+-- name: SetSyncDeviceApplied :exec
+insert into sync_devices (device, applied) values ($1, $2)
+on conflict (device) do update set applied = excluded.applied;
+
+-- name: GetSyncClock :one
+select * from sync_clocks where activity_uuid = $1 and field = $2;
+
+-- name: SetSyncClock :exec
+insert into sync_clocks (activity_uuid, field, changed_at, device, seq) values ($1, $2, $3, $4, $5)
+on conflict (activity_uuid, field) do update
+set changed_at = excluded.changed_at,
+    device = excluded.device,
+    seq = excluded.seq;
+
+-- name: InsertSyncConflict :exec
+insert into sync_conflicts (activity_uuid, field, kept, other, created_at) values ($1, $2, $3, $4, $5);
+
+-- name: QuerySyncConflicts :many
+select * from sync_conflicts order by id;
+
+-- name: DeleteSyncConflict :exec
+delete from sync_conflicts where id = $1;
@@ -0,0 +1,14 @@
+-- name: QueryTimeSegments :many
+select * from time_segments where activity_id = $1 order by start_time, id;
+
+-- name: InsertTimeSegment :one
+insert into time_segments (activity_id, start_time, end_time) values ($1, $2, $3) returning *;
+
+-- name: CloseTimeSegment :exec
+update time_segments set end_time = $1 where id = $2;
+
+-- name: UpdateTimeSegment :exec
+update time_segments set start_time = $1, end_time = $2 where id = $3;
+
+-- name: DeleteTimeSegments :exec
+delete from time_segments where activity_id = $1;
@@ -0,0 +1,7 @@
+-- name: UpsertUser :one
+insert into users (name) values ($1)
+on conflict (name) do update set name = excluded.name
+returning *;
+
+-- name: GetUser :one
+select * from users where name = $1;
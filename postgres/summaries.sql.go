@@ -0,0 +1,193 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: summaries.sql
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const getSummary = `-- name: GetSummary :one
+select id, period_start, period_end, input_hash, prompt_version, model, content, created_at from summaries
+where period_start = $1
+  and period_end = $2
+  and input_hash = $3
+  and prompt_version = $4
+`
+
+type GetSummaryParams struct {
+	PeriodStart   time.Time
+	PeriodEnd     time.Time
+	InputHash     string
+	PromptVersion int64
+}
+
+func (q *Queries) GetSummary(ctx context.Context, arg GetSummaryParams) (Summary, error) {
+	row := q.db.QueryRow(ctx, getSummary,
+		arg.PeriodStart,
+		arg.PeriodEnd,
+		arg.InputHash,
+		arg.PromptVersion,
+	)
+	var i Summary
+	err := row.Scan(
+		&i.ID,
+		&i.PeriodStart,
+		&i.PeriodEnd,
+		&i.InputHash,
+		&i.PromptVersion,
+		&i.Model,
+		&i.Content,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const insertLLMCall = `-- name: InsertLLMCall :exec
+insert into llm_calls (model, prompt_tokens, completion_tokens, cost_micros, created_at) values ($1, $2, $3, $4, $5)
+`
+
+type InsertLLMCallParams struct {
+	Model            string
+	PromptTokens     int64
+	CompletionTokens int64
+	CostMicros       sql.NullInt64
+	CreatedAt        time.Time
+}
+
+func (q *Queries) InsertLLMCall(ctx context.Context, arg InsertLLMCallParams) error {
+	_, err := q.db.Exec(ctx, insertLLMCall,
+		arg.Model,
+		arg.PromptTokens,
+		arg.CompletionTokens,
+		arg.CostMicros,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const queryAllSummaries = `-- name: QueryAllSummaries :many
+select id, period_start, period_end, input_hash, prompt_version, model, content, created_at from summaries order by id
+`
+
+func (q *Queries) QueryAllSummaries(ctx context.Context) ([]Summary, error) {
+	rows, err := q.db.Query(ctx, queryAllSummaries)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Summary
+	for rows.Next() {
+		var i Summary
+		if err := rows.Scan(
+			&i.ID,
+			&i.PeriodStart,
+			&i.PeriodEnd,
+			&i.InputHash,
+			&i.PromptVersion,
+			&i.Model,
+			&i.Content,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const queryLLMCalls = `-- name: QueryLLMCalls :many
+select id, model, prompt_tokens, completion_tokens, cost_micros, created_at from llm_calls order by created_at, id
+`
+
+func (q *Queries) QueryLLMCalls(ctx context.Context) ([]LLMCall, error) {
+	rows, err := q.db.Query(ctx, queryLLMCalls)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LLMCall
+	for rows.Next() {
+		var i LLMCall
+		if err := rows.Scan(
+			&i.ID,
+			&i.Model,
+			&i.PromptTokens,
+			&i.CompletionTokens,
+			&i.CostMicros,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setSummaryContent = `-- name: SetSummaryContent :exec
+update summaries set content = $1 where id = $2
+`
+
+type SetSummaryContentParams struct {
+	Content string
+	ID      int64
+}
+
+func (q *Queries) SetSummaryContent(ctx context.Context, arg SetSummaryContentParams) error {
+	_, err := q.db.Exec(ctx, setSummaryContent, arg.Content, arg.ID)
+	return err
+}
+
+const upsertSummary = `-- name: UpsertSummary :one
+insert into summaries (period_start, period_end, input_hash, prompt_version, model, content, created_at)
+values ($1, $2, $3, $4, $5, $6, $7)
+on conflict (period_start, period_end, input_hash, prompt_version) do update
+set model = excluded.model,
+    content = excluded.content,
+    created_at = excluded.created_at
+returning id, period_start, period_end, input_hash, prompt_version, model, content, created_at
+`
+
+type UpsertSummaryParams struct {
+	PeriodStart   time.Time
+	PeriodEnd     time.Time
+	InputHash     string
+	PromptVersion int64
+	Model         string
+	Content       string
+	CreatedAt     time.Time
+}
+
+func (q *Queries) UpsertSummary(ctx context.Context, arg UpsertSummaryParams) (Summary, error) {
+	row := q.db.QueryRow(ctx, upsertSummary,
+		arg.PeriodStart,
+		arg.PeriodEnd,
+		arg.InputHash,
+		arg.PromptVersion,
+		arg.Model,
+		arg.Content,
+		arg.CreatedAt,
+	)
+	var i Summary
+	err := row.Scan(
+		&i.ID,
+		&i.PeriodStart,
+		&i.PeriodEnd,
+		&i.InputHash,
+		&i.PromptVersion,
+		&i.Model,
+		&i.Content,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Proqpine/probable-memory/sqlite"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type timerToggledMsg struct {
+	paused bool
+}
+
+type segmentsMsg struct {
+	activityID int64
+	segments   []sqlite.TimeSegment
+}
+
+// togglePause pauses the running activity, or resumes it if it is paused.
+func (m model) togglePause() tea.Msg {
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	var err error
+	paused := isPaused(m.runningSegments)
+	if paused {
+		err = resumeActivity(ctx, m.Store, *m.running, time.Now())
+	} else {
+		err = pauseActivity(ctx, m.Store, *m.running, time.Now())
+	}
+	if err != nil {
+		return errorMsg{err}
+	}
+	return timerToggledMsg{paused: !paused}
+}
+
+func (m model) fetchSelectedSegments() tea.Msg {
+	id := m.SelectedActivity.ID
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	segments, err := m.Store.QueryTimeSegments(ctx, id)
+	if err != nil {
+		return errorMsg{err}
+	}
+	return segmentsMsg{activityID: id, segments: segments}
+}
+
+// listTitle shows the running activity, if any, next to the list title.
+func (m model) listTitle() string {
+	title := "Activities"
+	if m.running != nil {
+		state := "running"
+		if isPaused(m.runningSegments) {
+			state = "paused"
+		}
+		title = fmt.Sprintf("%s • %s (%s)", title, m.running.ActivityName, state)
+	}
+	if m.filter.active() {
+		title += " • filtered"
+	}
+	if len(m.marked) > 0 {
+		title = fmt.Sprintf("%s • %d marked", title, len(m.marked))
+	}
+	return title
+}
+
+func segmentsView(segments []sqlite.TimeSegment) string {
+	if len(segments) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\nSegments:\n")
+	for _, s := range segments {
+		end := "running"
+		if s.EndTime.Valid {
+			end = s.EndTime.Time.Format("15:04")
+		}
+		d := segmentsDuration([]sqlite.TimeSegment{s}, time.Now()).Truncate(time.Second)
+		fmt.Fprintf(&b, "  %s %s – %s (%s)\n", s.StartTime.Format("2006-01-02"), s.StartTime.Format("15:04"), end, d)
+	}
+	fmt.Fprintf(&b, "  Total: %s\n", segmentsDuration(segments, time.Now()).Truncate(time.Second))
+	return b.String()
+}
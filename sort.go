@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Proqpine/probable-memory/config"
+	"github.com/Proqpine/probable-memory/goals"
+	"github.com/Proqpine/probable-memory/sqlite"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Sort and group modes, in the order the keys cycle through them. The
+// first of each is used when the config holds an unknown value.
+var (
+	sortModes  = []string{"start-desc", "start", "duration", "project", "name"}
+	groupModes = []string{"none", "day", "project"}
+)
+
+var sortLabels = map[string]string{
+	"start-desc": "newest first",
+	"start":      "oldest first",
+	"duration":   "longest first",
+	"project":    "project",
+	"name":       "name",
+}
+
+func nextMode(modes []string, current string) string {
+	i := slices.Index(modes, current)
+	return modes[(i+1)%len(modes)]
+}
+
+// listedDuration is the duration shown for an activity in the list. Unlike
+// activityDuration it does not look at segments, so a running activity
+// counts from its start including any pauses.
+func listedDuration(a sqlite.Activity, now time.Time) time.Duration {
+	if a.Duration.Valid {
+		return time.Duration(a.Duration.Int64) * time.Second
+	}
+	if a.EndTime.Valid {
+		return a.EndTime.Time.Sub(a.StartTime)
+	}
+	return now.Sub(a.StartTime)
+}
+
+// sortActivities orders activities in place by mode. Ties keep the newest
+// activity first.
+func sortActivities(activities []sqlite.Activity, mode string, now time.Time) {
+	newest := func(a, b sqlite.Activity) bool { return a.StartTime.After(b.StartTime) }
+	less := newest
+	switch mode {
+	case "start":
+		less = func(a, b sqlite.Activity) bool { return a.StartTime.Before(b.StartTime) }
+	case "duration":
+		less = func(a, b sqlite.Activity) bool {
+			da, db := listedDuration(a, now), listedDuration(b, now)
+			if da != db {
+				return da > db
+			}
+			return newest(a, b)
+		}
+	case "project":
+		less = func(a, b sqlite.Activity) bool {
+			if a.Project != b.Project {
+				return strings.ToLower(a.Project) < strings.ToLower(b.Project)
+			}
+			return newest(a, b)
+		}
+	case "name":
+		less = func(a, b sqlite.Activity) bool {
+			if a.ActivityName != b.ActivityName {
+				return strings.ToLower(a.ActivityName) < strings.ToLower(b.ActivityName)
+			}
+			return newest(a, b)
+		}
+	}
+	sort.SliceStable(activities, func(i, j int) bool { return less(activities[i], activities[j]) })
+}
+
+// groupHeader is a section header between groups of activities. It has no
+// filter value, so headers disappear while filtering.
+type groupHeader struct {
+	title    string
+	count    int
+	duration time.Duration
+}
+
+func (h groupHeader) Title() string { return "── " + h.title }
+func (h groupHeader) Description() string {
+	return fmt.Sprintf("   %d activities • %s", h.count, goals.FormatHours(h.duration))
+}
+func (h groupHeader) FilterValue() string { return "" }
+
+func groupKey(a sqlite.Activity, mode string) string {
+	switch mode {
+	case "day":
+		return a.StartTime.Local().Format("Mon 2006-01-02")
+	case "project":
+		if a.Project == "" {
+			return "No project"
+		}
+		return a.Project
+	}
+	return ""
+}
+
+// listItems builds the list items from the loaded activities in the
+// configured order, leaving out those the filter form excludes. When
+// grouping, each group starts with a header holding its subtotal, and
+// groups appear in the order of their first activity.
+func (m model) listItems() []list.Item {
+	now := time.Now()
+	sortActivities(m.Activities, m.Config.List.Sort, now)
+	shown := m.Activities
+	if m.filter.active() {
+		shown = nil
+		for _, a := range m.Activities {
+			if m.filter.matches(a, m.tags[a.ID]) {
+				shown = append(shown, a)
+			}
+		}
+	}
+	group := m.Config.List.Group
+	if group != "day" && group != "project" {
+		items := make([]list.Item, len(shown))
+		for i, a := range shown {
+			items[i] = item{activity: a}
+		}
+		return items
+	}
+
+	var keys []string
+	groups := map[string][]sqlite.Activity{}
+	for _, a := range shown {
+		key := groupKey(a, group)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], a)
+	}
+	var items []list.Item
+	for _, key := range keys {
+		h := groupHeader{title: key, count: len(groups[key])}
+		for _, a := range groups[key] {
+			h.duration += listedDuration(a, now)
+		}
+		items = append(items, h)
+		for _, a := range groups[key] {
+			items = append(items, item{activity: a})
+		}
+	}
+	return items
+}
+
+func (m model) cycleSort() (tea.Model, tea.Cmd) {
+	m.Config.List.Sort = nextMode(sortModes, m.Config.List.Sort)
+	cmd := tea.Batch(
+		m.list.SetItems(m.listItems()),
+		retryable(m.saveConfig),
+		m.list.NewStatusMessage("Sorted by "+sortLabels[m.Config.List.Sort]),
+	)
+	return m, cmd
+}
+
+func (m model) cycleGroup() (tea.Model, tea.Cmd) {
+	m.Config.List.Group = nextMode(groupModes, m.Config.List.Group)
+	status := "Grouped by " + m.Config.List.Group
+	if m.Config.List.Group == "none" {
+		status = "Not grouped"
+	}
+	cmd := tea.Batch(
+		m.list.SetItems(m.listItems()),
+		retryable(m.saveConfig),
+		m.list.NewStatusMessage(status),
+	)
+	return m, cmd
+}
+
+func (m model) saveConfig() tea.Msg {
+	if err := config.Save(m.Config); err != nil {
+		return errorMsg{fmt.Errorf("failed to save config: %v", err)}
+	}
+	return nil
+}
+
+// renderHeader draws a group header in place of a two-line item, keeping
+// the list's pagination intact.
+func (d markDelegate) renderHeader(w io.Writer, h groupHeader) {
+	s := d.Styles
+	fmt.Fprintf(w, "%s\n%s", groupStyle.Render(h.Title()), s.DimmedDesc.Render(h.Description()))
+}
@@ -11,8 +11,26 @@ import (
 	"time"
 )
 
+const claimActivities = `-- name: ClaimActivities :exec
+update activities set owner_id = cast(?1 as integer) where owner_id is null
+`
+
+func (q *Queries) ClaimActivities(ctx context.Context, ownerID int64) error {
+	_, err := q.db.ExecContext(ctx, claimActivities, ownerID)
+	return err
+}
+
+const deleteActivity = `-- name: DeleteActivity :exec
+delete from activities where id = ?
+`
+
+func (q *Queries) DeleteActivity(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteActivity, id)
+	return err
+}
+
 const insertActivity = `-- name: InsertActivity :one
-insert into activities (start_time, end_time, duration, activity_name, description, project, notes) values (?, ?, ?, ?, ?, ?, ?) returning id, start_time, end_time, duration, activity_name, description, project, notes
+insert into activities (start_time, end_time, duration, activity_name, description, project, notes, billable, owner_id, uuid) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) returning id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid
 `
 
 type InsertActivityParams struct {
@@ -23,6 +41,9 @@ type InsertActivityParams struct {
 	Description  string
 	Project      string
 	Notes        string
+	Billable     bool
+	OwnerID      sql.NullInt64
+	UUID         string
 }
 
 func (q *Queries) InsertActivity(ctx context.Context, arg InsertActivityParams) (Activity, error) {
@@ -34,6 +55,9 @@ func (q *Queries) InsertActivity(ctx context.Context, arg InsertActivityParams)
 		arg.Description,
 		arg.Project,
 		arg.Notes,
+		arg.Billable,
+		arg.OwnerID,
+		arg.UUID,
 	)
 	var i Activity
 	err := row.Scan(
@@ -45,16 +69,21 @@ func (q *Queries) InsertActivity(ctx context.Context, arg InsertActivityParams)
 		&i.Description,
 		&i.Project,
 		&i.Notes,
+		&i.HeartbeatAt,
+		&i.Billable,
+		&i.InvoiceID,
+		&i.OwnerID,
+		&i.UUID,
 	)
 	return i, err
 }
 
 const queryActivities = `-- name: QueryActivities :many
-select id, start_time, end_time, duration, activity_name, description, project, notes from activities
+select id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid from activities where owner_id = cast(?1 as integer)
 `
 
-func (q *Queries) QueryActivities(ctx context.Context) ([]Activity, error) {
-	rows, err := q.db.QueryContext(ctx, queryActivities)
+func (q *Queries) QueryActivities(ctx context.Context, ownerID int64) ([]Activity, error) {
+	rows, err := q.db.QueryContext(ctx, queryActivities, ownerID)
 	if err != nil {
 		return nil, err
 	}
@@ -71,6 +100,62 @@ func (q *Queries) QueryActivities(ctx context.Context) ([]Activity, error) {
 			&i.Description,
 			&i.Project,
 			&i.Notes,
+			&i.HeartbeatAt,
+			&i.Billable,
+			&i.InvoiceID,
+			&i.OwnerID,
+			&i.UUID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const queryActivitiesBetween = `-- name: QueryActivitiesBetween :many
+select id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid from activities
+where owner_id = cast(?1 as integer)
+  and start_time >= ?2
+  and start_time < ?3
+order by start_time
+`
+
+type QueryActivitiesBetweenParams struct {
+	OwnerID     int64
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+}
+
+func (q *Queries) QueryActivitiesBetween(ctx context.Context, arg QueryActivitiesBetweenParams) ([]Activity, error) {
+	rows, err := q.db.QueryContext(ctx, queryActivitiesBetween, arg.OwnerID, arg.PeriodStart, arg.PeriodEnd)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Activity
+	for rows.Next() {
+		var i Activity
+		if err := rows.Scan(
+			&i.ID,
+			&i.StartTime,
+			&i.EndTime,
+			&i.Duration,
+			&i.ActivityName,
+			&i.Description,
+			&i.Project,
+			&i.Notes,
+			&i.HeartbeatAt,
+			&i.Billable,
+			&i.InvoiceID,
+			&i.OwnerID,
+			&i.UUID,
 		); err != nil {
 			return nil, err
 		}
@@ -86,11 +171,180 @@ func (q *Queries) QueryActivities(ctx context.Context) ([]Activity, error) {
 }
 
 const queryActivityByProject = `-- name: QueryActivityByProject :one
-select id, start_time, end_time, duration, activity_name, description, project, notes from activities where project=?
+select id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid from activities where project = ? and owner_id = cast(?2 as integer)
+`
+
+type QueryActivityByProjectParams struct {
+	Project string
+	OwnerID int64
+}
+
+func (q *Queries) QueryActivityByProject(ctx context.Context, arg QueryActivityByProjectParams) (Activity, error) {
+	row := q.db.QueryRowContext(ctx, queryActivityByProject, arg.Project, arg.OwnerID)
+	var i Activity
+	err := row.Scan(
+		&i.ID,
+		&i.StartTime,
+		&i.EndTime,
+		&i.Duration,
+		&i.ActivityName,
+		&i.Description,
+		&i.Project,
+		&i.Notes,
+		&i.HeartbeatAt,
+		&i.Billable,
+		&i.InvoiceID,
+		&i.OwnerID,
+		&i.UUID,
+	)
+	return i, err
+}
+
+const queryActivityByUUID = `-- name: QueryActivityByUUID :one
+select id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid from activities where uuid = ?
+`
+
+func (q *Queries) QueryActivityByUUID(ctx context.Context, uuid string) (Activity, error) {
+	row := q.db.QueryRowContext(ctx, queryActivityByUUID, uuid)
+	var i Activity
+	err := row.Scan(
+		&i.ID,
+		&i.StartTime,
+		&i.EndTime,
+		&i.Duration,
+		&i.ActivityName,
+		&i.Description,
+		&i.Project,
+		&i.Notes,
+		&i.HeartbeatAt,
+		&i.Billable,
+		&i.InvoiceID,
+		&i.OwnerID,
+		&i.UUID,
+	)
+	return i, err
+}
+
+const queryRunningActivity = `-- name: QueryRunningActivity :one
+select id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid from activities
+where owner_id = cast(?1 as integer)
+  and end_time is null
+  and duration is null
+order by start_time desc
+limit 1
+`
+
+func (q *Queries) QueryRunningActivity(ctx context.Context, ownerID int64) (Activity, error) {
+	row := q.db.QueryRowContext(ctx, queryRunningActivity, ownerID)
+	var i Activity
+	err := row.Scan(
+		&i.ID,
+		&i.StartTime,
+		&i.EndTime,
+		&i.Duration,
+		&i.ActivityName,
+		&i.Description,
+		&i.Project,
+		&i.Notes,
+		&i.HeartbeatAt,
+		&i.Billable,
+		&i.InvoiceID,
+		&i.OwnerID,
+		&i.UUID,
+	)
+	return i, err
+}
+
+const queryTeamActivitiesBetween = `-- name: QueryTeamActivitiesBetween :many
+select id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid from activities
+where start_time >= ?1
+  and start_time < ?2
+order by start_time
+`
+
+type QueryTeamActivitiesBetweenParams struct {
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+}
+
+func (q *Queries) QueryTeamActivitiesBetween(ctx context.Context, arg QueryTeamActivitiesBetweenParams) ([]Activity, error) {
+	rows, err := q.db.QueryContext(ctx, queryTeamActivitiesBetween, arg.PeriodStart, arg.PeriodEnd)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Activity
+	for rows.Next() {
+		var i Activity
+		if err := rows.Scan(
+			&i.ID,
+			&i.StartTime,
+			&i.EndTime,
+			&i.Duration,
+			&i.ActivityName,
+			&i.Description,
+			&i.Project,
+			&i.Notes,
+			&i.HeartbeatAt,
+			&i.Billable,
+			&i.InvoiceID,
+			&i.OwnerID,
+			&i.UUID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setActivityProject = `-- name: SetActivityProject :one
+update activities set project = ? where id = ? returning id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid
+`
+
+type SetActivityProjectParams struct {
+	Project string
+	ID      int64
+}
+
+func (q *Queries) SetActivityProject(ctx context.Context, arg SetActivityProjectParams) (Activity, error) {
+	row := q.db.QueryRowContext(ctx, setActivityProject, arg.Project, arg.ID)
+	var i Activity
+	err := row.Scan(
+		&i.ID,
+		&i.StartTime,
+		&i.EndTime,
+		&i.Duration,
+		&i.ActivityName,
+		&i.Description,
+		&i.Project,
+		&i.Notes,
+		&i.HeartbeatAt,
+		&i.Billable,
+		&i.InvoiceID,
+		&i.OwnerID,
+		&i.UUID,
+	)
+	return i, err
+}
+
+const setActivityUUID = `-- name: SetActivityUUID :one
+update activities set uuid = ? where id = ? returning id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid
 `
 
-func (q *Queries) QueryActivityByProject(ctx context.Context, project string) (Activity, error) {
-	row := q.db.QueryRowContext(ctx, queryActivityByProject, project)
+type SetActivityUUIDParams struct {
+	UUID string
+	ID   int64
+}
+
+func (q *Queries) SetActivityUUID(ctx context.Context, arg SetActivityUUIDParams) (Activity, error) {
+	row := q.db.QueryRowContext(ctx, setActivityUUID, arg.UUID, arg.ID)
 	var i Activity
 	err := row.Scan(
 		&i.ID,
@@ -101,10 +355,64 @@ func (q *Queries) QueryActivityByProject(ctx context.Context, project string) (A
 		&i.Description,
 		&i.Project,
 		&i.Notes,
+		&i.HeartbeatAt,
+		&i.Billable,
+		&i.InvoiceID,
+		&i.OwnerID,
+		&i.UUID,
 	)
 	return i, err
 }
 
+const stopActivity = `-- name: StopActivity :one
+update activities
+set end_time = ?,
+    duration = ?
+where id = ?
+returning id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid
+`
+
+type StopActivityParams struct {
+	EndTime  sql.NullTime
+	Duration sql.NullInt64
+	ID       int64
+}
+
+func (q *Queries) StopActivity(ctx context.Context, arg StopActivityParams) (Activity, error) {
+	row := q.db.QueryRowContext(ctx, stopActivity, arg.EndTime, arg.Duration, arg.ID)
+	var i Activity
+	err := row.Scan(
+		&i.ID,
+		&i.StartTime,
+		&i.EndTime,
+		&i.Duration,
+		&i.ActivityName,
+		&i.Description,
+		&i.Project,
+		&i.Notes,
+		&i.HeartbeatAt,
+		&i.Billable,
+		&i.InvoiceID,
+		&i.OwnerID,
+		&i.UUID,
+	)
+	return i, err
+}
+
+const touchActivity = `-- name: TouchActivity :exec
+update activities set heartbeat_at = ? where id = ?
+`
+
+type TouchActivityParams struct {
+	HeartbeatAt sql.NullTime
+	ID          int64
+}
+
+func (q *Queries) TouchActivity(ctx context.Context, arg TouchActivityParams) error {
+	_, err := q.db.ExecContext(ctx, touchActivity, arg.HeartbeatAt, arg.ID)
+	return err
+}
+
 const updateActivity = `-- name: UpdateActivity :one
 update activities
 set start_time = ?,
@@ -115,7 +423,7 @@ set start_time = ?,
     project = ?,
     notes = ?
 where id = ?
-returning id, start_time, end_time, duration, activity_name, description, project, notes
+returning id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid
 `
 
 type UpdateActivityParams struct {
@@ -126,7 +434,7 @@ type UpdateActivityParams struct {
 	Description  string
 	Project      string
 	Notes        string
-	ID           interface{}
+	ID           int64
 }
 
 func (q *Queries) UpdateActivity(ctx context.Context, arg UpdateActivityParams) (Activity, error) {
@@ -150,6 +458,11 @@ func (q *Queries) UpdateActivity(ctx context.Context, arg UpdateActivityParams)
 		&i.Description,
 		&i.Project,
 		&i.Notes,
+		&i.HeartbeatAt,
+		&i.Billable,
+		&i.InvoiceID,
+		&i.OwnerID,
+		&i.UUID,
 	)
 	return i, err
 }
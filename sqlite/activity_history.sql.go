@@ -0,0 +1,118 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: activity_history.sql
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const getLastActivityHistoryID = `-- name: GetLastActivityHistoryID :one
+select cast(coalesce(max(id), 0) as integer) from activity_history
+`
+
+func (q *Queries) GetLastActivityHistoryID(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getLastActivityHistoryID)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const insertActivityHistory = `-- name: InsertActivityHistory :exec
+insert into activity_history (activity_id, action, source, old_values, new_values, changed_at) values (?, ?, ?, ?, ?, ?)
+`
+
+type InsertActivityHistoryParams struct {
+	ActivityID int64
+	Action     string
+	Source     string
+	OldValues  sql.NullString
+	NewValues  sql.NullString
+	ChangedAt  time.Time
+}
+
+func (q *Queries) InsertActivityHistory(ctx context.Context, arg InsertActivityHistoryParams) error {
+	_, err := q.db.ExecContext(ctx, insertActivityHistory,
+		arg.ActivityID,
+		arg.Action,
+		arg.Source,
+		arg.OldValues,
+		arg.NewValues,
+		arg.ChangedAt,
+	)
+	return err
+}
+
+const queryActivityHistory = `-- name: QueryActivityHistory :many
+select id, activity_id, "action", source, old_values, new_values, changed_at from activity_history where activity_id = ? order by changed_at desc, id desc
+`
+
+func (q *Queries) QueryActivityHistory(ctx context.Context, activityID int64) ([]ActivityHistory, error) {
+	rows, err := q.db.QueryContext(ctx, queryActivityHistory, activityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ActivityHistory
+	for rows.Next() {
+		var i ActivityHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.ActivityID,
+			&i.Action,
+			&i.Source,
+			&i.OldValues,
+			&i.NewValues,
+			&i.ChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const queryActivityHistorySince = `-- name: QueryActivityHistorySince :many
+select id, activity_id, "action", source, old_values, new_values, changed_at from activity_history where id > ? order by id
+`
+
+func (q *Queries) QueryActivityHistorySince(ctx context.Context, id int64) ([]ActivityHistory, error) {
+	rows, err := q.db.QueryContext(ctx, queryActivityHistorySince, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ActivityHistory
+	for rows.Next() {
+		var i ActivityHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.ActivityID,
+			&i.Action,
+			&i.Source,
+			&i.OldValues,
+			&i.NewValues,
+			&i.ChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
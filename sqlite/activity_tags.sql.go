@@ -0,0 +1,104 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: activity_tags.sql
+
+package sqlite
+
+import (
+	"context"
+)
+
+const addActivityTag = `-- name: AddActivityTag :exec
+insert into activity_tags (activity_id, tag) values (?, ?) on conflict do nothing
+`
+
+type AddActivityTagParams struct {
+	ActivityID int64
+	Tag        string
+}
+
+func (q *Queries) AddActivityTag(ctx context.Context, arg AddActivityTagParams) error {
+	_, err := q.db.ExecContext(ctx, addActivityTag, arg.ActivityID, arg.Tag)
+	return err
+}
+
+const deleteActivityTags = `-- name: DeleteActivityTags :exec
+delete from activity_tags where activity_id = ?
+`
+
+func (q *Queries) DeleteActivityTags(ctx context.Context, activityID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteActivityTags, activityID)
+	return err
+}
+
+const queryActivityTags = `-- name: QueryActivityTags :many
+select tag from activity_tags where activity_id = ? order by tag
+`
+
+func (q *Queries) QueryActivityTags(ctx context.Context, activityID int64) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, queryActivityTags, activityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		items = append(items, tag)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const queryAllActivityTags = `-- name: QueryAllActivityTags :many
+select activity_tags.activity_id, activity_tags.tag from activity_tags
+join activities on activities.id = activity_tags.activity_id
+where activities.owner_id = cast(?1 as integer)
+order by activity_tags.activity_id, activity_tags.tag
+`
+
+func (q *Queries) QueryAllActivityTags(ctx context.Context, ownerID int64) ([]ActivityTag, error) {
+	rows, err := q.db.QueryContext(ctx, queryAllActivityTags, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ActivityTag
+	for rows.Next() {
+		var i ActivityTag
+		if err := rows.Scan(&i.ActivityID, &i.Tag); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const removeActivityTag = `-- name: RemoveActivityTag :exec
+delete from activity_tags where activity_id = ? and tag = ?
+`
+
+type RemoveActivityTagParams struct {
+	ActivityID int64
+	Tag        string
+}
+
+func (q *Queries) RemoveActivityTag(ctx context.Context, arg RemoveActivityTagParams) error {
+	_, err := q.db.ExecContext(ctx, removeActivityTag, arg.ActivityID, arg.Tag)
+	return err
+}
@@ -0,0 +1,328 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: billing.sql
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const getClient = `-- name: GetClient :one
+select name, hourly_rate, currency from clients where name = ?
+`
+
+func (q *Queries) GetClient(ctx context.Context, name string) (Client, error) {
+	row := q.db.QueryRowContext(ctx, getClient, name)
+	var i Client
+	err := row.Scan(&i.Name, &i.HourlyRate, &i.Currency)
+	return i, err
+}
+
+const getProject = `-- name: GetProject :one
+select name, client, billable, hourly_rate, currency from projects where name = ?
+`
+
+func (q *Queries) GetProject(ctx context.Context, name string) (Project, error) {
+	row := q.db.QueryRowContext(ctx, getProject, name)
+	var i Project
+	err := row.Scan(
+		&i.Name,
+		&i.Client,
+		&i.Billable,
+		&i.HourlyRate,
+		&i.Currency,
+	)
+	return i, err
+}
+
+const insertInvoice = `-- name: InsertInvoice :one
+insert into invoices (number, year, sequence, client, period_start, period_end, issued_at, currency, rounding_minutes, rounding_mode, total)
+values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+returning id, number, year, sequence, client, period_start, period_end, issued_at, currency, rounding_minutes, rounding_mode, total
+`
+
+type InsertInvoiceParams struct {
+	Number          string
+	Year            int64
+	Sequence        int64
+	Client          string
+	PeriodStart     time.Time
+	PeriodEnd       time.Time
+	IssuedAt        time.Time
+	Currency        string
+	RoundingMinutes int64
+	RoundingMode    string
+	Total           int64
+}
+
+func (q *Queries) InsertInvoice(ctx context.Context, arg InsertInvoiceParams) (Invoice, error) {
+	row := q.db.QueryRowContext(ctx, insertInvoice,
+		arg.Number,
+		arg.Year,
+		arg.Sequence,
+		arg.Client,
+		arg.PeriodStart,
+		arg.PeriodEnd,
+		arg.IssuedAt,
+		arg.Currency,
+		arg.RoundingMinutes,
+		arg.RoundingMode,
+		arg.Total,
+	)
+	var i Invoice
+	err := row.Scan(
+		&i.ID,
+		&i.Number,
+		&i.Year,
+		&i.Sequence,
+		&i.Client,
+		&i.PeriodStart,
+		&i.PeriodEnd,
+		&i.IssuedAt,
+		&i.Currency,
+		&i.RoundingMinutes,
+		&i.RoundingMode,
+		&i.Total,
+	)
+	return i, err
+}
+
+const insertInvoiceLine = `-- name: InsertInvoiceLine :exec
+insert into invoice_lines (invoice_id, project, minutes, hourly_rate, amount) values (?, ?, ?, ?, ?)
+`
+
+type InsertInvoiceLineParams struct {
+	InvoiceID  int64
+	Project    string
+	Minutes    int64
+	HourlyRate int64
+	Amount     int64
+}
+
+func (q *Queries) InsertInvoiceLine(ctx context.Context, arg InsertInvoiceLineParams) error {
+	_, err := q.db.ExecContext(ctx, insertInvoiceLine,
+		arg.InvoiceID,
+		arg.Project,
+		arg.Minutes,
+		arg.HourlyRate,
+		arg.Amount,
+	)
+	return err
+}
+
+const nextInvoiceSequence = `-- name: NextInvoiceSequence :one
+select cast(coalesce(max(sequence), 0) + 1 as integer) from invoices where year = ?
+`
+
+func (q *Queries) NextInvoiceSequence(ctx context.Context, year int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, nextInvoiceSequence, year)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const queryBillableActivities = `-- name: QueryBillableActivities :many
+select id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid from activities
+where project = ?
+  and billable
+  and invoice_id is null
+  and end_time is not null
+  and start_time >= ?2
+  and start_time < ?3
+order by start_time
+`
+
+type QueryBillableActivitiesParams struct {
+	Project     string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+}
+
+func (q *Queries) QueryBillableActivities(ctx context.Context, arg QueryBillableActivitiesParams) ([]Activity, error) {
+	rows, err := q.db.QueryContext(ctx, queryBillableActivities, arg.Project, arg.PeriodStart, arg.PeriodEnd)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Activity
+	for rows.Next() {
+		var i Activity
+		if err := rows.Scan(
+			&i.ID,
+			&i.StartTime,
+			&i.EndTime,
+			&i.Duration,
+			&i.ActivityName,
+			&i.Description,
+			&i.Project,
+			&i.Notes,
+			&i.HeartbeatAt,
+			&i.Billable,
+			&i.InvoiceID,
+			&i.OwnerID,
+			&i.UUID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const queryProjectsByClient = `-- name: QueryProjectsByClient :many
+select name, client, billable, hourly_rate, currency from projects where client = ? order by name
+`
+
+func (q *Queries) QueryProjectsByClient(ctx context.Context, client sql.NullString) ([]Project, error) {
+	rows, err := q.db.QueryContext(ctx, queryProjectsByClient, client)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Project
+	for rows.Next() {
+		var i Project
+		if err := rows.Scan(
+			&i.Name,
+			&i.Client,
+			&i.Billable,
+			&i.HourlyRate,
+			&i.Currency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setActivityBillable = `-- name: SetActivityBillable :one
+update activities set billable = ? where id = ? returning id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid
+`
+
+type SetActivityBillableParams struct {
+	Billable bool
+	ID       int64
+}
+
+func (q *Queries) SetActivityBillable(ctx context.Context, arg SetActivityBillableParams) (Activity, error) {
+	row := q.db.QueryRowContext(ctx, setActivityBillable, arg.Billable, arg.ID)
+	var i Activity
+	err := row.Scan(
+		&i.ID,
+		&i.StartTime,
+		&i.EndTime,
+		&i.Duration,
+		&i.ActivityName,
+		&i.Description,
+		&i.Project,
+		&i.Notes,
+		&i.HeartbeatAt,
+		&i.Billable,
+		&i.InvoiceID,
+		&i.OwnerID,
+		&i.UUID,
+	)
+	return i, err
+}
+
+const setActivityInvoice = `-- name: SetActivityInvoice :one
+update activities set invoice_id = ? where id = ? returning id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid
+`
+
+type SetActivityInvoiceParams struct {
+	InvoiceID sql.NullInt64
+	ID        int64
+}
+
+func (q *Queries) SetActivityInvoice(ctx context.Context, arg SetActivityInvoiceParams) (Activity, error) {
+	row := q.db.QueryRowContext(ctx, setActivityInvoice, arg.InvoiceID, arg.ID)
+	var i Activity
+	err := row.Scan(
+		&i.ID,
+		&i.StartTime,
+		&i.EndTime,
+		&i.Duration,
+		&i.ActivityName,
+		&i.Description,
+		&i.Project,
+		&i.Notes,
+		&i.HeartbeatAt,
+		&i.Billable,
+		&i.InvoiceID,
+		&i.OwnerID,
+		&i.UUID,
+	)
+	return i, err
+}
+
+const upsertClient = `-- name: UpsertClient :one
+insert into clients (name, hourly_rate, currency) values (?, ?, ?)
+on conflict (name) do update set hourly_rate = excluded.hourly_rate, currency = excluded.currency
+returning name, hourly_rate, currency
+`
+
+type UpsertClientParams struct {
+	Name       string
+	HourlyRate sql.NullInt64
+	Currency   string
+}
+
+func (q *Queries) UpsertClient(ctx context.Context, arg UpsertClientParams) (Client, error) {
+	row := q.db.QueryRowContext(ctx, upsertClient, arg.Name, arg.HourlyRate, arg.Currency)
+	var i Client
+	err := row.Scan(&i.Name, &i.HourlyRate, &i.Currency)
+	return i, err
+}
+
+const upsertProject = `-- name: UpsertProject :one
+insert into projects (name, client, billable, hourly_rate, currency) values (?, ?, ?, ?, ?)
+on conflict (name) do update set client = excluded.client,
+    billable = excluded.billable,
+    hourly_rate = excluded.hourly_rate,
+    currency = excluded.currency
+returning name, client, billable, hourly_rate, currency
+`
+
+type UpsertProjectParams struct {
+	Name       string
+	Client     sql.NullString
+	Billable   bool
+	HourlyRate sql.NullInt64
+	Currency   sql.NullString
+}
+
+func (q *Queries) UpsertProject(ctx context.Context, arg UpsertProjectParams) (Project, error) {
+	row := q.db.QueryRowContext(ctx, upsertProject,
+		arg.Name,
+		arg.Client,
+		arg.Billable,
+		arg.HourlyRate,
+		arg.Currency,
+	)
+	var i Project
+	err := row.Scan(
+		&i.Name,
+		&i.Client,
+		&i.Billable,
+		&i.HourlyRate,
+		&i.Currency,
+	)
+	return i, err
+}
@@ -0,0 +1,161 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: encryption.sql
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+)
+
+const deleteEncryption = `-- name: DeleteEncryption :exec
+delete from encryption
+`
+
+func (q *Queries) DeleteEncryption(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteEncryption)
+	return err
+}
+
+const getEncryption = `-- name: GetEncryption :one
+select kdf, salt, verifier, rekeying from encryption limit 1
+`
+
+func (q *Queries) GetEncryption(ctx context.Context) (Encryption, error) {
+	row := q.db.QueryRowContext(ctx, getEncryption)
+	var i Encryption
+	err := row.Scan(
+		&i.Kdf,
+		&i.Salt,
+		&i.Verifier,
+		&i.Rekeying,
+	)
+	return i, err
+}
+
+const insertEncryption = `-- name: InsertEncryption :exec
+insert into encryption (kdf, salt, verifier) values (?, ?, ?)
+`
+
+type InsertEncryptionParams struct {
+	Kdf      string
+	Salt     string
+	Verifier string
+}
+
+func (q *Queries) InsertEncryption(ctx context.Context, arg InsertEncryptionParams) error {
+	_, err := q.db.ExecContext(ctx, insertEncryption, arg.Kdf, arg.Salt, arg.Verifier)
+	return err
+}
+
+const queryAllActivities = `-- name: QueryAllActivities :many
+select id, start_time, end_time, duration, activity_name, description, project, notes, heartbeat_at, billable, invoice_id, owner_id, uuid from activities order by id
+`
+
+func (q *Queries) QueryAllActivities(ctx context.Context) ([]Activity, error) {
+	rows, err := q.db.QueryContext(ctx, queryAllActivities)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Activity
+	for rows.Next() {
+		var i Activity
+		if err := rows.Scan(
+			&i.ID,
+			&i.StartTime,
+			&i.EndTime,
+			&i.Duration,
+			&i.ActivityName,
+			&i.Description,
+			&i.Project,
+			&i.Notes,
+			&i.HeartbeatAt,
+			&i.Billable,
+			&i.InvoiceID,
+			&i.OwnerID,
+			&i.UUID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setActivityHistoryValues = `-- name: SetActivityHistoryValues :exec
+update activity_history set old_values = ?, new_values = ? where id = ?
+`
+
+type SetActivityHistoryValuesParams struct {
+	OldValues sql.NullString
+	NewValues sql.NullString
+	ID        int64
+}
+
+func (q *Queries) SetActivityHistoryValues(ctx context.Context, arg SetActivityHistoryValuesParams) error {
+	_, err := q.db.ExecContext(ctx, setActivityHistoryValues, arg.OldValues, arg.NewValues, arg.ID)
+	return err
+}
+
+const setActivityText = `-- name: SetActivityText :exec
+update activities set description = ?, notes = ? where id = ?
+`
+
+type SetActivityTextParams struct {
+	Description string
+	Notes       string
+	ID          int64
+}
+
+func (q *Queries) SetActivityText(ctx context.Context, arg SetActivityTextParams) error {
+	_, err := q.db.ExecContext(ctx, setActivityText, arg.Description, arg.Notes, arg.ID)
+	return err
+}
+
+const setEncryptionRekeying = `-- name: SetEncryptionRekeying :exec
+update encryption set rekeying = ?
+`
+
+func (q *Queries) SetEncryptionRekeying(ctx context.Context, rekeying bool) error {
+	_, err := q.db.ExecContext(ctx, setEncryptionRekeying, rekeying)
+	return err
+}
+
+const setSyncConflictValues = `-- name: SetSyncConflictValues :exec
+update sync_conflicts set kept = ?, other = ? where id = ?
+`
+
+type SetSyncConflictValuesParams struct {
+	Kept  string
+	Other string
+	ID    int64
+}
+
+func (q *Queries) SetSyncConflictValues(ctx context.Context, arg SetSyncConflictValuesParams) error {
+	_, err := q.db.ExecContext(ctx, setSyncConflictValues, arg.Kept, arg.Other, arg.ID)
+	return err
+}
+
+const updateEncryption = `-- name: UpdateEncryption :exec
+update encryption set kdf = ?, salt = ?, verifier = ?
+`
+
+type UpdateEncryptionParams struct {
+	Kdf      string
+	Salt     string
+	Verifier string
+}
+
+func (q *Queries) UpdateEncryption(ctx context.Context, arg UpdateEncryptionParams) error {
+	_, err := q.db.ExecContext(ctx, updateEncryption, arg.Kdf, arg.Salt, arg.Verifier)
+	return err
+}
@@ -0,0 +1,87 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: goals.sql
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+)
+
+const deleteGoal = `-- name: DeleteGoal :exec
+delete from goals where project = ? and period = ?
+`
+
+type DeleteGoalParams struct {
+	Project string
+	Period  string
+}
+
+func (q *Queries) DeleteGoal(ctx context.Context, arg DeleteGoalParams) error {
+	_, err := q.db.ExecContext(ctx, deleteGoal, arg.Project, arg.Period)
+	return err
+}
+
+const queryGoals = `-- name: QueryGoals :many
+select project, period, min_minutes, max_minutes from goals order by project, period
+`
+
+func (q *Queries) QueryGoals(ctx context.Context) ([]Goal, error) {
+	rows, err := q.db.QueryContext(ctx, queryGoals)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Goal
+	for rows.Next() {
+		var i Goal
+		if err := rows.Scan(
+			&i.Project,
+			&i.Period,
+			&i.MinMinutes,
+			&i.MaxMinutes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertGoal = `-- name: UpsertGoal :one
+insert into goals (project, period, min_minutes, max_minutes) values (?, ?, ?, ?)
+on conflict (project, period) do update set min_minutes = excluded.min_minutes, max_minutes = excluded.max_minutes
+returning project, period, min_minutes, max_minutes
+`
+
+type UpsertGoalParams struct {
+	Project    string
+	Period     string
+	MinMinutes sql.NullInt64
+	MaxMinutes sql.NullInt64
+}
+
+func (q *Queries) UpsertGoal(ctx context.Context, arg UpsertGoalParams) (Goal, error) {
+	row := q.db.QueryRowContext(ctx, upsertGoal,
+		arg.Project,
+		arg.Period,
+		arg.MinMinutes,
+		arg.MaxMinutes,
+	)
+	var i Goal
+	err := row.Scan(
+		&i.Project,
+		&i.Period,
+		&i.MinMinutes,
+		&i.MaxMinutes,
+	)
+	return i, err
+}
@@ -10,7 +10,7 @@ import (
 )
 
 type Activity struct {
-	ID           interface{}
+	ID           int64
 	StartTime    time.Time
 	EndTime      sql.NullTime
 	Duration     sql.NullInt64
@@ -18,4 +18,135 @@ type Activity struct {
 	Description  string
 	Project      string
 	Notes        string
+	HeartbeatAt  sql.NullTime
+	Billable     bool
+	InvoiceID    sql.NullInt64
+	OwnerID      sql.NullInt64
+	UUID         string
+}
+
+type ActivityHistory struct {
+	ID         int64
+	ActivityID int64
+	Action     string
+	Source     string
+	OldValues  sql.NullString
+	NewValues  sql.NullString
+	ChangedAt  time.Time
+}
+
+type ActivityTag struct {
+	ActivityID int64
+	Tag        string
+}
+
+type Client struct {
+	Name       string
+	HourlyRate sql.NullInt64
+	Currency   string
+}
+
+type Encryption struct {
+	Kdf      string
+	Salt     string
+	Verifier string
+	Rekeying bool
+}
+
+type Goal struct {
+	Project    string
+	Period     string
+	MinMinutes sql.NullInt64
+	MaxMinutes sql.NullInt64
+}
+
+type Invoice struct {
+	ID              int64
+	Number          string
+	Year            int64
+	Sequence        int64
+	Client          string
+	PeriodStart     time.Time
+	PeriodEnd       time.Time
+	IssuedAt        time.Time
+	Currency        string
+	RoundingMinutes int64
+	RoundingMode    string
+	Total           int64
+}
+
+type InvoiceLine struct {
+	ID         int64
+	InvoiceID  int64
+	Project    string
+	Minutes    int64
+	HourlyRate int64
+	Amount     int64
+}
+
+type LLMCall struct {
+	ID               int64
+	Model            string
+	PromptTokens     int64
+	CompletionTokens int64
+	CostMicros       sql.NullInt64
+	CreatedAt        time.Time
+}
+
+type Project struct {
+	Name       string
+	Client     sql.NullString
+	Billable   bool
+	HourlyRate sql.NullInt64
+	Currency   sql.NullString
+}
+
+type Summary struct {
+	ID            int64
+	PeriodStart   time.Time
+	PeriodEnd     time.Time
+	InputHash     string
+	PromptVersion int64
+	Model         string
+	Content       string
+	CreatedAt     time.Time
+}
+
+type SyncClock struct {
+	ActivityUUID string
+	Field        string
+	ChangedAt    time.Time
+	Device       string
+	Seq          int64
+}
+
+type SyncConflict struct {
+	ID           int64
+	ActivityUUID string
+	Field        string
+	Kept         string
+	Other        string
+	CreatedAt    time.Time
+}
+
+type SyncDevice struct {
+	Device  string
+	Applied int64
+}
+
+type SyncLocal struct {
+	Device            string
+	ExportedHistoryID int64
+}
+
+type TimeSegment struct {
+	ID         int64
+	ActivityID int64
+	StartTime  time.Time
+	EndTime    sql.NullTime
+}
+
+type User struct {
+	ID   int64
+	Name string
 }
@@ -0,0 +1,84 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+)
+
+type Querier interface {
+	AddActivityTag(ctx context.Context, arg AddActivityTagParams) error
+	ClaimActivities(ctx context.Context, ownerID int64) error
+	CloseTimeSegment(ctx context.Context, arg CloseTimeSegmentParams) error
+	DeleteActivity(ctx context.Context, id int64) error
+	DeleteActivityTags(ctx context.Context, activityID int64) error
+	DeleteEncryption(ctx context.Context) error
+	DeleteGoal(ctx context.Context, arg DeleteGoalParams) error
+	DeleteSyncConflict(ctx context.Context, id int64) error
+	DeleteTimeSegments(ctx context.Context, activityID int64) error
+	GetClient(ctx context.Context, name string) (Client, error)
+	GetEncryption(ctx context.Context) (Encryption, error)
+	GetLastActivityHistoryID(ctx context.Context) (int64, error)
+	GetProject(ctx context.Context, name string) (Project, error)
+	GetSummary(ctx context.Context, arg GetSummaryParams) (Summary, error)
+	GetSyncClock(ctx context.Context, arg GetSyncClockParams) (SyncClock, error)
+	GetSyncLocal(ctx context.Context) (SyncLocal, error)
+	GetUser(ctx context.Context, name string) (User, error)
+	InsertActivity(ctx context.Context, arg InsertActivityParams) (Activity, error)
+	InsertActivityHistory(ctx context.Context, arg InsertActivityHistoryParams) error
+	InsertEncryption(ctx context.Context, arg InsertEncryptionParams) error
+	InsertInvoice(ctx context.Context, arg InsertInvoiceParams) (Invoice, error)
+	InsertInvoiceLine(ctx context.Context, arg InsertInvoiceLineParams) error
+	InsertLLMCall(ctx context.Context, arg InsertLLMCallParams) error
+	InsertSyncConflict(ctx context.Context, arg InsertSyncConflictParams) error
+	InsertSyncLocal(ctx context.Context, arg InsertSyncLocalParams) error
+	InsertTimeSegment(ctx context.Context, arg InsertTimeSegmentParams) (TimeSegment, error)
+	NextInvoiceSequence(ctx context.Context, year int64) (int64, error)
+	QueryActivities(ctx context.Context, ownerID int64) ([]Activity, error)
+	QueryActivitiesBetween(ctx context.Context, arg QueryActivitiesBetweenParams) ([]Activity, error)
+	QueryActivityByProject(ctx context.Context, arg QueryActivityByProjectParams) (Activity, error)
+	QueryActivityByUUID(ctx context.Context, uuid string) (Activity, error)
+	QueryActivityHistory(ctx context.Context, activityID int64) ([]ActivityHistory, error)
+	QueryActivityHistorySince(ctx context.Context, id int64) ([]ActivityHistory, error)
+	QueryActivityTags(ctx context.Context, activityID int64) ([]string, error)
+	QueryAllActivities(ctx context.Context) ([]Activity, error)
+	QueryAllActivityTags(ctx context.Context, ownerID int64) ([]ActivityTag, error)
+	QueryAllSummaries(ctx context.Context) ([]Summary, error)
+	QueryBillableActivities(ctx context.Context, arg QueryBillableActivitiesParams) ([]Activity, error)
+	QueryGoals(ctx context.Context) ([]Goal, error)
+	QueryLLMCalls(ctx context.Context) ([]LLMCall, error)
+	QueryProjectsByClient(ctx context.Context, client sql.NullString) ([]Project, error)
+	QueryRunningActivity(ctx context.Context, ownerID int64) (Activity, error)
+	QuerySyncConflicts(ctx context.Context) ([]SyncConflict, error)
+	QuerySyncDevices(ctx context.Context) ([]SyncDevice, error)
+	QueryTeamActivitiesBetween(ctx context.Context, arg QueryTeamActivitiesBetweenParams) ([]Activity, error)
+	QueryTimeSegments(ctx context.Context, activityID int64) ([]TimeSegment, error)
+	RemoveActivityTag(ctx context.Context, arg RemoveActivityTagParams) error
+	SetActivityBillable(ctx context.Context, arg SetActivityBillableParams) (Activity, error)
+	SetActivityHistoryValues(ctx context.Context, arg SetActivityHistoryValuesParams) error
+	SetActivityInvoice(ctx context.Context, arg SetActivityInvoiceParams) (Activity, error)
+	SetActivityProject(ctx context.Context, arg SetActivityProjectParams) (Activity, error)
+	SetActivityText(ctx context.Context, arg SetActivityTextParams) error
+	SetActivityUUID(ctx context.Context, arg SetActivityUUIDParams) (Activity, error)
+	SetEncryptionRekeying(ctx context.Context, rekeying bool) error
+	SetSummaryContent(ctx context.Context, arg SetSummaryContentParams) error
+	SetSyncClock(ctx context.Context, arg SetSyncClockParams) error
+	SetSyncConflictValues(ctx context.Context, arg SetSyncConflictValuesParams) error
+	SetSyncDeviceApplied(ctx context.Context, arg SetSyncDeviceAppliedParams) error
+	SetSyncExported(ctx context.Context, exportedHistoryID int64) error
+	StopActivity(ctx context.Context, arg StopActivityParams) (Activity, error)
+	TouchActivity(ctx context.Context, arg TouchActivityParams) error
+	UpdateActivity(ctx context.Context, arg UpdateActivityParams) (Activity, error)
+	UpdateEncryption(ctx context.Context, arg UpdateEncryptionParams) error
+	UpdateTimeSegment(ctx context.Context, arg UpdateTimeSegmentParams) error
+	UpsertClient(ctx context.Context, arg UpsertClientParams) (Client, error)
+	UpsertGoal(ctx context.Context, arg UpsertGoalParams) (Goal, error)
+	UpsertProject(ctx context.Context, arg UpsertProjectParams) (Project, error)
+	UpsertSummary(ctx context.Context, arg UpsertSummaryParams) (Summary, error)
+	UpsertUser(ctx context.Context, name string) (User, error)
+}
+
+var _ Querier = (*Queries)(nil)
@@ -1,11 +1,11 @@
 -- name: QueryActivities :many
-select * from activities;
+select * from activities where owner_id = cast(sqlc.arg(owner_id) as integer);
 
 -- name: QueryActivityByProject :one
-select * from activities where project=?;
+select * from activities where project = ? and owner_id = cast(sqlc.arg(owner_id) as integer);
 
 -- name: InsertActivity :one
-insert into activities (start_time, end_time, duration, activity_name, description, project, notes) values (?, ?, ?, ?, ?, ?, ?) returning *;
+insert into activities (start_time, end_time, duration, activity_name, description, project, notes, billable, owner_id, uuid) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) returning *;
 
 -- name: UpdateActivity :one
 update activities
@@ -18,3 +18,49 @@ set start_time = ?,
     notes = ?
 where id = ?
 returning *;
+
+-- name: QueryRunningActivity :one
+select * from activities
+where owner_id = cast(sqlc.arg(owner_id) as integer)
+  and end_time is null
+  and duration is null
+order by start_time desc
+limit 1;
+
+-- name: StopActivity :one
+update activities
+set end_time = ?,
+    duration = ?
+where id = ?
+returning *;
+
+-- name: TouchActivity :exec
+update activities set heartbeat_at = ? where id = ?;
+
+-- name: QueryActivitiesBetween :many
+select * from activities
+where owner_id = cast(sqlc.arg(owner_id) as integer)
+  and start_time >= sqlc.arg(period_start)
+  and start_time < sqlc.arg(period_end)
+order by start_time;
+
+-- name: QueryTeamActivitiesBetween :many
+select * from activities
+where start_time >= sqlc.arg(period_start)
+  and start_time < sqlc.arg(period_end)
+order by start_time;
+
+-- name: SetActivityProject :one
+update activities set project = ? where id = ? returning *;
+
+-- name: DeleteActivity :exec
+delete from activities where id = ?;
+
+-- name: ClaimActivities :exec
+update activities set owner_id = cast(sqlc.arg(owner_id) as integer) where owner_id is null;
+
+-- name: QueryActivityByUUID :one
+select * from activities where uuid = ?;
+
+-- name: SetActivityUUID :one
+update activities set uuid = ? where id = ? returning *;
@@ -0,0 +1,11 @@
+-- name: InsertActivityHistory :exec
+insert into activity_history (activity_id, action, source, old_values, new_values, changed_at) values (?, ?, ?, ?, ?, ?);
+
+-- name: QueryActivityHistory :many
+select * from activity_history where activity_id = ? order by changed_at desc, id desc;
+
+-- name: QueryActivityHistorySince :many
+select * from activity_history where id > ? order by id;
+
+-- name: GetLastActivityHistoryID :one
+select cast(coalesce(max(id), 0) as integer) from activity_history;
@@ -0,0 +1,17 @@
+-- name: QueryActivityTags :many
+select tag from activity_tags where activity_id = ? order by tag;
+
+-- name: AddActivityTag :exec
+insert into activity_tags (activity_id, tag) values (?, ?) on conflict do nothing;
+
+-- name: RemoveActivityTag :exec
+delete from activity_tags where activity_id = ? and tag = ?;
+
+-- name: DeleteActivityTags :exec
+delete from activity_tags where activity_id = ?;
+
+-- name: QueryAllActivityTags :many
+select activity_tags.activity_id, activity_tags.tag from activity_tags
+join activities on activities.id = activity_tags.activity_id
+where activities.owner_id = cast(sqlc.arg(owner_id) as integer)
+order by activity_tags.activity_id, activity_tags.tag;
@@ -0,0 +1,48 @@
+-- name: UpsertClient :one
+insert into clients (name, hourly_rate, currency) values (?, ?, ?)
+on conflict (name) do update set hourly_rate = excluded.hourly_rate, currency = excluded.currency
+returning *;
+
+-- name: GetClient :one
+select * from clients where name = ?;
+
+-- name: UpsertProject :one
+insert into projects (name, client, billable, hourly_rate, currency) values (?, ?, ?, ?, ?)
+on conflict (name) do update set client = excluded.client,
+    billable = excluded.billable,
+    hourly_rate = excluded.hourly_rate,
+    currency = excluded.currency
+returning *;
+
+-- name: GetProject :one
+select * from projects where name = ?;
+
+-- name: QueryProjectsByClient :many
+select * from projects where client = ? order by name;
+
+-- name: SetActivityBillable :one
+update activities set billable = ? where id = ? returning *;
+
+-- name: QueryBillableActivities :many
+select * from activities
+where project = ?
+  and billable
+  and invoice_id is null
+  and end_time is not null
+  and start_time >= sqlc.arg(period_start)
+  and start_time < sqlc.arg(period_end)
+order by start_time;
+
+-- name: NextInvoiceSequence :one
+select cast(coalesce(max(sequence), 0) + 1 as integer) from invoices where year = ?;
+
+-- name: InsertInvoice :one
+insert into invoices (number, year, sequence, client, period_start, period_end, issued_at, currency, rounding_minutes, rounding_mode, total)
+values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+returning *;
+
+-- name: InsertInvoiceLine :exec
+insert into invoice_lines (invoice_id, project, minutes, hourly_rate, amount) values (?, ?, ?, ?, ?);
+
+-- name: SetActivityInvoice :one
+update activities set invoice_id = ? where id = ? returning *;
@@ -0,0 +1,26 @@
+-- name: GetEncryption :one
+select * from encryption limit 1;
+
+-- name: InsertEncryption :exec
+insert into encryption (kdf, salt, verifier) values (?, ?, ?);
+
+-- name: UpdateEncryption :exec
+update encryption set kdf = ?, salt = ?, verifier = ?;
+
+-- name: DeleteEncryption :exec
+delete from encryption;
+
+-- name: SetEncryptionRekeying :exec
+update encryption set rekeying = ?;
+
+-- name: QueryAllActivities :many
+select * from activities order by id;
+
+-- name: SetActivityText :exec
+update activities set description = ?, notes = ? where id = ?;
+
+-- name: SetActivityHistoryValues :exec
+update activity_history set old_values = ?, new_values = ? where id = ?;
+
+-- name: SetSyncConflictValues :exec
+update sync_conflicts set kept = ?, other = ? where id = ?;
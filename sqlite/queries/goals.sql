@@ -0,0 +1,10 @@
+-- name: QueryGoals :many
+select * from goals order by project, period;
+
+-- name: UpsertGoal :one
+insert into goals (project, period, min_minutes, max_minutes) values (?, ?, ?, ?)
+on conflict (project, period) do update set min_minutes = excluded.min_minutes, max_minutes = excluded.max_minutes
+returning *;
+
+-- name: DeleteGoal :exec
+delete from goals where project = ? and period = ?;
@@ -0,0 +1,14 @@
+-- name: QueryTimeSegments :many
+select * from time_segments where activity_id = ? order by start_time;
+
+-- name: InsertTimeSegment :one
+insert into time_segments (activity_id, start_time, end_time) values (?, ?, ?) returning *;
+
+-- name: CloseTimeSegment :exec
+update time_segments set end_time = ? where id = ?;
+
+-- name: UpdateTimeSegment :exec
+update time_segments set start_time = ?, end_time = ? where id = ?;
+
+-- name: DeleteTimeSegments :exec
+delete from time_segments where activity_id = ?;
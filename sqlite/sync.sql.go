@@ -0,0 +1,202 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: sync.sql
+
+package sqlite
+
+import (
+	"context"
+	"time"
+)
+
+const deleteSyncConflict = `-- name: DeleteSyncConflict :exec
+delete from sync_conflicts where id = ?
+`
+
+func (q *Queries) DeleteSyncConflict(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteSyncConflict, id)
+	return err
+}
+
+const getSyncClock = `-- name: GetSyncClock :one
+select activity_uuid, field, changed_at, device, seq from sync_clocks where activity_uuid = ? and field = ?
+`
+
+type GetSyncClockParams struct {
+	ActivityUUID string
+	Field        string
+}
+
+func (q *Queries) GetSyncClock(ctx context.Context, arg GetSyncClockParams) (SyncClock, error) {
+	row := q.db.QueryRowContext(ctx, getSyncClock, arg.ActivityUUID, arg.Field)
+	var i SyncClock
+	err := row.Scan(
+		&i.ActivityUUID,
+		&i.Field,
+		&i.ChangedAt,
+		&i.Device,
+		&i.Seq,
+	)
+	return i, err
+}
+
+const getSyncLocal = `-- name: GetSyncLocal :one
+select device, exported_history_id from sync_local limit 1
+`
+
+func (q *Queries) GetSyncLocal(ctx context.Context) (SyncLocal, error) {
+	row := q.db.QueryRowContext(ctx, getSyncLocal)
+	var i SyncLocal
+	err := row.Scan(&i.Device, &i.ExportedHistoryID)
+	return i, err
+}
+
+const insertSyncConflict = `-- name: InsertSyncConflict :exec
+insert into sync_conflicts (activity_uuid, field, kept, other, created_at) values (?, ?, ?, ?, ?)
+`
+
+type InsertSyncConflictParams struct {
+	ActivityUUID string
+	Field        string
+	Kept         string
+	Other        string
+	CreatedAt    time.Time
+}
+
+func (q *Queries) InsertSyncConflict(ctx context.Context, arg InsertSyncConflictParams) error {
+	_, err := q.db.ExecContext(ctx, insertSyncConflict,
+		arg.ActivityUUID,
+		arg.Field,
+		arg.Kept,
+		arg.Other,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const insertSyncLocal = `-- name: InsertSyncLocal :exec
+insert into sync_local (device, exported_history_id) values (?, ?)
+`
+
+type InsertSyncLocalParams struct {
+	Device            string
+	ExportedHistoryID int64
+}
+
+func (q *Queries) InsertSyncLocal(ctx context.Context, arg InsertSyncLocalParams) error {
+	_, err := q.db.ExecContext(ctx, insertSyncLocal, arg.Device, arg.ExportedHistoryID)
+	return err
+}
+
+const querySyncConflicts = `-- name: QuerySyncConflicts :many
+select id, activity_uuid, field, kept, other, created_at from sync_conflicts order by id
+`
+
+func (q *Queries) QuerySyncConflicts(ctx context.Context) ([]SyncConflict, error) {
+	rows, err := q.db.QueryContext(ctx, querySyncConflicts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SyncConflict
+	for rows.Next() {
+		var i SyncConflict
+		if err := rows.Scan(
+			&i.ID,
+			&i.ActivityUUID,
+			&i.Field,
+			&i.Kept,
+			&i.Other,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const querySyncDevices = `-- name: QuerySyncDevices :many
+select device, applied from sync_devices order by device
+`
+
+func (q *Queries) QuerySyncDevices(ctx context.Context) ([]SyncDevice, error) {
+	rows, err := q.db.QueryContext(ctx, querySyncDevices)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SyncDevice
+	for rows.Next() {
+		var i SyncDevice
+		if err := rows.Scan(&i.Device, &i.Applied); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setSyncClock = `-- name: SetSyncClock :exec
+insert into sync_clocks (activity_uuid, field, changed_at, device, seq) values (?, ?, ?, ?, ?)
+on conflict (activity_uuid, field) do update
+set changed_at = excluded.changed_at,
+    device = excluded.device,
+    seq = excluded.seq
+`
+
+type SetSyncClockParams struct {
+	ActivityUUID string
+	Field        string
+	ChangedAt    time.Time
+	Device       string
+	Seq          int64
+}
+
+func (q *Queries) SetSyncClock(ctx context.Context, arg SetSyncClockParams) error {
+	_, err := q.db.ExecContext(ctx, setSyncClock,
+		arg.ActivityUUID,
+		arg.Field,
+		arg.ChangedAt,
+		arg.Device,
+		arg.Seq,
+	)
+	return err
+}
+
+const setSyncDeviceApplied = `-- name: SetSyncDeviceApplied :exec
+insert into sync_devices (device, applied) values (?, ?)
+on conflict (device) do update set applied = excluded.applied
+`
+
+type SetSyncDeviceAppliedParams struct {
+	Device  string
+	Applied int64
+}
+
+func (q *Queries) SetSyncDeviceApplied(ctx context.Context, arg SetSyncDeviceAppliedParams) error {
+	_, err := q.db.ExecContext(ctx, setSyncDeviceApplied, arg.Device, arg.Applied)
+	return err
+}
+
+const setSyncExported = `-- name: SetSyncExported :exec
+update sync_local set exported_history_id = ?
+`
+
+func (q *Queries) SetSyncExported(ctx context.Context, exportedHistoryID int64) error {
+	_, err := q.db.ExecContext(ctx, setSyncExported, exportedHistoryID)
+	return err
+}
@@ -0,0 +1,104 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: time_segments.sql
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const closeTimeSegment = `-- name: CloseTimeSegment :exec
+update time_segments set end_time = ? where id = ?
+`
+
+type CloseTimeSegmentParams struct {
+	EndTime sql.NullTime
+	ID      int64
+}
+
+func (q *Queries) CloseTimeSegment(ctx context.Context, arg CloseTimeSegmentParams) error {
+	_, err := q.db.ExecContext(ctx, closeTimeSegment, arg.EndTime, arg.ID)
+	return err
+}
+
+const deleteTimeSegments = `-- name: DeleteTimeSegments :exec
+delete from time_segments where activity_id = ?
+`
+
+func (q *Queries) DeleteTimeSegments(ctx context.Context, activityID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteTimeSegments, activityID)
+	return err
+}
+
+const insertTimeSegment = `-- name: InsertTimeSegment :one
+insert into time_segments (activity_id, start_time, end_time) values (?, ?, ?) returning id, activity_id, start_time, end_time
+`
+
+type InsertTimeSegmentParams struct {
+	ActivityID int64
+	StartTime  time.Time
+	EndTime    sql.NullTime
+}
+
+func (q *Queries) InsertTimeSegment(ctx context.Context, arg InsertTimeSegmentParams) (TimeSegment, error) {
+	row := q.db.QueryRowContext(ctx, insertTimeSegment, arg.ActivityID, arg.StartTime, arg.EndTime)
+	var i TimeSegment
+	err := row.Scan(
+		&i.ID,
+		&i.ActivityID,
+		&i.StartTime,
+		&i.EndTime,
+	)
+	return i, err
+}
+
+const queryTimeSegments = `-- name: QueryTimeSegments :many
+select id, activity_id, start_time, end_time from time_segments where activity_id = ? order by start_time
+`
+
+func (q *Queries) QueryTimeSegments(ctx context.Context, activityID int64) ([]TimeSegment, error) {
+	rows, err := q.db.QueryContext(ctx, queryTimeSegments, activityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TimeSegment
+	for rows.Next() {
+		var i TimeSegment
+		if err := rows.Scan(
+			&i.ID,
+			&i.ActivityID,
+			&i.StartTime,
+			&i.EndTime,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateTimeSegment = `-- name: UpdateTimeSegment :exec
+update time_segments set start_time = ?, end_time = ? where id = ?
+`
+
+type UpdateTimeSegmentParams struct {
+	StartTime time.Time
+	EndTime   sql.NullTime
+	ID        int64
+}
+
+func (q *Queries) UpdateTimeSegment(ctx context.Context, arg UpdateTimeSegmentParams) error {
+	_, err := q.db.ExecContext(ctx, updateTimeSegment, arg.StartTime, arg.EndTime, arg.ID)
+	return err
+}
@@ -0,0 +1,34 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: users.sql
+
+package sqlite
+
+import (
+	"context"
+)
+
+const getUser = `-- name: GetUser :one
+select id, name from users where name = ?
+`
+
+func (q *Queries) GetUser(ctx context.Context, name string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUser, name)
+	var i User
+	err := row.Scan(&i.ID, &i.Name)
+	return i, err
+}
+
+const upsertUser = `-- name: UpsertUser :one
+insert into users (name) values (?)
+on conflict (name) do update set name = excluded.name
+returning id, name
+`
+
+func (q *Queries) UpsertUser(ctx context.Context, name string) (User, error) {
+	row := q.db.QueryRowContext(ctx, upsertUser, name)
+	var i User
+	err := row.Scan(&i.ID, &i.Name)
+	return i, err
+}
@@ -1,15 +1,19 @@
 package src
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"math"
 	"net/http"
 	"os"
+	"strings"
+	"unicode/utf8"
 
-	"github.com/sanity-io/litter"
 	"github.com/subosito/gotenv"
 )
 
@@ -19,8 +23,15 @@ type Message struct {
 }
 
 type AIRequest struct {
-	Model    string    `json:"model"`
-	Messages []Message `json:"messages"`
+	Model         string         `json:"model"`
+	Messages      []Message      `json:"messages"`
+	Stream        bool           `json:"stream,omitempty"`
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+}
+
+// StreamOptions asks for the usage in the last event of a stream.
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 type AIResponse struct {
@@ -47,75 +58,268 @@ type Usage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+	// Estimated is set when the API did not say what was used, and the
+	// tokens are a guess from the length of the text.
+	Estimated bool `json:"-"`
 }
 
-func SummariseActivities() {
-	err := gotenv.Load(".env")
-	if err != nil {
-		log.Fatalf("Error loading .env: %s", err)
+// EstimateUsage guesses what a call used from the length of its messages
+// and of the completion, at about four characters a token.
+func EstimateUsage(messages []Message, completion string) Usage {
+	var prompt int
+	for _, m := range messages {
+		prompt += utf8.RuneCountInString(m.Content)
 	}
+	u := Usage{
+		PromptTokens:     (prompt + 3) / 4,
+		CompletionTokens: (utf8.RuneCountInString(completion) + 3) / 4,
+		Estimated:        true,
+	}
+	u.TotalTokens = u.PromptTokens + u.CompletionTokens
+	return u
+}
 
-	OPENAI_API_KEY := os.Getenv("OPENAI_API_KEY")
+// PromptVersion numbers the summary prompt. Bump it whenever the prompt
+// changes, so that summaries written from the old one are not reused.
+const PromptVersion = 1
 
-	request := AIRequest{
-		Model: "gpt-4o-mini",
-		Messages: []Message{
-			{
-				Role:    "system",
-				Content: "You are a helpful assistant.",
-			},
-			{
-				Role:    "user",
-				Content: "Write a haiku that explains dependency injection.",
-			},
-		},
-	}
+const summaryPrompt = `You summarise a week of tracked work for the person who did it.
+Each line of the input is one activity: the day, how long it took, the project
+in brackets, its name and, after a dash, its description. Write a few short
+paragraphs in plain text, grouped by project, saying what was worked on and
+where the time went. Do not invent work that is not in the input.`
 
-	requestByte, err := json.Marshal(request)
-	if err != nil {
-		log.Fatal(err)
+// SummaryMessages asks for a summary of input, which lists activities one
+// per line.
+func SummaryMessages(input string) []Message {
+	return []Message{
+		{Role: "system", Content: summaryPrompt},
+		{Role: "user", Content: input},
 	}
+}
 
-	client := &http.Client{}
+// Price is what a model costs in US dollars per million tokens.
+type Price struct {
+	Prompt     float64
+	Completion float64
+}
 
-	req, err := http.NewRequest(
-		"POST",
-		"https://api.openai.com/v1/chat/completions",
-		bytes.NewBuffer(requestByte),
-	)
+// Prices are the published prices of common models.
+var Prices = map[string]Price{
+	"gpt-4o-mini":  {Prompt: 0.15, Completion: 0.60},
+	"gpt-4o":       {Prompt: 2.50, Completion: 10.00},
+	"gpt-4.1-mini": {Prompt: 0.40, Completion: 1.60},
+	"gpt-4.1":      {Prompt: 2.00, Completion: 8.00},
+}
 
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add(
-		"Authorization",
-		fmt.Sprintf("Bearer %s", OPENAI_API_KEY))
+// Cost estimates what usage cost in millionths of a dollar, which is the
+// number of tokens times the price per million.
+func (p Price) Cost(u Usage) int64 {
+	return int64(math.Round(float64(u.PromptTokens)*p.Prompt + float64(u.CompletionTokens)*p.Completion))
+}
 
+// Client calls the chat completions endpoint of an OpenAI-compatible API.
+type Client struct {
+	URL    string
+	APIKey string
+	Model  string
+	HTTP   *http.Client
+}
+
+// NewClient returns a client of model at url. The API key is taken from
+// OPENAI_API_KEY, which may be set in a .env file in the working directory.
+func NewClient(url, model string) Client {
+	// A missing .env is fine: the key may be in the environment, and a
+	// local model needs none.
+	_ = gotenv.Load(".env")
+	return Client{URL: url, APIKey: os.Getenv("OPENAI_API_KEY"), Model: model, HTTP: http.DefaultClient}
+}
+
+// post sends request and returns the response, which the caller closes,
+// if it succeeded.
+func (c Client) post(ctx context.Context, request AIRequest) (*http.Response, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(b)))
 	}
+	return resp, nil
+}
 
-	resp, err := client.Do(req)
+// Complete sends messages and returns the response.
+func (c Client) Complete(ctx context.Context, messages []Message) (AIResponse, error) {
+	resp, err := c.post(ctx, AIRequest{Model: c.Model, Messages: messages})
 	if err != nil {
-		log.Fatal(err)
+		return AIResponse{}, err
+	}
+	defer resp.Body.Close()
+	var response AIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return AIResponse{}, err
 	}
+	return response, nil
+}
+
+// streamChunk is one event of a streamed response: a delta in the OpenAI
+// format, or a message in the format of Ollama's own API.
+type streamChunk struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *Usage `json:"usage"`
+	Error any    `json:"error"`
 
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Done            bool   `json:"done"`
+	DoneReason      string `json:"done_reason"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+}
+
+// Stream sends messages asking for the response to be streamed, and passes
+// its text to onToken as it arrives. Both server-sent events, as sent by
+// OpenAI-compatible APIs, and the JSON lines of Ollama's /api/chat are
+// read. It returns the whole response, and what had arrived of it when it
+// fails. The usage comes in the last event, so when the stream is stopped
+// or fails before then, or the API does not send it, it is estimated.
+func (c Client) Stream(ctx context.Context, messages []Message, onToken func(string)) (AIResponse, error) {
+	resp, err := c.post(ctx, AIRequest{
+		Model:         c.Model,
+		Messages:      messages,
+		Stream:        true,
+		StreamOptions: &StreamOptions{IncludeUsage: true},
+	})
+	if err != nil {
+		return AIResponse{}, err
+	}
 	defer resp.Body.Close()
 
-	var response AIResponse
+	var (
+		content  strings.Builder
+		response AIResponse
+		finish   string
+	)
+	done := func(err error) (AIResponse, error) {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		response.Choices = []Choices{{
+			ResponseMessage: ResponseMessage{Role: "assistant", Content: content.String()},
+			FinishReason:    finish,
+		}}
+		if response.Usage.TotalTokens == 0 {
+			response.Usage = EstimateUsage(messages, content.String())
+		}
+		return response, err
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if data, ok := strings.CutPrefix(line, "data:"); ok {
+			line = strings.TrimSpace(data)
+		} else if !strings.HasPrefix(line, "{") {
+			// Blank lines end events, and comments, event names, ids and
+			// retry times are of no use here.
+			continue
+		}
+		if line == "[DONE]" {
+			break
+		}
+		var chunk streamChunk
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return done(fmt.Errorf("invalid event in the stream: %v", err))
+		}
+		if chunk.Error != nil {
+			return done(fmt.Errorf("the stream failed: %v", streamError(chunk.Error)))
+		}
+		if chunk.Model != "" {
+			response.Model = chunk.Model
+		}
+		token := chunk.Message.Content
+		for _, choice := range chunk.Choices {
+			token += choice.Delta.Content
+			if choice.FinishReason != "" {
+				finish = choice.FinishReason
+			}
+		}
+		if token != "" {
+			content.WriteString(token)
+			if onToken != nil {
+				onToken(token)
+			}
+		}
+		if chunk.Usage != nil {
+			response.Usage = *chunk.Usage
+		}
+		if chunk.Done {
+			finish = chunk.DoneReason
+			response.Usage = Usage{
+				PromptTokens:     chunk.PromptEvalCount,
+				CompletionTokens: chunk.EvalCount,
+				TotalTokens:      chunk.PromptEvalCount + chunk.EvalCount,
+			}
+			break
+		}
+	}
+	return done(scanner.Err())
+}
+
+// streamError is the message of an error event, which is a string from
+// Ollama and an object from OpenAI.
+func streamError(e any) any {
+	if m, ok := e.(map[string]any); ok && m["message"] != nil {
+		return m["message"]
+	}
+	return e
+}
 
-	body, err := io.ReadAll(resp.Body)
-	fmt.Println(string(body))
-	err = json.Unmarshal(body, &response)
+// Summarise asks for a summary of input and posts it to the webhook. It
+// returns the summary and the tokens it took.
+func (c Client) Summarise(ctx context.Context, input string) (string, Usage, error) {
+	return summary(c.Complete(ctx, SummaryMessages(input)))
+}
+
+// SummariseStream is Summarise with the response streamed to onToken.
+func (c Client) SummariseStream(ctx context.Context, input string, onToken func(string)) (string, Usage, error) {
+	return summary(c.Stream(ctx, SummaryMessages(input), onToken))
+}
+
+func summary(response AIResponse, err error) (string, Usage, error) {
 	if err != nil {
-		log.Fatal(err)
+		return "", response.Usage, err
 	}
-	if resp.StatusCode != http.StatusOK {
-		log.Fatalf("Error: %s", resp.Status)
+	if len(response.Choices) == 0 {
+		return "", response.Usage, errors.New("the response has no choices")
 	}
+	content := response.Choices[0].ResponseMessage.Content
 	data := NewWebHookData(
-		response.Choices[0].ResponseMessage.Content,
-		"Haiku Naiku",
+		content,
+		"probable-memory",
 		"https://gravatar.com/avatar/344ff2b0f7ecff02ad9050696059866c?s=400&d=robohash&r=x",
 	)
 	ExecuteWebHook(data)
-	litter.Dump(response.Choices[0].ResponseMessage)
+	return content, response.Usage, nil
 }
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Proqpine/probable-memory/config"
+	"github.com/Proqpine/probable-memory/goals"
+	"github.com/Proqpine/probable-memory/store"
+)
+
+// exitCode is returned by commands that want to fail without printing an
+// error, like status when nothing is running.
+type exitCode int
+
+func (c exitCode) Error() string {
+	return fmt.Sprintf("exit status %d", int(c))
+}
+
+// statusData is what status templates and the JSON output see.
+type statusData struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Project     string    `json:"project"`
+	Start       time.Time `json:"start"`
+	Elapsed     string    `json:"elapsed"`
+	Seconds     int64     `json:"elapsed_seconds"`
+	Paused      bool      `json:"paused"`
+}
+
+// statusCommand prints the running activity for shell prompts, status lines
+// and editor plugins. It exits with status 1 and no output when nothing is
+// running.
+func statusCommand(ctx context.Context, q store.ActivityStore, cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	format := fs.String("format", "{{.Name}} {{.Elapsed}}", "Go template over Name, Description, Project, Start, Elapsed, Seconds and Paused")
+	asJSON := fs.Bool("json", false, "print JSON instead of using --format")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	tmpl, err := template.New("status").Parse(*format)
+	if err != nil {
+		return fmt.Errorf("invalid --format: %v", err)
+	}
+	if *asJSON || strings.Contains(*format, "Description") {
+		q, err = unlockStore(ctx, q, cfg)
+		if err != nil {
+			return err
+		}
+	}
+
+	running, err := q.QueryRunningActivity(ctx, userFrom(ctx))
+	if errors.Is(err, sql.ErrNoRows) {
+		return exitCode(1)
+	}
+	if err != nil {
+		return err
+	}
+	segments, err := q.QueryTimeSegments(ctx, running.ID)
+	if err != nil {
+		return err
+	}
+	elapsed, err := activityDuration(ctx, q, running, time.Now())
+	if err != nil {
+		return err
+	}
+
+	data := statusData{
+		Name:        running.ActivityName,
+		Description: running.Description,
+		Project:     running.Project,
+		Start:       running.StartTime,
+		Elapsed:     goals.FormatHours(elapsed),
+		Seconds:     int64(elapsed.Seconds()),
+		Paused:      isPaused(segments),
+	}
+	if *asJSON {
+		return json.NewEncoder(os.Stdout).Encode(data)
+	}
+	if err := tmpl.Execute(os.Stdout, data); err != nil {
+		return err
+	}
+	fmt.Println()
+	return nil
+}
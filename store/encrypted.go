@@ -0,0 +1,292 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Proqpine/probable-memory/sqlite"
+)
+
+// Cipher encrypts field values. Decrypt must return values that were never
+// encrypted as they are, so a database can be read while it is converted.
+type Cipher interface {
+	Encrypt(string) (string, error)
+	Decrypt(string) (string, error)
+}
+
+// Encrypted is an ActivityStore that keeps the description and notes of
+// activities, the history and sync conflicts that copy them, and the
+// summaries written from them, encrypted in the store it wraps. Everything
+// else, which queries filter and sort on, is stored as it is.
+type Encrypted struct {
+	ActivityStore
+	c Cipher
+}
+
+var _ ActivityStore = (*Encrypted)(nil)
+
+func NewEncrypted(s ActivityStore, c Cipher) *Encrypted {
+	return &Encrypted{ActivityStore: s, c: c}
+}
+
+// Cipher returns the cipher values are encrypted with.
+func (s *Encrypted) Cipher() Cipher {
+	return s.c
+}
+
+func (s *Encrypted) InTx(ctx context.Context, fn func(ActivityStore) error) error {
+	return s.ActivityStore.InTx(ctx, func(tx ActivityStore) error {
+		return fn(&Encrypted{ActivityStore: tx, c: s.c})
+	})
+}
+
+func (s *Encrypted) sealNull(v sql.NullString) (sql.NullString, error) {
+	if !v.Valid {
+		return v, nil
+	}
+	var err error
+	v.String, err = s.c.Encrypt(v.String)
+	return v, err
+}
+
+func (s *Encrypted) openNull(v sql.NullString) (sql.NullString, error) {
+	if !v.Valid {
+		return v, nil
+	}
+	var err error
+	v.String, err = s.c.Decrypt(v.String)
+	return v, err
+}
+
+func (s *Encrypted) sealText(description, notes *string) error {
+	var err error
+	if *description, err = s.c.Encrypt(*description); err != nil {
+		return err
+	}
+	*notes, err = s.c.Encrypt(*notes)
+	return err
+}
+
+func (s *Encrypted) activity(a sqlite.Activity, err error) (sqlite.Activity, error) {
+	if err != nil {
+		return sqlite.Activity{}, err
+	}
+	if a.Description, err = s.c.Decrypt(a.Description); err != nil {
+		return sqlite.Activity{}, err
+	}
+	if a.Notes, err = s.c.Decrypt(a.Notes); err != nil {
+		return sqlite.Activity{}, err
+	}
+	return a, nil
+}
+
+func (s *Encrypted) activities(rows []sqlite.Activity, err error) ([]sqlite.Activity, error) {
+	if err != nil {
+		return nil, err
+	}
+	for i, a := range rows {
+		if rows[i], err = s.activity(a, nil); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+func (s *Encrypted) history(rows []sqlite.ActivityHistory, err error) ([]sqlite.ActivityHistory, error) {
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		if rows[i].OldValues, err = s.openNull(rows[i].OldValues); err != nil {
+			return nil, err
+		}
+		if rows[i].NewValues, err = s.openNull(rows[i].NewValues); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+func (s *Encrypted) InsertActivity(ctx context.Context, arg sqlite.InsertActivityParams) (sqlite.Activity, error) {
+	if err := s.sealText(&arg.Description, &arg.Notes); err != nil {
+		return sqlite.Activity{}, err
+	}
+	return s.activity(s.ActivityStore.InsertActivity(ctx, arg))
+}
+
+func (s *Encrypted) UpdateActivity(ctx context.Context, arg sqlite.UpdateActivityParams) (sqlite.Activity, error) {
+	if err := s.sealText(&arg.Description, &arg.Notes); err != nil {
+		return sqlite.Activity{}, err
+	}
+	return s.activity(s.ActivityStore.UpdateActivity(ctx, arg))
+}
+
+func (s *Encrypted) SetActivityText(ctx context.Context, arg sqlite.SetActivityTextParams) error {
+	if err := s.sealText(&arg.Description, &arg.Notes); err != nil {
+		return err
+	}
+	return s.ActivityStore.SetActivityText(ctx, arg)
+}
+
+func (s *Encrypted) QueryActivities(ctx context.Context, ownerID int64) ([]sqlite.Activity, error) {
+	return s.activities(s.ActivityStore.QueryActivities(ctx, ownerID))
+}
+
+func (s *Encrypted) QueryAllActivities(ctx context.Context) ([]sqlite.Activity, error) {
+	return s.activities(s.ActivityStore.QueryAllActivities(ctx))
+}
+
+func (s *Encrypted) QueryActivitiesBetween(ctx context.Context, arg sqlite.QueryActivitiesBetweenParams) ([]sqlite.Activity, error) {
+	return s.activities(s.ActivityStore.QueryActivitiesBetween(ctx, arg))
+}
+
+func (s *Encrypted) QueryTeamActivitiesBetween(ctx context.Context, arg sqlite.QueryTeamActivitiesBetweenParams) ([]sqlite.Activity, error) {
+	return s.activities(s.ActivityStore.QueryTeamActivitiesBetween(ctx, arg))
+}
+
+func (s *Encrypted) QueryBillableActivities(ctx context.Context, arg sqlite.QueryBillableActivitiesParams) ([]sqlite.Activity, error) {
+	return s.activities(s.ActivityStore.QueryBillableActivities(ctx, arg))
+}
+
+func (s *Encrypted) QueryActivityByProject(ctx context.Context, arg sqlite.QueryActivityByProjectParams) (sqlite.Activity, error) {
+	return s.activity(s.ActivityStore.QueryActivityByProject(ctx, arg))
+}
+
+func (s *Encrypted) QueryActivityByUUID(ctx context.Context, uuid string) (sqlite.Activity, error) {
+	return s.activity(s.ActivityStore.QueryActivityByUUID(ctx, uuid))
+}
+
+func (s *Encrypted) QueryRunningActivity(ctx context.Context, ownerID int64) (sqlite.Activity, error) {
+	return s.activity(s.ActivityStore.QueryRunningActivity(ctx, ownerID))
+}
+
+func (s *Encrypted) StopActivity(ctx context.Context, arg sqlite.StopActivityParams) (sqlite.Activity, error) {
+	return s.activity(s.ActivityStore.StopActivity(ctx, arg))
+}
+
+func (s *Encrypted) SetActivityUUID(ctx context.Context, arg sqlite.SetActivityUUIDParams) (sqlite.Activity, error) {
+	return s.activity(s.ActivityStore.SetActivityUUID(ctx, arg))
+}
+
+func (s *Encrypted) SetActivityProject(ctx context.Context, arg sqlite.SetActivityProjectParams) (sqlite.Activity, error) {
+	return s.activity(s.ActivityStore.SetActivityProject(ctx, arg))
+}
+
+func (s *Encrypted) SetActivityBillable(ctx context.Context, arg sqlite.SetActivityBillableParams) (sqlite.Activity, error) {
+	return s.activity(s.ActivityStore.SetActivityBillable(ctx, arg))
+}
+
+func (s *Encrypted) SetActivityInvoice(ctx context.Context, arg sqlite.SetActivityInvoiceParams) (sqlite.Activity, error) {
+	return s.activity(s.ActivityStore.SetActivityInvoice(ctx, arg))
+}
+
+func (s *Encrypted) InsertActivityHistory(ctx context.Context, arg sqlite.InsertActivityHistoryParams) error {
+	var err error
+	if arg.OldValues, err = s.sealNull(arg.OldValues); err != nil {
+		return err
+	}
+	if arg.NewValues, err = s.sealNull(arg.NewValues); err != nil {
+		return err
+	}
+	return s.ActivityStore.InsertActivityHistory(ctx, arg)
+}
+
+func (s *Encrypted) SetActivityHistoryValues(ctx context.Context, arg sqlite.SetActivityHistoryValuesParams) error {
+	var err error
+	if arg.OldValues, err = s.sealNull(arg.OldValues); err != nil {
+		return err
+	}
+	if arg.NewValues, err = s.sealNull(arg.NewValues); err != nil {
+		return err
+	}
+	return s.ActivityStore.SetActivityHistoryValues(ctx, arg)
+}
+
+func (s *Encrypted) QueryActivityHistory(ctx context.Context, activityID int64) ([]sqlite.ActivityHistory, error) {
+	return s.history(s.ActivityStore.QueryActivityHistory(ctx, activityID))
+}
+
+func (s *Encrypted) QueryActivityHistorySince(ctx context.Context, id int64) ([]sqlite.ActivityHistory, error) {
+	return s.history(s.ActivityStore.QueryActivityHistorySince(ctx, id))
+}
+
+func (s *Encrypted) InsertSyncConflict(ctx context.Context, arg sqlite.InsertSyncConflictParams) error {
+	var err error
+	if arg.Kept, err = s.c.Encrypt(arg.Kept); err != nil {
+		return err
+	}
+	if arg.Other, err = s.c.Encrypt(arg.Other); err != nil {
+		return err
+	}
+	return s.ActivityStore.InsertSyncConflict(ctx, arg)
+}
+
+func (s *Encrypted) SetSyncConflictValues(ctx context.Context, arg sqlite.SetSyncConflictValuesParams) error {
+	var err error
+	if arg.Kept, err = s.c.Encrypt(arg.Kept); err != nil {
+		return err
+	}
+	if arg.Other, err = s.c.Encrypt(arg.Other); err != nil {
+		return err
+	}
+	return s.ActivityStore.SetSyncConflictValues(ctx, arg)
+}
+
+func (s *Encrypted) QuerySyncConflicts(ctx context.Context) ([]sqlite.SyncConflict, error) {
+	rows, err := s.ActivityStore.QuerySyncConflicts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		if rows[i].Kept, err = s.c.Decrypt(rows[i].Kept); err != nil {
+			return nil, err
+		}
+		if rows[i].Other, err = s.c.Decrypt(rows[i].Other); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+func (s *Encrypted) summary(sum sqlite.Summary, err error) (sqlite.Summary, error) {
+	if err != nil {
+		return sqlite.Summary{}, err
+	}
+	if sum.Content, err = s.c.Decrypt(sum.Content); err != nil {
+		return sqlite.Summary{}, err
+	}
+	return sum, nil
+}
+
+func (s *Encrypted) GetSummary(ctx context.Context, arg sqlite.GetSummaryParams) (sqlite.Summary, error) {
+	return s.summary(s.ActivityStore.GetSummary(ctx, arg))
+}
+
+func (s *Encrypted) UpsertSummary(ctx context.Context, arg sqlite.UpsertSummaryParams) (sqlite.Summary, error) {
+	var err error
+	if arg.Content, err = s.c.Encrypt(arg.Content); err != nil {
+		return sqlite.Summary{}, err
+	}
+	return s.summary(s.ActivityStore.UpsertSummary(ctx, arg))
+}
+
+func (s *Encrypted) SetSummaryContent(ctx context.Context, arg sqlite.SetSummaryContentParams) error {
+	var err error
+	if arg.Content, err = s.c.Encrypt(arg.Content); err != nil {
+		return err
+	}
+	return s.ActivityStore.SetSummaryContent(ctx, arg)
+}
+
+func (s *Encrypted) QueryAllSummaries(ctx context.Context) ([]sqlite.Summary, error) {
+	rows, err := s.ActivityStore.QueryAllSummaries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, sum := range rows {
+		if rows[i], err = s.summary(sum, nil); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,1227 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/Proqpine/probable-memory/sqlite"
+)
+
+// Memory is an ActivityStore that keeps everything in memory, for tests.
+// It enforces the same keys, checks and foreign keys as the SQLite schema,
+// and the lock on invoiced activities.
+type Memory struct {
+	mu   sync.Mutex
+	data memoryData
+}
+
+var _ ActivityStore = (*Memory)(nil)
+
+type goalKey struct{ project, period string }
+
+type memoryData struct {
+	users      map[string]sqlite.User
+	activities map[int64]sqlite.Activity
+	history    []sqlite.ActivityHistory
+	tags       map[int64]map[string]bool
+	clients    map[string]sqlite.Client
+	projects   map[string]sqlite.Project
+	invoices   map[int64]sqlite.Invoice
+	lines      []sqlite.InvoiceLine
+	goals      map[goalKey]sqlite.Goal
+	segments   map[int64]sqlite.TimeSegment
+	syncLocal  *sqlite.SyncLocal
+	devices    map[string]int64
+	clocks     map[[2]string]sqlite.SyncClock
+	conflicts  []sqlite.SyncConflict
+	encryption *sqlite.Encryption
+	summaries  []sqlite.Summary
+	llmCalls   []sqlite.LLMCall
+
+	// Last ids handed out. Like SQLite's rowids they only grow while the
+	// highest row is kept.
+	lastUser, lastActivity, lastHistory, lastInvoice, lastLine, lastSegment, lastConflict, lastSummary, lastLLMCall int64
+}
+
+func NewMemory() *Memory {
+	return &Memory{data: memoryData{
+		users:      map[string]sqlite.User{},
+		activities: map[int64]sqlite.Activity{},
+		tags:       map[int64]map[string]bool{},
+		clients:    map[string]sqlite.Client{},
+		projects:   map[string]sqlite.Project{},
+		invoices:   map[int64]sqlite.Invoice{},
+		goals:      map[goalKey]sqlite.Goal{},
+		segments:   map[int64]sqlite.TimeSegment{},
+		devices:    map[string]int64{},
+		clocks:     map[[2]string]sqlite.SyncClock{},
+	}}
+}
+
+func (d memoryData) clone() memoryData {
+	c := d
+	c.users = cloneMap(d.users)
+	c.activities = cloneMap(d.activities)
+	c.history = append([]sqlite.ActivityHistory(nil), d.history...)
+	c.tags = make(map[int64]map[string]bool, len(d.tags))
+	for id, tags := range d.tags {
+		c.tags[id] = cloneMap(tags)
+	}
+	c.clients = cloneMap(d.clients)
+	c.projects = cloneMap(d.projects)
+	c.invoices = cloneMap(d.invoices)
+	c.lines = append([]sqlite.InvoiceLine(nil), d.lines...)
+	c.goals = cloneMap(d.goals)
+	c.segments = cloneMap(d.segments)
+	if d.syncLocal != nil {
+		local := *d.syncLocal
+		c.syncLocal = &local
+	}
+	c.devices = cloneMap(d.devices)
+	c.clocks = cloneMap(d.clocks)
+	c.conflicts = append([]sqlite.SyncConflict(nil), d.conflicts...)
+	if d.encryption != nil {
+		e := *d.encryption
+		c.encryption = &e
+	}
+	c.summaries = append([]sqlite.Summary(nil), d.summaries...)
+	c.llmCalls = append([]sqlite.LLMCall(nil), d.llmCalls...)
+	return c
+}
+
+func cloneMap[K comparable, V any](m map[K]V) map[K]V {
+	c := make(map[K]V, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// InTx runs fn against a copy of the data, which replaces the data if fn
+// succeeds. Other calls wait until it is done, much as SQLite allows one
+// writer at a time.
+func (s *Memory) InTx(ctx context.Context, fn func(ActivityStore) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tx := &Memory{data: s.data.clone()}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	s.data = tx.data
+	return nil
+}
+
+// lock checks ctx and locks the store; call the returned function to
+// unlock it.
+func (s *Memory) lock(ctx context.Context) (func(), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	return s.mu.Unlock, nil
+}
+
+var (
+	errInvoiced   = errors.New("activity is invoiced and locked")
+	errForeignKey = errors.New("FOREIGN KEY constraint failed")
+)
+
+func (d memoryData) sortedActivities(keep func(sqlite.Activity) bool) []sqlite.Activity {
+	var out []sqlite.Activity
+	for _, a := range d.activities {
+		if keep(a) {
+			out = append(out, a)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func ownedBy(owner int64) func(sqlite.Activity) bool {
+	return func(a sqlite.Activity) bool { return a.OwnerID.Valid && a.OwnerID.Int64 == owner }
+}
+
+func (d memoryData) hasUser(id int64) bool {
+	for _, u := range d.users {
+		if u.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func byStartTime(activities []sqlite.Activity) {
+	sort.SliceStable(activities, func(i, j int) bool {
+		return activities[i].StartTime.Before(activities[j].StartTime)
+	})
+}
+
+// updateActivity applies change to the activity with the given id, unless
+// it has been invoiced and the database is not being rekeyed.
+func (d memoryData) updateActivity(id int64, change func(*sqlite.Activity)) (sqlite.Activity, error) {
+	a, ok := d.activities[id]
+	if !ok {
+		return sqlite.Activity{}, sql.ErrNoRows
+	}
+	if a.InvoiceID.Valid && (d.encryption == nil || !d.encryption.Rekeying) {
+		return sqlite.Activity{}, errInvoiced
+	}
+	change(&a)
+	d.activities[id] = a
+	return a, nil
+}
+
+func (s *Memory) UpsertUser(ctx context.Context, name string) (sqlite.User, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return sqlite.User{}, err
+	}
+	defer unlock()
+	if u, ok := s.data.users[name]; ok {
+		return u, nil
+	}
+	s.data.lastUser++
+	u := sqlite.User{ID: s.data.lastUser, Name: name}
+	s.data.users[name] = u
+	return u, nil
+}
+
+func (s *Memory) GetUser(ctx context.Context, name string) (sqlite.User, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return sqlite.User{}, err
+	}
+	defer unlock()
+	u, ok := s.data.users[name]
+	if !ok {
+		return sqlite.User{}, sql.ErrNoRows
+	}
+	return u, nil
+}
+
+func (s *Memory) ClaimActivities(ctx context.Context, ownerID int64) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if !s.data.hasUser(ownerID) {
+		return errForeignKey
+	}
+	// The invoiced lock does not cover the owner.
+	for id, a := range s.data.activities {
+		if !a.OwnerID.Valid {
+			a.OwnerID = sql.NullInt64{Int64: ownerID, Valid: true}
+			s.data.activities[id] = a
+		}
+	}
+	return nil
+}
+
+func (s *Memory) QueryActivities(ctx context.Context, ownerID int64) ([]sqlite.Activity, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return s.data.sortedActivities(ownedBy(ownerID)), nil
+}
+
+func (s *Memory) QueryActivityByProject(ctx context.Context, arg sqlite.QueryActivityByProjectParams) (sqlite.Activity, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return sqlite.Activity{}, err
+	}
+	defer unlock()
+	owned := ownedBy(arg.OwnerID)
+	found := s.data.sortedActivities(func(a sqlite.Activity) bool { return a.Project == arg.Project && owned(a) })
+	if len(found) == 0 {
+		return sqlite.Activity{}, sql.ErrNoRows
+	}
+	return found[0], nil
+}
+
+func (s *Memory) InsertActivity(ctx context.Context, arg sqlite.InsertActivityParams) (sqlite.Activity, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return sqlite.Activity{}, err
+	}
+	defer unlock()
+	if arg.OwnerID.Valid && !s.data.hasUser(arg.OwnerID.Int64) {
+		return sqlite.Activity{}, errForeignKey
+	}
+	for _, a := range s.data.activities {
+		if a.UUID == arg.UUID {
+			return sqlite.Activity{}, errors.New("UNIQUE constraint failed: activities.uuid")
+		}
+	}
+	s.data.lastActivity++
+	a := sqlite.Activity{
+		ID:           s.data.lastActivity,
+		StartTime:    arg.StartTime,
+		EndTime:      arg.EndTime,
+		Duration:     arg.Duration,
+		ActivityName: arg.ActivityName,
+		Description:  arg.Description,
+		Project:      arg.Project,
+		Notes:        arg.Notes,
+		Billable:     arg.Billable,
+		OwnerID:      arg.OwnerID,
+		UUID:         arg.UUID,
+	}
+	s.data.activities[a.ID] = a
+	return a, nil
+}
+
+func (s *Memory) QueryActivityByUUID(ctx context.Context, uuid string) (sqlite.Activity, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return sqlite.Activity{}, err
+	}
+	defer unlock()
+	found := s.data.sortedActivities(func(a sqlite.Activity) bool { return a.UUID == uuid })
+	if len(found) == 0 {
+		return sqlite.Activity{}, sql.ErrNoRows
+	}
+	return found[0], nil
+}
+
+func (s *Memory) SetActivityUUID(ctx context.Context, arg sqlite.SetActivityUUIDParams) (sqlite.Activity, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return sqlite.Activity{}, err
+	}
+	defer unlock()
+	a, ok := s.data.activities[arg.ID]
+	if !ok {
+		return sqlite.Activity{}, sql.ErrNoRows
+	}
+	for id, other := range s.data.activities {
+		if id != arg.ID && other.UUID == arg.UUID {
+			return sqlite.Activity{}, errors.New("UNIQUE constraint failed: activities.uuid")
+		}
+	}
+	// The invoiced lock does not cover the UUID.
+	a.UUID = arg.UUID
+	s.data.activities[arg.ID] = a
+	return a, nil
+}
+
+func (s *Memory) UpdateActivity(ctx context.Context, arg sqlite.UpdateActivityParams) (sqlite.Activity, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return sqlite.Activity{}, err
+	}
+	defer unlock()
+	return s.data.updateActivity(arg.ID, func(a *sqlite.Activity) {
+		a.StartTime = arg.StartTime
+		a.EndTime = arg.EndTime
+		a.Duration = arg.Duration
+		a.ActivityName = arg.ActivityName
+		a.Description = arg.Description
+		a.Project = arg.Project
+		a.Notes = arg.Notes
+	})
+}
+
+func (s *Memory) QueryRunningActivity(ctx context.Context, ownerID int64) (sqlite.Activity, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return sqlite.Activity{}, err
+	}
+	defer unlock()
+	owned := ownedBy(ownerID)
+	running := s.data.sortedActivities(func(a sqlite.Activity) bool {
+		return owned(a) && !a.EndTime.Valid && !a.Duration.Valid
+	})
+	if len(running) == 0 {
+		return sqlite.Activity{}, sql.ErrNoRows
+	}
+	byStartTime(running)
+	return running[len(running)-1], nil
+}
+
+func (s *Memory) StopActivity(ctx context.Context, arg sqlite.StopActivityParams) (sqlite.Activity, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return sqlite.Activity{}, err
+	}
+	defer unlock()
+	return s.data.updateActivity(arg.ID, func(a *sqlite.Activity) {
+		a.EndTime = arg.EndTime
+		a.Duration = arg.Duration
+	})
+}
+
+func (s *Memory) TouchActivity(ctx context.Context, arg sqlite.TouchActivityParams) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	_, err = s.data.updateActivity(arg.ID, func(a *sqlite.Activity) {
+		a.HeartbeatAt = arg.HeartbeatAt
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	return err
+}
+
+func (s *Memory) QueryActivitiesBetween(ctx context.Context, arg sqlite.QueryActivitiesBetweenParams) ([]sqlite.Activity, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	owned := ownedBy(arg.OwnerID)
+	found := s.data.sortedActivities(func(a sqlite.Activity) bool {
+		return owned(a) && !a.StartTime.Before(arg.PeriodStart) && a.StartTime.Before(arg.PeriodEnd)
+	})
+	byStartTime(found)
+	return found, nil
+}
+
+func (s *Memory) QueryTeamActivitiesBetween(ctx context.Context, arg sqlite.QueryTeamActivitiesBetweenParams) ([]sqlite.Activity, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	found := s.data.sortedActivities(func(a sqlite.Activity) bool {
+		return !a.StartTime.Before(arg.PeriodStart) && a.StartTime.Before(arg.PeriodEnd)
+	})
+	byStartTime(found)
+	return found, nil
+}
+
+func (s *Memory) SetActivityProject(ctx context.Context, arg sqlite.SetActivityProjectParams) (sqlite.Activity, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return sqlite.Activity{}, err
+	}
+	defer unlock()
+	return s.data.updateActivity(arg.ID, func(a *sqlite.Activity) {
+		a.Project = arg.Project
+	})
+}
+
+func (s *Memory) DeleteActivity(ctx context.Context, id int64) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	delete(s.data.activities, id)
+	// The foreign keys cascade to segments and tags.
+	delete(s.data.tags, id)
+	for sid, seg := range s.data.segments {
+		if seg.ActivityID == id {
+			delete(s.data.segments, sid)
+		}
+	}
+	return nil
+}
+
+func (s *Memory) InsertActivityHistory(ctx context.Context, arg sqlite.InsertActivityHistoryParams) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	switch arg.Action {
+	case "insert", "update", "delete":
+	default:
+		return fmt.Errorf("CHECK constraint failed: action %q", arg.Action)
+	}
+	switch arg.Source {
+	case "tui", "cli", "api", "import", "sync":
+	default:
+		return fmt.Errorf("CHECK constraint failed: source %q", arg.Source)
+	}
+	s.data.lastHistory++
+	s.data.history = append(s.data.history, sqlite.ActivityHistory{
+		ID:         s.data.lastHistory,
+		ActivityID: arg.ActivityID,
+		Action:     arg.Action,
+		Source:     arg.Source,
+		OldValues:  arg.OldValues,
+		NewValues:  arg.NewValues,
+		ChangedAt:  arg.ChangedAt,
+	})
+	return nil
+}
+
+func (s *Memory) QueryActivityHistory(ctx context.Context, activityID int64) ([]sqlite.ActivityHistory, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	var found []sqlite.ActivityHistory
+	for _, h := range s.data.history {
+		if h.ActivityID == activityID {
+			found = append(found, h)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if !found[i].ChangedAt.Equal(found[j].ChangedAt) {
+			return found[i].ChangedAt.After(found[j].ChangedAt)
+		}
+		return found[i].ID > found[j].ID
+	})
+	return found, nil
+}
+
+func (s *Memory) QueryActivityHistorySince(ctx context.Context, id int64) ([]sqlite.ActivityHistory, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	var found []sqlite.ActivityHistory
+	for _, h := range s.data.history {
+		if h.ID > id {
+			found = append(found, h)
+		}
+	}
+	return found, nil
+}
+
+func (s *Memory) GetLastActivityHistoryID(ctx context.Context) (int64, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	if len(s.data.history) == 0 {
+		return 0, nil
+	}
+	return s.data.history[len(s.data.history)-1].ID, nil
+}
+
+func (s *Memory) QueryActivityTags(ctx context.Context, activityID int64) ([]string, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	var tags []string
+	for tag := range s.data.tags[activityID] {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+func (s *Memory) AddActivityTag(ctx context.Context, arg sqlite.AddActivityTagParams) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if _, ok := s.data.activities[arg.ActivityID]; !ok {
+		return errForeignKey
+	}
+	if s.data.tags[arg.ActivityID] == nil {
+		s.data.tags[arg.ActivityID] = map[string]bool{}
+	}
+	s.data.tags[arg.ActivityID][arg.Tag] = true
+	return nil
+}
+
+func (s *Memory) RemoveActivityTag(ctx context.Context, arg sqlite.RemoveActivityTagParams) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	delete(s.data.tags[arg.ActivityID], arg.Tag)
+	return nil
+}
+
+func (s *Memory) DeleteActivityTags(ctx context.Context, activityID int64) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	delete(s.data.tags, activityID)
+	return nil
+}
+
+func (s *Memory) QueryAllActivityTags(ctx context.Context, ownerID int64) ([]sqlite.ActivityTag, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	owned := ownedBy(ownerID)
+	var all []sqlite.ActivityTag
+	for id, tags := range s.data.tags {
+		if !owned(s.data.activities[id]) {
+			continue
+		}
+		for tag := range tags {
+			all = append(all, sqlite.ActivityTag{ActivityID: id, Tag: tag})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].ActivityID != all[j].ActivityID {
+			return all[i].ActivityID < all[j].ActivityID
+		}
+		return all[i].Tag < all[j].Tag
+	})
+	return all, nil
+}
+
+func (s *Memory) UpsertClient(ctx context.Context, arg sqlite.UpsertClientParams) (sqlite.Client, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return sqlite.Client{}, err
+	}
+	defer unlock()
+	c := sqlite.Client(arg)
+	s.data.clients[c.Name] = c
+	return c, nil
+}
+
+func (s *Memory) GetClient(ctx context.Context, name string) (sqlite.Client, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return sqlite.Client{}, err
+	}
+	defer unlock()
+	c, ok := s.data.clients[name]
+	if !ok {
+		return sqlite.Client{}, sql.ErrNoRows
+	}
+	return c, nil
+}
+
+func (s *Memory) UpsertProject(ctx context.Context, arg sqlite.UpsertProjectParams) (sqlite.Project, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return sqlite.Project{}, err
+	}
+	defer unlock()
+	if _, ok := s.data.clients[arg.Client.String]; arg.Client.Valid && !ok {
+		return sqlite.Project{}, errForeignKey
+	}
+	p := sqlite.Project(arg)
+	s.data.projects[p.Name] = p
+	return p, nil
+}
+
+func (s *Memory) GetProject(ctx context.Context, name string) (sqlite.Project, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return sqlite.Project{}, err
+	}
+	defer unlock()
+	p, ok := s.data.projects[name]
+	if !ok {
+		return sqlite.Project{}, sql.ErrNoRows
+	}
+	return p, nil
+}
+
+func (s *Memory) QueryProjectsByClient(ctx context.Context, client sql.NullString) ([]sqlite.Project, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	var found []sqlite.Project
+	for _, p := range s.data.projects {
+		// As in SQL, null matches nothing.
+		if client.Valid && p.Client.Valid && p.Client.String == client.String {
+			found = append(found, p)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Name < found[j].Name })
+	return found, nil
+}
+
+func (s *Memory) SetActivityBillable(ctx context.Context, arg sqlite.SetActivityBillableParams) (sqlite.Activity, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return sqlite.Activity{}, err
+	}
+	defer unlock()
+	return s.data.updateActivity(arg.ID, func(a *sqlite.Activity) {
+		a.Billable = arg.Billable
+	})
+}
+
+func (s *Memory) QueryBillableActivities(ctx context.Context, arg sqlite.QueryBillableActivitiesParams) ([]sqlite.Activity, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	found := s.data.sortedActivities(func(a sqlite.Activity) bool {
+		return a.Project == arg.Project && a.Billable && !a.InvoiceID.Valid && a.EndTime.Valid &&
+			!a.StartTime.Before(arg.PeriodStart) && a.StartTime.Before(arg.PeriodEnd)
+	})
+	byStartTime(found)
+	return found, nil
+}
+
+func (s *Memory) NextInvoiceSequence(ctx context.Context, year int64) (int64, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	var last int64
+	for _, inv := range s.data.invoices {
+		if inv.Year == year && inv.Sequence > last {
+			last = inv.Sequence
+		}
+	}
+	return last + 1, nil
+}
+
+func (s *Memory) InsertInvoice(ctx context.Context, arg sqlite.InsertInvoiceParams) (sqlite.Invoice, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return sqlite.Invoice{}, err
+	}
+	defer unlock()
+	if _, ok := s.data.clients[arg.Client]; !ok {
+		return sqlite.Invoice{}, errForeignKey
+	}
+	for _, inv := range s.data.invoices {
+		if inv.Number == arg.Number {
+			return sqlite.Invoice{}, fmt.Errorf("UNIQUE constraint failed: invoices.number")
+		}
+	}
+	s.data.lastInvoice++
+	inv := sqlite.Invoice{
+		ID:              s.data.lastInvoice,
+		Number:          arg.Number,
+		Year:            arg.Year,
+		Sequence:        arg.Sequence,
+		Client:          arg.Client,
+		PeriodStart:     arg.PeriodStart,
+		PeriodEnd:       arg.PeriodEnd,
+		IssuedAt:        arg.IssuedAt,
+		Currency:        arg.Currency,
+		RoundingMinutes: arg.RoundingMinutes,
+		RoundingMode:    arg.RoundingMode,
+		Total:           arg.Total,
+	}
+	s.data.invoices[inv.ID] = inv
+	return inv, nil
+}
+
+func (s *Memory) InsertInvoiceLine(ctx context.Context, arg sqlite.InsertInvoiceLineParams) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if _, ok := s.data.invoices[arg.InvoiceID]; !ok {
+		return errForeignKey
+	}
+	s.data.lastLine++
+	s.data.lines = append(s.data.lines, sqlite.InvoiceLine{
+		ID:         s.data.lastLine,
+		InvoiceID:  arg.InvoiceID,
+		Project:    arg.Project,
+		Minutes:    arg.Minutes,
+		HourlyRate: arg.HourlyRate,
+		Amount:     arg.Amount,
+	})
+	return nil
+}
+
+func (s *Memory) SetActivityInvoice(ctx context.Context, arg sqlite.SetActivityInvoiceParams) (sqlite.Activity, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return sqlite.Activity{}, err
+	}
+	defer unlock()
+	if _, ok := s.data.invoices[arg.InvoiceID.Int64]; arg.InvoiceID.Valid && !ok {
+		return sqlite.Activity{}, errForeignKey
+	}
+	return s.data.updateActivity(arg.ID, func(a *sqlite.Activity) {
+		a.InvoiceID = arg.InvoiceID
+	})
+}
+
+func (s *Memory) QueryGoals(ctx context.Context) ([]sqlite.Goal, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	var goals []sqlite.Goal
+	for _, g := range s.data.goals {
+		goals = append(goals, g)
+	}
+	sort.Slice(goals, func(i, j int) bool {
+		if goals[i].Project != goals[j].Project {
+			return goals[i].Project < goals[j].Project
+		}
+		return goals[i].Period < goals[j].Period
+	})
+	return goals, nil
+}
+
+func (s *Memory) UpsertGoal(ctx context.Context, arg sqlite.UpsertGoalParams) (sqlite.Goal, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return sqlite.Goal{}, err
+	}
+	defer unlock()
+	switch arg.Period {
+	case "day", "week", "month":
+	default:
+		return sqlite.Goal{}, fmt.Errorf("CHECK constraint failed: period %q", arg.Period)
+	}
+	g := sqlite.Goal(arg)
+	s.data.goals[goalKey{g.Project, g.Period}] = g
+	return g, nil
+}
+
+func (s *Memory) DeleteGoal(ctx context.Context, arg sqlite.DeleteGoalParams) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	delete(s.data.goals, goalKey{arg.Project, arg.Period})
+	return nil
+}
+
+func (s *Memory) QueryTimeSegments(ctx context.Context, activityID int64) ([]sqlite.TimeSegment, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	var found []sqlite.TimeSegment
+	for _, seg := range s.data.segments {
+		if seg.ActivityID == activityID {
+			found = append(found, seg)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if !found[i].StartTime.Equal(found[j].StartTime) {
+			return found[i].StartTime.Before(found[j].StartTime)
+		}
+		return found[i].ID < found[j].ID
+	})
+	return found, nil
+}
+
+func (s *Memory) InsertTimeSegment(ctx context.Context, arg sqlite.InsertTimeSegmentParams) (sqlite.TimeSegment, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return sqlite.TimeSegment{}, err
+	}
+	defer unlock()
+	if _, ok := s.data.activities[arg.ActivityID]; !ok {
+		return sqlite.TimeSegment{}, errForeignKey
+	}
+	s.data.lastSegment++
+	seg := sqlite.TimeSegment{
+		ID:         s.data.lastSegment,
+		ActivityID: arg.ActivityID,
+		StartTime:  arg.StartTime,
+		EndTime:    arg.EndTime,
+	}
+	s.data.segments[seg.ID] = seg
+	return seg, nil
+}
+
+func (s *Memory) CloseTimeSegment(ctx context.Context, arg sqlite.CloseTimeSegmentParams) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if seg, ok := s.data.segments[arg.ID]; ok {
+		seg.EndTime = arg.EndTime
+		s.data.segments[arg.ID] = seg
+	}
+	return nil
+}
+
+func (s *Memory) UpdateTimeSegment(ctx context.Context, arg sqlite.UpdateTimeSegmentParams) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if seg, ok := s.data.segments[arg.ID]; ok {
+		seg.StartTime = arg.StartTime
+		seg.EndTime = arg.EndTime
+		s.data.segments[arg.ID] = seg
+	}
+	return nil
+}
+
+func (s *Memory) DeleteTimeSegments(ctx context.Context, activityID int64) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	for id, seg := range s.data.segments {
+		if seg.ActivityID == activityID {
+			delete(s.data.segments, id)
+		}
+	}
+	return nil
+}
+
+func (s *Memory) GetSyncLocal(ctx context.Context) (sqlite.SyncLocal, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return sqlite.SyncLocal{}, err
+	}
+	defer unlock()
+	if s.data.syncLocal == nil {
+		return sqlite.SyncLocal{}, sql.ErrNoRows
+	}
+	return *s.data.syncLocal, nil
+}
+
+func (s *Memory) InsertSyncLocal(ctx context.Context, arg sqlite.InsertSyncLocalParams) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	s.data.syncLocal = &sqlite.SyncLocal{Device: arg.Device, ExportedHistoryID: arg.ExportedHistoryID}
+	return nil
+}
+
+func (s *Memory) SetSyncExported(ctx context.Context, exportedHistoryID int64) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if s.data.syncLocal != nil {
+		s.data.syncLocal.ExportedHistoryID = exportedHistoryID
+	}
+	return nil
+}
+
+func (s *Memory) QuerySyncDevices(ctx context.Context) ([]sqlite.SyncDevice, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	var out []sqlite.SyncDevice
+	for device, applied := range s.data.devices {
+		out = append(out, sqlite.SyncDevice{Device: device, Applied: applied})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Device < out[j].Device })
+	return out, nil
+}
+
+func (s *Memory) SetSyncDeviceApplied(ctx context.Context, arg sqlite.SetSyncDeviceAppliedParams) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	s.data.devices[arg.Device] = arg.Applied
+	return nil
+}
+
+func (s *Memory) GetSyncClock(ctx context.Context, arg sqlite.GetSyncClockParams) (sqlite.SyncClock, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return sqlite.SyncClock{}, err
+	}
+	defer unlock()
+	c, ok := s.data.clocks[[2]string{arg.ActivityUUID, arg.Field}]
+	if !ok {
+		return sqlite.SyncClock{}, sql.ErrNoRows
+	}
+	return c, nil
+}
+
+func (s *Memory) SetSyncClock(ctx context.Context, arg sqlite.SetSyncClockParams) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	s.data.clocks[[2]string{arg.ActivityUUID, arg.Field}] = sqlite.SyncClock(arg)
+	return nil
+}
+
+func (s *Memory) InsertSyncConflict(ctx context.Context, arg sqlite.InsertSyncConflictParams) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	s.data.lastConflict++
+	s.data.conflicts = append(s.data.conflicts, sqlite.SyncConflict{
+		ID:           s.data.lastConflict,
+		ActivityUUID: arg.ActivityUUID,
+		Field:        arg.Field,
+		Kept:         arg.Kept,
+		Other:        arg.Other,
+		CreatedAt:    arg.CreatedAt,
+	})
+	return nil
+}
+
+func (s *Memory) QuerySyncConflicts(ctx context.Context) ([]sqlite.SyncConflict, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return append([]sqlite.SyncConflict(nil), s.data.conflicts...), nil
+}
+
+func (s *Memory) DeleteSyncConflict(ctx context.Context, id int64) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	for i, c := range s.data.conflicts {
+		if c.ID == id {
+			s.data.conflicts = append(s.data.conflicts[:i:i], s.data.conflicts[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (s *Memory) GetEncryption(ctx context.Context) (sqlite.Encryption, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return sqlite.Encryption{}, err
+	}
+	defer unlock()
+	if s.data.encryption == nil {
+		return sqlite.Encryption{}, sql.ErrNoRows
+	}
+	return *s.data.encryption, nil
+}
+
+func (s *Memory) InsertEncryption(ctx context.Context, arg sqlite.InsertEncryptionParams) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if arg.Kdf != "argon2id" && arg.Kdf != "keyfile" {
+		return fmt.Errorf("CHECK constraint failed: kdf %q", arg.Kdf)
+	}
+	s.data.encryption = &sqlite.Encryption{Kdf: arg.Kdf, Salt: arg.Salt, Verifier: arg.Verifier}
+	return nil
+}
+
+func (s *Memory) UpdateEncryption(ctx context.Context, arg sqlite.UpdateEncryptionParams) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if s.data.encryption == nil {
+		return nil
+	}
+	if arg.Kdf != "argon2id" && arg.Kdf != "keyfile" {
+		return fmt.Errorf("CHECK constraint failed: kdf %q", arg.Kdf)
+	}
+	s.data.encryption.Kdf = arg.Kdf
+	s.data.encryption.Salt = arg.Salt
+	s.data.encryption.Verifier = arg.Verifier
+	return nil
+}
+
+func (s *Memory) DeleteEncryption(ctx context.Context) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	s.data.encryption = nil
+	return nil
+}
+
+func (s *Memory) SetEncryptionRekeying(ctx context.Context, rekeying bool) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if s.data.encryption != nil {
+		s.data.encryption.Rekeying = rekeying
+	}
+	return nil
+}
+
+func (s *Memory) QueryAllActivities(ctx context.Context) ([]sqlite.Activity, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return s.data.sortedActivities(func(sqlite.Activity) bool { return true }), nil
+}
+
+func (s *Memory) SetActivityText(ctx context.Context, arg sqlite.SetActivityTextParams) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	_, err = s.data.updateActivity(arg.ID, func(a *sqlite.Activity) {
+		a.Description = arg.Description
+		a.Notes = arg.Notes
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	return err
+}
+
+func (s *Memory) SetActivityHistoryValues(ctx context.Context, arg sqlite.SetActivityHistoryValuesParams) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	for i, h := range s.data.history {
+		if h.ID == arg.ID {
+			s.data.history[i].OldValues = arg.OldValues
+			s.data.history[i].NewValues = arg.NewValues
+		}
+	}
+	return nil
+}
+
+func (s *Memory) SetSyncConflictValues(ctx context.Context, arg sqlite.SetSyncConflictValuesParams) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	for i, c := range s.data.conflicts {
+		if c.ID == arg.ID {
+			s.data.conflicts[i].Kept = arg.Kept
+			s.data.conflicts[i].Other = arg.Other
+		}
+	}
+	return nil
+}
+
+func (s *Memory) GetSummary(ctx context.Context, arg sqlite.GetSummaryParams) (sqlite.Summary, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return sqlite.Summary{}, err
+	}
+	defer unlock()
+	for _, sum := range s.data.summaries {
+		if sum.PeriodStart.Equal(arg.PeriodStart) && sum.PeriodEnd.Equal(arg.PeriodEnd) &&
+			sum.InputHash == arg.InputHash && sum.PromptVersion == arg.PromptVersion {
+			return sum, nil
+		}
+	}
+	return sqlite.Summary{}, sql.ErrNoRows
+}
+
+func (s *Memory) UpsertSummary(ctx context.Context, arg sqlite.UpsertSummaryParams) (sqlite.Summary, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return sqlite.Summary{}, err
+	}
+	defer unlock()
+	for i, sum := range s.data.summaries {
+		if sum.PeriodStart.Equal(arg.PeriodStart) && sum.PeriodEnd.Equal(arg.PeriodEnd) &&
+			sum.InputHash == arg.InputHash && sum.PromptVersion == arg.PromptVersion {
+			sum.Model = arg.Model
+			sum.Content = arg.Content
+			sum.CreatedAt = arg.CreatedAt
+			s.data.summaries[i] = sum
+			return sum, nil
+		}
+	}
+	s.data.lastSummary++
+	sum := sqlite.Summary{
+		ID:            s.data.lastSummary,
+		PeriodStart:   arg.PeriodStart,
+		PeriodEnd:     arg.PeriodEnd,
+		InputHash:     arg.InputHash,
+		PromptVersion: arg.PromptVersion,
+		Model:         arg.Model,
+		Content:       arg.Content,
+		CreatedAt:     arg.CreatedAt,
+	}
+	s.data.summaries = append(s.data.summaries, sum)
+	return sum, nil
+}
+
+func (s *Memory) QueryAllSummaries(ctx context.Context) ([]sqlite.Summary, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return append([]sqlite.Summary(nil), s.data.summaries...), nil
+}
+
+func (s *Memory) SetSummaryContent(ctx context.Context, arg sqlite.SetSummaryContentParams) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	for i, sum := range s.data.summaries {
+		if sum.ID == arg.ID {
+			s.data.summaries[i].Content = arg.Content
+		}
+	}
+	return nil
+}
+
+func (s *Memory) InsertLLMCall(ctx context.Context, arg sqlite.InsertLLMCallParams) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	s.data.lastLLMCall++
+	s.data.llmCalls = append(s.data.llmCalls, sqlite.LLMCall{
+		ID:               s.data.lastLLMCall,
+		Model:            arg.Model,
+		PromptTokens:     arg.PromptTokens,
+		CompletionTokens: arg.CompletionTokens,
+		CostMicros:       arg.CostMicros,
+		CreatedAt:        arg.CreatedAt,
+	})
+	return nil
+}
+
+func (s *Memory) QueryLLMCalls(ctx context.Context) ([]sqlite.LLMCall, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	out := append([]sqlite.LLMCall(nil), s.data.llmCalls...)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
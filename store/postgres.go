@@ -0,0 +1,430 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Proqpine/probable-memory/postgres"
+	"github.com/Proqpine/probable-memory/sqlite"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Postgres is an ActivityStore backed by a shared PostgreSQL database,
+// through the sqlc code generated from postgres/queries. Rows are converted
+// to the sqlite package's types, which the rest of the app uses; the only
+// real difference is that durations are intervals rather than seconds.
+type Postgres struct {
+	queries *postgres.Queries
+	pool    *pgxpool.Pool
+	tx      pgx.Tx
+}
+
+var _ ActivityStore = (*Postgres)(nil)
+
+func NewPostgres(pool *pgxpool.Pool) *Postgres {
+	return &Postgres{queries: postgres.New(pool), pool: pool}
+}
+
+// InTx runs fn in a transaction, committing if it returns nil. Calls made
+// while already in a transaction join it.
+func (s *Postgres) InTx(ctx context.Context, fn func(ActivityStore) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if err := fn(&Postgres{queries: s.queries.WithTx(tx), pool: s.pool, tx: tx}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// interval converts a duration in seconds, as the sqlite package stores
+// it, to an interval.
+func interval(seconds sql.NullInt64) pgtype.Interval {
+	return pgtype.Interval{
+		Microseconds: seconds.Int64 * int64(time.Second/time.Microsecond),
+		Valid:        seconds.Valid,
+	}
+}
+
+// seconds converts an interval back. Intervals written by other tools may
+// use days and months, which are taken as 24 hours and 30 days.
+func seconds(i pgtype.Interval) sql.NullInt64 {
+	const day = 24 * 60 * 60
+	return sql.NullInt64{
+		Int64: i.Microseconds/int64(time.Second/time.Microsecond) + int64(i.Days)*day + int64(i.Months)*30*day,
+		Valid: i.Valid,
+	}
+}
+
+func toActivity(a postgres.Activity) sqlite.Activity {
+	return sqlite.Activity{
+		ID:           a.ID,
+		StartTime:    a.StartTime,
+		EndTime:      a.EndTime,
+		Duration:     seconds(a.Duration),
+		ActivityName: a.ActivityName,
+		Description:  a.Description,
+		Project:      a.Project,
+		Notes:        a.Notes,
+		HeartbeatAt:  a.HeartbeatAt,
+		Billable:     a.Billable,
+		InvoiceID:    a.InvoiceID,
+		OwnerID:      a.OwnerID,
+		UUID:         a.UUID,
+	}
+}
+
+func activity(a postgres.Activity, err error) (sqlite.Activity, error) {
+	if err != nil {
+		return sqlite.Activity{}, err
+	}
+	return toActivity(a), nil
+}
+
+func activities(rows []postgres.Activity, err error) ([]sqlite.Activity, error) {
+	return convert(rows, err, toActivity)
+}
+
+// convert maps rows of the postgres package to the sqlite package.
+func convert[From, To any](rows []From, err error, to func(From) To) ([]To, error) {
+	if err != nil {
+		return nil, err
+	}
+	out := make([]To, len(rows))
+	for i, row := range rows {
+		out[i] = to(row)
+	}
+	return out, nil
+}
+
+func (s *Postgres) UpsertUser(ctx context.Context, name string) (sqlite.User, error) {
+	u, err := s.queries.UpsertUser(ctx, name)
+	return sqlite.User(u), err
+}
+
+func (s *Postgres) GetUser(ctx context.Context, name string) (sqlite.User, error) {
+	u, err := s.queries.GetUser(ctx, name)
+	return sqlite.User(u), err
+}
+
+func (s *Postgres) ClaimActivities(ctx context.Context, ownerID int64) error {
+	return s.queries.ClaimActivities(ctx, ownerID)
+}
+
+func (s *Postgres) QueryActivities(ctx context.Context, ownerID int64) ([]sqlite.Activity, error) {
+	return activities(s.queries.QueryActivities(ctx, ownerID))
+}
+
+func (s *Postgres) QueryActivityByProject(ctx context.Context, arg sqlite.QueryActivityByProjectParams) (sqlite.Activity, error) {
+	return activity(s.queries.QueryActivityByProject(ctx, postgres.QueryActivityByProjectParams(arg)))
+}
+
+func (s *Postgres) InsertActivity(ctx context.Context, arg sqlite.InsertActivityParams) (sqlite.Activity, error) {
+	return activity(s.queries.InsertActivity(ctx, postgres.InsertActivityParams{
+		StartTime:    arg.StartTime,
+		EndTime:      arg.EndTime,
+		Duration:     interval(arg.Duration),
+		ActivityName: arg.ActivityName,
+		Description:  arg.Description,
+		Project:      arg.Project,
+		Notes:        arg.Notes,
+		Billable:     arg.Billable,
+		OwnerID:      arg.OwnerID,
+		UUID:         arg.UUID,
+	}))
+}
+
+func (s *Postgres) QueryActivityByUUID(ctx context.Context, uuid string) (sqlite.Activity, error) {
+	return activity(s.queries.QueryActivityByUUID(ctx, uuid))
+}
+
+func (s *Postgres) UpdateActivity(ctx context.Context, arg sqlite.UpdateActivityParams) (sqlite.Activity, error) {
+	return activity(s.queries.UpdateActivity(ctx, postgres.UpdateActivityParams{
+		StartTime:    arg.StartTime,
+		EndTime:      arg.EndTime,
+		Duration:     interval(arg.Duration),
+		ActivityName: arg.ActivityName,
+		Description:  arg.Description,
+		Project:      arg.Project,
+		Notes:        arg.Notes,
+		ID:           arg.ID,
+	}))
+}
+
+func (s *Postgres) QueryRunningActivity(ctx context.Context, ownerID int64) (sqlite.Activity, error) {
+	return activity(s.queries.QueryRunningActivity(ctx, ownerID))
+}
+
+func (s *Postgres) StopActivity(ctx context.Context, arg sqlite.StopActivityParams) (sqlite.Activity, error) {
+	return activity(s.queries.StopActivity(ctx, postgres.StopActivityParams{
+		EndTime:  arg.EndTime,
+		Duration: interval(arg.Duration),
+		ID:       arg.ID,
+	}))
+}
+
+func (s *Postgres) TouchActivity(ctx context.Context, arg sqlite.TouchActivityParams) error {
+	return s.queries.TouchActivity(ctx, postgres.TouchActivityParams(arg))
+}
+
+func (s *Postgres) QueryActivitiesBetween(ctx context.Context, arg sqlite.QueryActivitiesBetweenParams) ([]sqlite.Activity, error) {
+	return activities(s.queries.QueryActivitiesBetween(ctx, postgres.QueryActivitiesBetweenParams(arg)))
+}
+
+func (s *Postgres) QueryTeamActivitiesBetween(ctx context.Context, arg sqlite.QueryTeamActivitiesBetweenParams) ([]sqlite.Activity, error) {
+	return activities(s.queries.QueryTeamActivitiesBetween(ctx, postgres.QueryTeamActivitiesBetweenParams(arg)))
+}
+
+func (s *Postgres) SetActivityUUID(ctx context.Context, arg sqlite.SetActivityUUIDParams) (sqlite.Activity, error) {
+	return activity(s.queries.SetActivityUUID(ctx, postgres.SetActivityUUIDParams(arg)))
+}
+
+func (s *Postgres) SetActivityProject(ctx context.Context, arg sqlite.SetActivityProjectParams) (sqlite.Activity, error) {
+	return activity(s.queries.SetActivityProject(ctx, postgres.SetActivityProjectParams(arg)))
+}
+
+func (s *Postgres) DeleteActivity(ctx context.Context, id int64) error {
+	return s.queries.DeleteActivity(ctx, id)
+}
+
+func (s *Postgres) InsertActivityHistory(ctx context.Context, arg sqlite.InsertActivityHistoryParams) error {
+	return s.queries.InsertActivityHistory(ctx, postgres.InsertActivityHistoryParams(arg))
+}
+
+func (s *Postgres) QueryActivityHistory(ctx context.Context, activityID int64) ([]sqlite.ActivityHistory, error) {
+	rows, err := s.queries.QueryActivityHistory(ctx, activityID)
+	return convert(rows, err, func(h postgres.ActivityHistory) sqlite.ActivityHistory { return sqlite.ActivityHistory(h) })
+}
+
+func (s *Postgres) QueryActivityHistorySince(ctx context.Context, id int64) ([]sqlite.ActivityHistory, error) {
+	rows, err := s.queries.QueryActivityHistorySince(ctx, id)
+	return convert(rows, err, func(h postgres.ActivityHistory) sqlite.ActivityHistory { return sqlite.ActivityHistory(h) })
+}
+
+func (s *Postgres) GetLastActivityHistoryID(ctx context.Context) (int64, error) {
+	return s.queries.GetLastActivityHistoryID(ctx)
+}
+
+func (s *Postgres) QueryActivityTags(ctx context.Context, activityID int64) ([]string, error) {
+	return s.queries.QueryActivityTags(ctx, activityID)
+}
+
+func (s *Postgres) AddActivityTag(ctx context.Context, arg sqlite.AddActivityTagParams) error {
+	return s.queries.AddActivityTag(ctx, postgres.AddActivityTagParams(arg))
+}
+
+func (s *Postgres) RemoveActivityTag(ctx context.Context, arg sqlite.RemoveActivityTagParams) error {
+	return s.queries.RemoveActivityTag(ctx, postgres.RemoveActivityTagParams(arg))
+}
+
+func (s *Postgres) DeleteActivityTags(ctx context.Context, activityID int64) error {
+	return s.queries.DeleteActivityTags(ctx, activityID)
+}
+
+func (s *Postgres) QueryAllActivityTags(ctx context.Context, ownerID int64) ([]sqlite.ActivityTag, error) {
+	rows, err := s.queries.QueryAllActivityTags(ctx, ownerID)
+	return convert(rows, err, func(t postgres.ActivityTag) sqlite.ActivityTag { return sqlite.ActivityTag(t) })
+}
+
+func (s *Postgres) UpsertClient(ctx context.Context, arg sqlite.UpsertClientParams) (sqlite.Client, error) {
+	c, err := s.queries.UpsertClient(ctx, postgres.UpsertClientParams(arg))
+	return sqlite.Client(c), err
+}
+
+func (s *Postgres) GetClient(ctx context.Context, name string) (sqlite.Client, error) {
+	c, err := s.queries.GetClient(ctx, name)
+	return sqlite.Client(c), err
+}
+
+func (s *Postgres) UpsertProject(ctx context.Context, arg sqlite.UpsertProjectParams) (sqlite.Project, error) {
+	p, err := s.queries.UpsertProject(ctx, postgres.UpsertProjectParams(arg))
+	return sqlite.Project(p), err
+}
+
+func (s *Postgres) GetProject(ctx context.Context, name string) (sqlite.Project, error) {
+	p, err := s.queries.GetProject(ctx, name)
+	return sqlite.Project(p), err
+}
+
+func (s *Postgres) QueryProjectsByClient(ctx context.Context, client sql.NullString) ([]sqlite.Project, error) {
+	rows, err := s.queries.QueryProjectsByClient(ctx, client)
+	return convert(rows, err, func(p postgres.Project) sqlite.Project { return sqlite.Project(p) })
+}
+
+func (s *Postgres) SetActivityBillable(ctx context.Context, arg sqlite.SetActivityBillableParams) (sqlite.Activity, error) {
+	return activity(s.queries.SetActivityBillable(ctx, postgres.SetActivityBillableParams(arg)))
+}
+
+func (s *Postgres) QueryBillableActivities(ctx context.Context, arg sqlite.QueryBillableActivitiesParams) ([]sqlite.Activity, error) {
+	return activities(s.queries.QueryBillableActivities(ctx, postgres.QueryBillableActivitiesParams(arg)))
+}
+
+func (s *Postgres) NextInvoiceSequence(ctx context.Context, year int64) (int64, error) {
+	return s.queries.NextInvoiceSequence(ctx, year)
+}
+
+func (s *Postgres) InsertInvoice(ctx context.Context, arg sqlite.InsertInvoiceParams) (sqlite.Invoice, error) {
+	inv, err := s.queries.InsertInvoice(ctx, postgres.InsertInvoiceParams(arg))
+	return sqlite.Invoice(inv), err
+}
+
+func (s *Postgres) InsertInvoiceLine(ctx context.Context, arg sqlite.InsertInvoiceLineParams) error {
+	return s.queries.InsertInvoiceLine(ctx, postgres.InsertInvoiceLineParams(arg))
+}
+
+func (s *Postgres) SetActivityInvoice(ctx context.Context, arg sqlite.SetActivityInvoiceParams) (sqlite.Activity, error) {
+	return activity(s.queries.SetActivityInvoice(ctx, postgres.SetActivityInvoiceParams(arg)))
+}
+
+func (s *Postgres) QueryGoals(ctx context.Context) ([]sqlite.Goal, error) {
+	rows, err := s.queries.QueryGoals(ctx)
+	return convert(rows, err, func(g postgres.Goal) sqlite.Goal { return sqlite.Goal(g) })
+}
+
+func (s *Postgres) UpsertGoal(ctx context.Context, arg sqlite.UpsertGoalParams) (sqlite.Goal, error) {
+	g, err := s.queries.UpsertGoal(ctx, postgres.UpsertGoalParams(arg))
+	return sqlite.Goal(g), err
+}
+
+func (s *Postgres) DeleteGoal(ctx context.Context, arg sqlite.DeleteGoalParams) error {
+	return s.queries.DeleteGoal(ctx, postgres.DeleteGoalParams(arg))
+}
+
+func (s *Postgres) QueryTimeSegments(ctx context.Context, activityID int64) ([]sqlite.TimeSegment, error) {
+	rows, err := s.queries.QueryTimeSegments(ctx, activityID)
+	return convert(rows, err, func(t postgres.TimeSegment) sqlite.TimeSegment { return sqlite.TimeSegment(t) })
+}
+
+func (s *Postgres) InsertTimeSegment(ctx context.Context, arg sqlite.InsertTimeSegmentParams) (sqlite.TimeSegment, error) {
+	t, err := s.queries.InsertTimeSegment(ctx, postgres.InsertTimeSegmentParams(arg))
+	return sqlite.TimeSegment(t), err
+}
+
+func (s *Postgres) CloseTimeSegment(ctx context.Context, arg sqlite.CloseTimeSegmentParams) error {
+	return s.queries.CloseTimeSegment(ctx, postgres.CloseTimeSegmentParams(arg))
+}
+
+func (s *Postgres) UpdateTimeSegment(ctx context.Context, arg sqlite.UpdateTimeSegmentParams) error {
+	return s.queries.UpdateTimeSegment(ctx, postgres.UpdateTimeSegmentParams(arg))
+}
+
+func (s *Postgres) DeleteTimeSegments(ctx context.Context, activityID int64) error {
+	return s.queries.DeleteTimeSegments(ctx, activityID)
+}
+
+func (s *Postgres) GetSyncLocal(ctx context.Context) (sqlite.SyncLocal, error) {
+	l, err := s.queries.GetSyncLocal(ctx)
+	return sqlite.SyncLocal(l), err
+}
+
+func (s *Postgres) InsertSyncLocal(ctx context.Context, arg sqlite.InsertSyncLocalParams) error {
+	return s.queries.InsertSyncLocal(ctx, postgres.InsertSyncLocalParams(arg))
+}
+
+func (s *Postgres) SetSyncExported(ctx context.Context, exportedHistoryID int64) error {
+	return s.queries.SetSyncExported(ctx, exportedHistoryID)
+}
+
+func (s *Postgres) QuerySyncDevices(ctx context.Context) ([]sqlite.SyncDevice, error) {
+	rows, err := s.queries.QuerySyncDevices(ctx)
+	return convert(rows, err, func(d postgres.SyncDevice) sqlite.SyncDevice { return sqlite.SyncDevice(d) })
+}
+
+func (s *Postgres) SetSyncDeviceApplied(ctx context.Context, arg sqlite.SetSyncDeviceAppliedParams) error {
+	return s.queries.SetSyncDeviceApplied(ctx, postgres.SetSyncDeviceAppliedParams(arg))
+}
+
+func (s *Postgres) GetSyncClock(ctx context.Context, arg sqlite.GetSyncClockParams) (sqlite.SyncClock, error) {
+	c, err := s.queries.GetSyncClock(ctx, postgres.GetSyncClockParams(arg))
+	return sqlite.SyncClock(c), err
+}
+
+func (s *Postgres) SetSyncClock(ctx context.Context, arg sqlite.SetSyncClockParams) error {
+	return s.queries.SetSyncClock(ctx, postgres.SetSyncClockParams(arg))
+}
+
+func (s *Postgres) InsertSyncConflict(ctx context.Context, arg sqlite.InsertSyncConflictParams) error {
+	return s.queries.InsertSyncConflict(ctx, postgres.InsertSyncConflictParams(arg))
+}
+
+func (s *Postgres) QuerySyncConflicts(ctx context.Context) ([]sqlite.SyncConflict, error) {
+	rows, err := s.queries.QuerySyncConflicts(ctx)
+	return convert(rows, err, func(c postgres.SyncConflict) sqlite.SyncConflict { return sqlite.SyncConflict(c) })
+}
+
+func (s *Postgres) DeleteSyncConflict(ctx context.Context, id int64) error {
+	return s.queries.DeleteSyncConflict(ctx, id)
+}
+
+func (s *Postgres) GetEncryption(ctx context.Context) (sqlite.Encryption, error) {
+	e, err := s.queries.GetEncryption(ctx)
+	return sqlite.Encryption(e), err
+}
+
+func (s *Postgres) InsertEncryption(ctx context.Context, arg sqlite.InsertEncryptionParams) error {
+	return s.queries.InsertEncryption(ctx, postgres.InsertEncryptionParams(arg))
+}
+
+func (s *Postgres) UpdateEncryption(ctx context.Context, arg sqlite.UpdateEncryptionParams) error {
+	return s.queries.UpdateEncryption(ctx, postgres.UpdateEncryptionParams(arg))
+}
+
+func (s *Postgres) DeleteEncryption(ctx context.Context) error {
+	return s.queries.DeleteEncryption(ctx)
+}
+
+func (s *Postgres) SetEncryptionRekeying(ctx context.Context, rekeying bool) error {
+	return s.queries.SetEncryptionRekeying(ctx, rekeying)
+}
+
+func (s *Postgres) QueryAllActivities(ctx context.Context) ([]sqlite.Activity, error) {
+	return activities(s.queries.QueryAllActivities(ctx))
+}
+
+func (s *Postgres) SetActivityText(ctx context.Context, arg sqlite.SetActivityTextParams) error {
+	return s.queries.SetActivityText(ctx, postgres.SetActivityTextParams(arg))
+}
+
+func (s *Postgres) SetActivityHistoryValues(ctx context.Context, arg sqlite.SetActivityHistoryValuesParams) error {
+	return s.queries.SetActivityHistoryValues(ctx, postgres.SetActivityHistoryValuesParams(arg))
+}
+
+func (s *Postgres) SetSyncConflictValues(ctx context.Context, arg sqlite.SetSyncConflictValuesParams) error {
+	return s.queries.SetSyncConflictValues(ctx, postgres.SetSyncConflictValuesParams(arg))
+}
+
+func (s *Postgres) GetSummary(ctx context.Context, arg sqlite.GetSummaryParams) (sqlite.Summary, error) {
+	sum, err := s.queries.GetSummary(ctx, postgres.GetSummaryParams(arg))
+	return sqlite.Summary(sum), err
+}
+
+func (s *Postgres) UpsertSummary(ctx context.Context, arg sqlite.UpsertSummaryParams) (sqlite.Summary, error) {
+	sum, err := s.queries.UpsertSummary(ctx, postgres.UpsertSummaryParams(arg))
+	return sqlite.Summary(sum), err
+}
+
+func (s *Postgres) QueryAllSummaries(ctx context.Context) ([]sqlite.Summary, error) {
+	rows, err := s.queries.QueryAllSummaries(ctx)
+	return convert(rows, err, func(sum postgres.Summary) sqlite.Summary { return sqlite.Summary(sum) })
+}
+
+func (s *Postgres) SetSummaryContent(ctx context.Context, arg sqlite.SetSummaryContentParams) error {
+	return s.queries.SetSummaryContent(ctx, postgres.SetSummaryContentParams(arg))
+}
+
+func (s *Postgres) InsertLLMCall(ctx context.Context, arg sqlite.InsertLLMCallParams) error {
+	return s.queries.InsertLLMCall(ctx, postgres.InsertLLMCallParams(arg))
+}
+
+func (s *Postgres) QueryLLMCalls(ctx context.Context) ([]sqlite.LLMCall, error) {
+	rows, err := s.queries.QueryLLMCalls(ctx)
+	return convert(rows, err, func(c postgres.LLMCall) sqlite.LLMCall { return sqlite.LLMCall(c) })
+}
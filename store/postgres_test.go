@@ -0,0 +1,196 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// The Postgres tests use the database named by PROBABLE_MEMORY_TEST_POSTGRES
+// if it is set. Otherwise they start a throwaway server with the initdb and
+// pg_ctl found on the PATH or in the usual install locations, and are
+// skipped if there are none.
+const postgresEnv = "PROBABLE_MEMORY_TEST_POSTGRES"
+
+var testServer struct {
+	once sync.Once
+	dsn  string
+	stop func()
+	err  error
+}
+
+var testSchemas atomic.Int64
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if testServer.stop != nil {
+		testServer.stop()
+	}
+	os.Exit(code)
+}
+
+func TestPostgres(t *testing.T) {
+	dsn := postgresDSN(t)
+	testStore(t, func(t *testing.T) ActivityStore {
+		return NewPostgres(openTestPool(t, dsn))
+	})
+}
+
+func postgresDSN(t *testing.T) string {
+	t.Helper()
+	if dsn := os.Getenv(postgresEnv); dsn != "" {
+		return dsn
+	}
+	testServer.once.Do(func() {
+		testServer.dsn, testServer.stop, testServer.err = startPostgres()
+	})
+	if testServer.err != nil {
+		t.Skipf("no PostgreSQL to test against (set %s to use one): %v", postgresEnv, testServer.err)
+	}
+	return testServer.dsn
+}
+
+// postgresBin finds the directory holding initdb and pg_ctl.
+func postgresBin() (string, error) {
+	if path, err := exec.LookPath("initdb"); err == nil {
+		return filepath.Dir(path), nil
+	}
+	var found []string
+	for _, pattern := range []string{
+		"/usr/lib/postgresql/*/bin/initdb",
+		"/usr/local/opt/postgresql*/bin/initdb",
+		"/opt/homebrew/opt/postgresql*/bin/initdb",
+	} {
+		matches, _ := filepath.Glob(pattern)
+		found = append(found, matches...)
+	}
+	if len(found) == 0 {
+		return "", errors.New("initdb not found")
+	}
+	sort.Strings(found)
+	return filepath.Dir(found[len(found)-1]), nil
+}
+
+// startPostgres runs a server in a temporary directory, listening only on
+// a Unix socket there.
+func startPostgres() (dsn string, stop func(), err error) {
+	bin, err := postgresBin()
+	if err != nil {
+		return "", nil, err
+	}
+	if os.Geteuid() == 0 {
+		return "", nil, errors.New("initdb cannot be run as root")
+	}
+	dir, err := os.MkdirTemp("", "probable-memory-postgres-")
+	if err != nil {
+		return "", nil, err
+	}
+	data := filepath.Join(dir, "data")
+	run := func(name string, args ...string) error {
+		out, err := exec.Command(filepath.Join(bin, name), args...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%s: %v\n%s", name, err, out)
+		}
+		return nil
+	}
+	err = run("initdb", "-D", data, "-U", "postgres", "-A", "trust", "-E", "UTF8", "--no-sync")
+	if err == nil {
+		err = run("pg_ctl", "-D", data, "-l", filepath.Join(dir, "postgres.log"), "-w",
+			"-o", fmt.Sprintf("-k %s -c listen_addresses=''", dir), "start")
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+	stop = func() {
+		run("pg_ctl", "-D", data, "-m", "immediate", "stop")
+		os.RemoveAll(dir)
+	}
+	return fmt.Sprintf("host=%s user=postgres dbname=postgres", dir), stop, nil
+}
+
+// openTestPool gives each test a schema of its own, created from the Up
+// section of every migration in ../postgres/migrations and dropped when
+// the test ends.
+func openTestPool(t *testing.T, dsn string) *pgxpool.Pool {
+	t.Helper()
+	ctx := context.Background()
+	schema := fmt.Sprintf("probable_memory_test_%d_%d", os.Getpid(), testSchemas.Add(1))
+
+	admin, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close(ctx)
+	if _, err := admin.Exec(ctx, "create schema "+schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		admin, err := pgx.Connect(ctx, dsn)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer admin.Close(ctx)
+		if _, err := admin.Exec(ctx, "drop schema "+schema+" cascade"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+
+	files, err := filepath.Glob("../postgres/migrations/*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		up, _, _ := strings.Cut(string(b), "-- +goose Down")
+		if _, err := pool.Exec(ctx, up); err != nil {
+			t.Fatalf("%s: %v", filepath.Base(file), err)
+		}
+	}
+	return pool
+}
+
+func TestInterval(t *testing.T) {
+	for _, secs := range []int64{0, 1, 5400, 90061} {
+		got := seconds(interval(sql.NullInt64{Int64: secs, Valid: true}))
+		if !got.Valid || got.Int64 != secs {
+			t.Errorf("seconds(interval(%d)) = %v", secs, got)
+		}
+	}
+	if got := seconds(interval(sql.NullInt64{})); got.Valid {
+		t.Errorf("a null duration became %v", got)
+	}
+	got := seconds(pgtype.Interval{Months: 1, Days: 1, Microseconds: 1_000_000, Valid: true})
+	if want := int64(31*24*60*60 + 1); got.Int64 != want {
+		t.Errorf("seconds(1 mon 1 day 1 s) = %d, want %d", got.Int64, want)
+	}
+}
@@ -0,0 +1,49 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Proqpine/probable-memory/sqlite"
+)
+
+// SQLite is an ActivityStore over the sqlc queries.
+type SQLite struct {
+	*sqlite.Queries
+	db *sql.DB
+	tx *sql.Tx
+}
+
+var _ ActivityStore = (*SQLite)(nil)
+
+func NewSQLite(db *sql.DB) *SQLite {
+	return &SQLite{Queries: sqlite.New(db), db: db}
+}
+
+// Compact rebuilds the database file and empties the write-ahead log into
+// it, so that no page holding overwritten or deleted values is left in
+// either.
+func (s *SQLite) Compact(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, "vacuum"); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, "pragma wal_checkpoint(truncate)")
+	return err
+}
+
+// InTx runs fn in a database transaction. Inside a transaction it runs fn
+// in the same one.
+func (s *SQLite) InTx(ctx context.Context, fn func(ActivityStore) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(&SQLite{Queries: s.Queries.WithTx(tx), db: s.db, tx: tx}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,20 @@
+// Package store is the app's storage: ActivityStore is every query the app
+// runs, with an implementation over SQLite and one in memory for tests.
+package store
+
+import (
+	"context"
+
+	"github.com/Proqpine/probable-memory/sqlite"
+)
+
+// ActivityStore is the app's storage. The queries are those of the sqlc
+// package and behave as they do against SQLite with foreign keys enforced;
+// a :one query that matches nothing returns sql.ErrNoRows.
+type ActivityStore interface {
+	sqlite.Querier
+
+	// InTx runs fn against a store whose changes are kept only if fn
+	// returns nil.
+	InTx(ctx context.Context, fn func(ActivityStore) error) error
+}
@@ -0,0 +1,930 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Proqpine/probable-memory/fieldcrypt"
+	"github.com/Proqpine/probable-memory/sqlite"
+	"github.com/google/uuid"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSQLite(t *testing.T) {
+	testStore(t, func(t *testing.T) ActivityStore {
+		return NewSQLite(openTestDB(t))
+	})
+}
+
+func TestMemory(t *testing.T) {
+	testStore(t, func(t *testing.T) ActivityStore {
+		return NewMemory()
+	})
+}
+
+func TestEncrypted(t *testing.T) {
+	testStore(t, func(t *testing.T) ActivityStore {
+		return NewEncrypted(NewSQLite(openTestDB(t)), testKey(t))
+	})
+}
+
+func testKey(t *testing.T) *fieldcrypt.Key {
+	t.Helper()
+	key, err := fieldcrypt.ParseKeyFile([]byte(strings.Repeat("k", fieldcrypt.KeySize)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// TestEncryptedAtRest checks that what Encrypted writes cannot be read
+// from the store underneath.
+func TestEncryptedAtRest(t *testing.T) {
+	ctx := context.Background()
+	raw := NewSQLite(openTestDB(t))
+	s := NewEncrypted(raw, testKey(t))
+	a, err := s.InsertActivity(ctx, sqlite.InsertActivityParams{
+		StartTime:    day,
+		ActivityName: "Call",
+		Description:  "Acme merger",
+		Notes:        "confidential",
+		UUID:         uuid.NewString(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Description != "Acme merger" || a.Notes != "confidential" {
+		t.Errorf("InsertActivity returned %q, %q", a.Description, a.Notes)
+	}
+	stored, err := raw.QueryActivityByUUID(ctx, a.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fieldcrypt.IsEncrypted(stored.Description) || !fieldcrypt.IsEncrypted(stored.Notes) || stored.ActivityName != "Call" {
+		t.Errorf("stored %q, %q, %q", stored.ActivityName, stored.Description, stored.Notes)
+	}
+	wrong, err := fieldcrypt.ParseKeyFile([]byte(strings.Repeat("w", fieldcrypt.KeySize)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewEncrypted(raw, wrong).QueryActivityByUUID(ctx, a.UUID); !errors.Is(err, fieldcrypt.ErrWrongKey) {
+		t.Errorf("reading with the wrong key = %v, want ErrWrongKey", err)
+	}
+}
+
+// TestCompact checks that overwritten values are gone from the database
+// file and its write-ahead log.
+func TestCompact(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("pragma journal_mode = wal"); err != nil {
+		t.Fatal(err)
+	}
+	var seq int
+	var name, path string
+	if err := db.QueryRow("pragma database_list").Scan(&seq, &name, &path); err != nil {
+		t.Fatal(err)
+	}
+	s := NewSQLite(db)
+	a, err := s.InsertActivity(ctx, sqlite.InsertActivityParams{
+		StartTime:    day,
+		ActivityName: "Call",
+		Description:  "Acme merger",
+		UUID:         uuid.NewString(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetActivityText(ctx, sqlite.SetActivityTextParams{ID: a.ID, Description: "sealed"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Compact(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{path, path + "-wal"} {
+		b, err := os.ReadFile(file)
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(b), "Acme merger") {
+			t.Errorf("%s still holds the old description", filepath.Base(file))
+		}
+	}
+}
+
+// openTestDB creates a database in a temporary directory and applies the
+// Up section of every migration in order.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "activity.db")
+	db, err := sql.Open("sqlite3", "file:"+path+"?_foreign_keys=on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	files, err := filepath.Glob("../migrations/*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		up, _, _ := strings.Cut(string(b), "-- +goose Down")
+		if _, err := db.Exec(up); err != nil {
+			t.Fatalf("%s: %v", filepath.Base(file), err)
+		}
+	}
+	return db
+}
+
+var day = time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+
+// addUser returns the id of the named user, adding them if need be.
+func addUser(t *testing.T, s ActivityStore, name string) int64 {
+	t.Helper()
+	u, err := s.UpsertUser(context.Background(), name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.ID
+}
+
+// addActivity adds an activity owned by the user "me".
+func addActivity(t *testing.T, s ActivityStore, name, project string, start time.Time, duration time.Duration) sqlite.Activity {
+	t.Helper()
+	params := sqlite.InsertActivityParams{
+		StartTime:    start,
+		ActivityName: name,
+		Project:      project,
+		OwnerID:      sql.NullInt64{Int64: addUser(t, s, "me"), Valid: true},
+		UUID:         uuid.NewString(),
+	}
+	if duration > 0 {
+		params.EndTime = sql.NullTime{Time: start.Add(duration), Valid: true}
+		params.Duration = sql.NullInt64{Int64: int64(duration.Seconds()), Valid: true}
+	}
+	a, err := s.InsertActivity(context.Background(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func names(activities []sqlite.Activity) string {
+	var out []string
+	for _, a := range activities {
+		out = append(out, a.ActivityName)
+	}
+	return strings.Join(out, ",")
+}
+
+// testStore is the contract every ActivityStore must meet. open returns an
+// empty store.
+func testStore(t *testing.T, open func(*testing.T) ActivityStore) {
+	ctx := context.Background()
+
+	t.Run("activities", func(t *testing.T) {
+		s := open(t)
+		b := addActivity(t, s, "b", "bolt", day.Add(2*time.Hour), time.Hour)
+		addActivity(t, s, "a", "docs", day, time.Hour)
+		addActivity(t, s, "c", "bolt", day.Add(24*time.Hour), time.Hour)
+		me := addUser(t, s, "me")
+
+		all, err := s.QueryActivities(ctx, me)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := names(all); got != "b,a,c" {
+			t.Errorf("QueryActivities = %s, want insertion order b,a,c", got)
+		}
+		between, err := s.QueryActivitiesBetween(ctx, sqlite.QueryActivitiesBetweenParams{
+			PeriodStart: day,
+			PeriodEnd:   day.Add(24 * time.Hour),
+			OwnerID:     me,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := names(between); got != "a,b" {
+			t.Errorf("QueryActivitiesBetween = %s, want a,b", got)
+		}
+		first, err := s.QueryActivityByProject(ctx, sqlite.QueryActivityByProjectParams{Project: "bolt", OwnerID: me})
+		if err != nil || first.ID != b.ID {
+			t.Errorf("QueryActivityByProject = %v, %v, want %d", first.ID, err, b.ID)
+		}
+		if _, err := s.QueryActivityByProject(ctx, sqlite.QueryActivityByProjectParams{Project: "none", OwnerID: me}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("QueryActivityByProject(missing) error = %v, want sql.ErrNoRows", err)
+		}
+
+		updated, err := s.UpdateActivity(ctx, sqlite.UpdateActivityParams{
+			ID:           b.ID,
+			StartTime:    b.StartTime,
+			EndTime:      b.EndTime,
+			Duration:     b.Duration,
+			ActivityName: "b2",
+			Project:      "docs",
+			Notes:        "# Notes\n\nlong",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if updated.ActivityName != "b2" || updated.Project != "docs" || updated.Notes != "# Notes\n\nlong" {
+			t.Errorf("UpdateActivity = %+v", updated)
+		}
+		if _, err := s.UpdateActivity(ctx, sqlite.UpdateActivityParams{ID: 999}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("UpdateActivity(missing) error = %v, want sql.ErrNoRows", err)
+		}
+		moved, err := s.SetActivityProject(ctx, sqlite.SetActivityProjectParams{ID: b.ID, Project: "bolt"})
+		if err != nil || moved.Project != "bolt" {
+			t.Errorf("SetActivityProject = %q, %v", moved.Project, err)
+		}
+	})
+
+	t.Run("running", func(t *testing.T) {
+		s := open(t)
+		me := addUser(t, s, "me")
+		if _, err := s.QueryRunningActivity(ctx, me); !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("QueryRunningActivity on empty store error = %v, want sql.ErrNoRows", err)
+		}
+		addActivity(t, s, "done", "", day.Add(3*time.Hour), time.Hour)
+		addActivity(t, s, "older", "", day, 0)
+		running := addActivity(t, s, "newer", "", day.Add(time.Hour), 0)
+
+		got, err := s.QueryRunningActivity(ctx, me)
+		if err != nil || got.ID != running.ID {
+			t.Fatalf("QueryRunningActivity = %q, %v, want newer", got.ActivityName, err)
+		}
+		seen := day.Add(90 * time.Minute)
+		if err := s.TouchActivity(ctx, sqlite.TouchActivityParams{ID: running.ID, HeartbeatAt: sql.NullTime{Time: seen, Valid: true}}); err != nil {
+			t.Fatal(err)
+		}
+		stopped, err := s.StopActivity(ctx, sqlite.StopActivityParams{
+			ID:       running.ID,
+			EndTime:  sql.NullTime{Time: day.Add(2 * time.Hour), Valid: true},
+			Duration: sql.NullInt64{Int64: 3600, Valid: true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !stopped.HeartbeatAt.Valid || !stopped.HeartbeatAt.Time.Equal(seen) || stopped.Duration.Int64 != 3600 {
+			t.Errorf("StopActivity = %+v", stopped)
+		}
+		got, err = s.QueryRunningActivity(ctx, me)
+		if err != nil || got.ActivityName != "older" {
+			t.Errorf("QueryRunningActivity after stop = %q, %v, want older", got.ActivityName, err)
+		}
+		if _, err := s.StopActivity(ctx, sqlite.StopActivityParams{ID: 999}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("StopActivity(missing) error = %v, want sql.ErrNoRows", err)
+		}
+	})
+
+	t.Run("users", func(t *testing.T) {
+		s := open(t)
+		me := addUser(t, s, "me")
+		if again := addUser(t, s, "me"); again != me {
+			t.Errorf("UpsertUser of an existing user = %d, want %d", again, me)
+		}
+		other := addUser(t, s, "other")
+		if other == me {
+			t.Fatal("two users share an id")
+		}
+		addActivity(t, s, "mine", "bolt", day, 0)
+		theirs, err := s.InsertActivity(ctx, sqlite.InsertActivityParams{
+			StartTime:    day.Add(time.Hour),
+			ActivityName: "theirs",
+			Project:      "bolt",
+			OwnerID:      sql.NullInt64{Int64: other, Valid: true},
+			UUID:         uuid.NewString(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.AddActivityTag(ctx, sqlite.AddActivityTagParams{ActivityID: theirs.ID, Tag: "x"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.InsertActivity(ctx, sqlite.InsertActivityParams{StartTime: day, ActivityName: "old", UUID: uuid.NewString()}); err != nil {
+			t.Fatal(err)
+		}
+
+		if all, _ := s.QueryActivities(ctx, me); names(all) != "mine" {
+			t.Errorf("QueryActivities(me) = %s, want mine", names(all))
+		}
+		if got, err := s.QueryRunningActivity(ctx, other); err != nil || got.ID != theirs.ID {
+			t.Errorf("QueryRunningActivity(other) = %q, %v, want theirs", got.ActivityName, err)
+		}
+		if a, err := s.QueryActivityByProject(ctx, sqlite.QueryActivityByProjectParams{Project: "bolt", OwnerID: other}); err != nil || a.ID != theirs.ID {
+			t.Errorf("QueryActivityByProject(other) = %q, %v, want theirs", a.ActivityName, err)
+		}
+		if tags, _ := s.QueryAllActivityTags(ctx, me); len(tags) != 0 {
+			t.Errorf("QueryAllActivityTags(me) = %v, want none", tags)
+		}
+		team, err := s.QueryTeamActivitiesBetween(ctx, sqlite.QueryTeamActivitiesBetweenParams{
+			PeriodStart: day,
+			PeriodEnd:   day.Add(24 * time.Hour),
+		})
+		if err != nil || len(team) != 3 {
+			t.Errorf("QueryTeamActivitiesBetween = %s, %v, want all three", names(team), err)
+		}
+
+		if err := s.ClaimActivities(ctx, me); err != nil {
+			t.Fatal(err)
+		}
+		if all, _ := s.QueryActivities(ctx, me); names(all) != "mine,old" {
+			t.Errorf("QueryActivities(me) after claim = %s, want mine,old", names(all))
+		}
+		if all, _ := s.QueryActivities(ctx, other); names(all) != "theirs" {
+			t.Errorf("QueryActivities(other) after claim = %s, want theirs", names(all))
+		}
+		if _, err := s.InsertActivity(ctx, sqlite.InsertActivityParams{StartTime: day, OwnerID: sql.NullInt64{Int64: 999, Valid: true}}); err == nil {
+			t.Error("InsertActivity for a missing user succeeded")
+		}
+	})
+
+	t.Run("sync", func(t *testing.T) {
+		s := open(t)
+		a := addActivity(t, s, "a", "", day, time.Hour)
+		if got, err := s.QueryActivityByUUID(ctx, a.UUID); err != nil || got.ID != a.ID {
+			t.Errorf("QueryActivityByUUID = %d, %v, want %d", got.ID, err, a.ID)
+		}
+		if _, err := s.QueryActivityByUUID(ctx, "missing"); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("QueryActivityByUUID(missing) error = %v, want sql.ErrNoRows", err)
+		}
+		if _, err := s.InsertActivity(ctx, sqlite.InsertActivityParams{StartTime: day, UUID: a.UUID}); err == nil {
+			t.Error("InsertActivity with a duplicate UUID succeeded")
+		}
+		b := addActivity(t, s, "b", "", day, time.Hour)
+		if _, err := s.SetActivityUUID(ctx, sqlite.SetActivityUUIDParams{UUID: a.UUID, ID: b.ID}); err == nil {
+			t.Error("SetActivityUUID to a duplicate UUID succeeded")
+		}
+		if got, err := s.SetActivityUUID(ctx, sqlite.SetActivityUUIDParams{UUID: "adopted", ID: b.ID}); err != nil || got.UUID != "adopted" {
+			t.Errorf("SetActivityUUID = %q, %v, want adopted", got.UUID, err)
+		}
+
+		if last, err := s.GetLastActivityHistoryID(ctx); err != nil || last != 0 {
+			t.Errorf("GetLastActivityHistoryID on empty history = %d, %v", last, err)
+		}
+		for _, source := range []string{"tui", "sync"} {
+			err := s.InsertActivityHistory(ctx, sqlite.InsertActivityHistoryParams{ActivityID: a.ID, Action: "update", Source: source, ChangedAt: day})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		last, err := s.GetLastActivityHistoryID(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		since, err := s.QueryActivityHistorySince(ctx, last-1)
+		if err != nil || len(since) != 1 || since[0].Source != "sync" {
+			t.Errorf("QueryActivityHistorySince = %+v, %v, want the sync entry", since, err)
+		}
+
+		if _, err := s.GetSyncLocal(ctx); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("GetSyncLocal before the first sync error = %v, want sql.ErrNoRows", err)
+		}
+		if err := s.InsertSyncLocal(ctx, sqlite.InsertSyncLocalParams{Device: "here", ExportedHistoryID: 1}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.SetSyncExported(ctx, last); err != nil {
+			t.Fatal(err)
+		}
+		if local, err := s.GetSyncLocal(ctx); err != nil || local.Device != "here" || local.ExportedHistoryID != last {
+			t.Errorf("GetSyncLocal = %+v, %v", local, err)
+		}
+
+		for _, d := range []sqlite.SetSyncDeviceAppliedParams{{Device: "b", Applied: 1}, {Device: "a", Applied: 2}, {Device: "b", Applied: 3}} {
+			if err := s.SetSyncDeviceApplied(ctx, d); err != nil {
+				t.Fatal(err)
+			}
+		}
+		devices, err := s.QuerySyncDevices(ctx)
+		if err != nil || len(devices) != 2 || devices[0] != (sqlite.SyncDevice{Device: "a", Applied: 2}) || devices[1] != (sqlite.SyncDevice{Device: "b", Applied: 3}) {
+			t.Errorf("QuerySyncDevices = %+v, %v", devices, err)
+		}
+
+		key := sqlite.GetSyncClockParams{ActivityUUID: a.UUID, Field: "name"}
+		if _, err := s.GetSyncClock(ctx, key); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("GetSyncClock(missing) error = %v, want sql.ErrNoRows", err)
+		}
+		for _, c := range []sqlite.SetSyncClockParams{
+			{ActivityUUID: a.UUID, Field: "name", ChangedAt: day, Device: "a", Seq: 1},
+			{ActivityUUID: a.UUID, Field: "name", ChangedAt: day.Add(time.Hour), Device: "b", Seq: 4},
+		} {
+			if err := s.SetSyncClock(ctx, c); err != nil {
+				t.Fatal(err)
+			}
+		}
+		clock, err := s.GetSyncClock(ctx, key)
+		if err != nil || clock.Device != "b" || clock.Seq != 4 || !clock.ChangedAt.Equal(day.Add(time.Hour)) {
+			t.Errorf("GetSyncClock = %+v, %v", clock, err)
+		}
+
+		for _, field := range []string{"name", "project"} {
+			err := s.InsertSyncConflict(ctx, sqlite.InsertSyncConflictParams{ActivityUUID: a.UUID, Field: field, Kept: `"x"`, Other: `"y"`, CreatedAt: day})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		conflicts, err := s.QuerySyncConflicts(ctx)
+		if err != nil || len(conflicts) != 2 || conflicts[0].Field != "name" || conflicts[1].Other != `"y"` {
+			t.Fatalf("QuerySyncConflicts = %+v, %v", conflicts, err)
+		}
+		if err := s.DeleteSyncConflict(ctx, conflicts[0].ID); err != nil {
+			t.Fatal(err)
+		}
+		if conflicts, _ := s.QuerySyncConflicts(ctx); len(conflicts) != 1 || conflicts[0].Field != "project" {
+			t.Errorf("QuerySyncConflicts after delete = %+v", conflicts)
+		}
+	})
+
+	t.Run("segments", func(t *testing.T) {
+		s := open(t)
+		a := addActivity(t, s, "a", "", day, 0)
+		first, err := s.InsertTimeSegment(ctx, sqlite.InsertTimeSegmentParams{ActivityID: a.ID, StartTime: day})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.CloseTimeSegment(ctx, sqlite.CloseTimeSegmentParams{ID: first.ID, EndTime: sql.NullTime{Time: day.Add(time.Hour), Valid: true}}); err != nil {
+			t.Fatal(err)
+		}
+		second, err := s.InsertTimeSegment(ctx, sqlite.InsertTimeSegmentParams{ActivityID: a.ID, StartTime: day.Add(2 * time.Hour)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.UpdateTimeSegment(ctx, sqlite.UpdateTimeSegmentParams{
+			ID:        second.ID,
+			StartTime: day.Add(3 * time.Hour),
+			EndTime:   sql.NullTime{Time: day.Add(4 * time.Hour), Valid: true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		segments, err := s.QueryTimeSegments(ctx, a.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(segments) != 2 || segments[0].ID != first.ID || !segments[0].EndTime.Valid || !segments[1].StartTime.Equal(day.Add(3*time.Hour)) {
+			t.Errorf("QueryTimeSegments = %+v", segments)
+		}
+		if _, err := s.InsertTimeSegment(ctx, sqlite.InsertTimeSegmentParams{ActivityID: 999, StartTime: day}); err == nil {
+			t.Error("InsertTimeSegment for a missing activity succeeded")
+		}
+		if err := s.DeleteTimeSegments(ctx, a.ID); err != nil {
+			t.Fatal(err)
+		}
+		if segments, _ := s.QueryTimeSegments(ctx, a.ID); len(segments) != 0 {
+			t.Errorf("QueryTimeSegments after delete = %+v", segments)
+		}
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		s := open(t)
+		a := addActivity(t, s, "a", "", day, time.Hour)
+		b := addActivity(t, s, "b", "", day, time.Hour)
+		for _, tag := range []string{"review", "meeting", "review"} {
+			if err := s.AddActivityTag(ctx, sqlite.AddActivityTagParams{ActivityID: a.ID, Tag: tag}); err != nil {
+				t.Fatalf("AddActivityTag(%q): %v", tag, err)
+			}
+		}
+		if err := s.AddActivityTag(ctx, sqlite.AddActivityTagParams{ActivityID: b.ID, Tag: "focus"}); err != nil {
+			t.Fatal(err)
+		}
+		tags, err := s.QueryActivityTags(ctx, a.ID)
+		if err != nil || strings.Join(tags, ",") != "meeting,review" {
+			t.Errorf("QueryActivityTags = %v, %v, want meeting,review", tags, err)
+		}
+		all, err := s.QueryAllActivityTags(ctx, addUser(t, s, "me"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []sqlite.ActivityTag{{ActivityID: a.ID, Tag: "meeting"}, {ActivityID: a.ID, Tag: "review"}, {ActivityID: b.ID, Tag: "focus"}}
+		if len(all) != len(want) {
+			t.Fatalf("QueryAllActivityTags = %v, want %v", all, want)
+		}
+		for i := range want {
+			if all[i] != want[i] {
+				t.Errorf("QueryAllActivityTags[%d] = %v, want %v", i, all[i], want[i])
+			}
+		}
+		if err := s.RemoveActivityTag(ctx, sqlite.RemoveActivityTagParams{ActivityID: a.ID, Tag: "review"}); err != nil {
+			t.Fatal(err)
+		}
+		if tags, _ := s.QueryActivityTags(ctx, a.ID); strings.Join(tags, ",") != "meeting" {
+			t.Errorf("QueryActivityTags after remove = %v", tags)
+		}
+		if err := s.DeleteActivityTags(ctx, a.ID); err != nil {
+			t.Fatal(err)
+		}
+		if tags, _ := s.QueryActivityTags(ctx, a.ID); len(tags) != 0 {
+			t.Errorf("QueryActivityTags after delete = %v", tags)
+		}
+		if err := s.AddActivityTag(ctx, sqlite.AddActivityTagParams{ActivityID: 999, Tag: "x"}); err == nil {
+			t.Error("AddActivityTag for a missing activity succeeded")
+		}
+	})
+
+	t.Run("delete cascades", func(t *testing.T) {
+		s := open(t)
+		a := addActivity(t, s, "a", "", day, time.Hour)
+		if _, err := s.InsertTimeSegment(ctx, sqlite.InsertTimeSegmentParams{ActivityID: a.ID, StartTime: day}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.AddActivityTag(ctx, sqlite.AddActivityTagParams{ActivityID: a.ID, Tag: "x"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.DeleteActivity(ctx, a.ID); err != nil {
+			t.Fatal(err)
+		}
+		if all, _ := s.QueryActivities(ctx, addUser(t, s, "me")); len(all) != 0 {
+			t.Errorf("QueryActivities after delete = %s", names(all))
+		}
+		if segments, _ := s.QueryTimeSegments(ctx, a.ID); len(segments) != 0 {
+			t.Errorf("segments left after delete: %+v", segments)
+		}
+		if tags, _ := s.QueryAllActivityTags(ctx, addUser(t, s, "me")); len(tags) != 0 {
+			t.Errorf("tags left after delete: %v", tags)
+		}
+	})
+
+	t.Run("history", func(t *testing.T) {
+		s := open(t)
+		for i, action := range []string{"insert", "update", "update"} {
+			err := s.InsertActivityHistory(ctx, sqlite.InsertActivityHistoryParams{
+				ActivityID: 1,
+				Action:     action,
+				Source:     "tui",
+				NewValues:  sql.NullString{String: action, Valid: true},
+				ChangedAt:  day.Add(time.Duration(min(i, 1)) * time.Minute),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		rows, err := s.QueryActivityHistory(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != 3 || rows[0].ID <= rows[1].ID || rows[2].Action != "insert" {
+			t.Errorf("QueryActivityHistory = %+v, want newest first", rows)
+		}
+		for _, bad := range []sqlite.InsertActivityHistoryParams{
+			{ActivityID: 1, Action: "rename", Source: "tui", ChangedAt: day},
+			{ActivityID: 1, Action: "update", Source: "web", ChangedAt: day},
+		} {
+			if err := s.InsertActivityHistory(ctx, bad); err == nil {
+				t.Errorf("InsertActivityHistory(%s, %s) succeeded", bad.Action, bad.Source)
+			}
+		}
+	})
+
+	t.Run("clients and projects", func(t *testing.T) {
+		s := open(t)
+		if _, err := s.GetClient(ctx, "Acme"); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("GetClient(missing) error = %v, want sql.ErrNoRows", err)
+		}
+		acme := sql.NullString{String: "Acme", Valid: true}
+		if _, err := s.UpsertProject(ctx, sqlite.UpsertProjectParams{Name: "bolt", Client: acme}); err == nil {
+			t.Error("UpsertProject for a missing client succeeded")
+		}
+		if _, err := s.UpsertClient(ctx, sqlite.UpsertClientParams{Name: "Acme", Currency: "USD"}); err != nil {
+			t.Fatal(err)
+		}
+		c, err := s.UpsertClient(ctx, sqlite.UpsertClientParams{Name: "Acme", Currency: "EUR", HourlyRate: sql.NullInt64{Int64: 9500, Valid: true}})
+		if err != nil || c.Currency != "EUR" {
+			t.Errorf("UpsertClient = %+v, %v", c, err)
+		}
+		if c, err := s.GetClient(ctx, "Acme"); err != nil || c.HourlyRate.Int64 != 9500 {
+			t.Errorf("GetClient = %+v, %v", c, err)
+		}
+		for _, name := range []string{"docs", "bolt"} {
+			if _, err := s.UpsertProject(ctx, sqlite.UpsertProjectParams{Name: name, Client: acme, Billable: true}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := s.UpsertProject(ctx, sqlite.UpsertProjectParams{Name: "internal"}); err != nil {
+			t.Fatal(err)
+		}
+		p, err := s.GetProject(ctx, "bolt")
+		if err != nil || !p.Billable || p.Client != acme {
+			t.Errorf("GetProject = %+v, %v", p, err)
+		}
+		if _, err := s.GetProject(ctx, "none"); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("GetProject(missing) error = %v, want sql.ErrNoRows", err)
+		}
+		projects, err := s.QueryProjectsByClient(ctx, acme)
+		if err != nil || len(projects) != 2 || projects[0].Name != "bolt" {
+			t.Errorf("QueryProjectsByClient = %+v, %v", projects, err)
+		}
+		if projects, _ := s.QueryProjectsByClient(ctx, sql.NullString{}); len(projects) != 0 {
+			t.Errorf("QueryProjectsByClient(null) = %+v, want none", projects)
+		}
+	})
+
+	t.Run("goals", func(t *testing.T) {
+		s := open(t)
+		max := sql.NullInt64{Int64: 1200, Valid: true}
+		for _, g := range []sqlite.UpsertGoalParams{
+			{Project: "bolt", Period: "week", MaxMinutes: max},
+			{Project: "", Period: "day", MinMinutes: sql.NullInt64{Int64: 360, Valid: true}},
+			{Project: "bolt", Period: "week", MaxMinutes: sql.NullInt64{Int64: 600, Valid: true}},
+		} {
+			if _, err := s.UpsertGoal(ctx, g); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := s.UpsertGoal(ctx, sqlite.UpsertGoalParams{Project: "bolt", Period: "year"}); err == nil {
+			t.Error("UpsertGoal with period year succeeded")
+		}
+		goals, err := s.QueryGoals(ctx)
+		if err != nil || len(goals) != 2 || goals[1].MaxMinutes.Int64 != 600 {
+			t.Errorf("QueryGoals = %+v, %v", goals, err)
+		}
+		if err := s.DeleteGoal(ctx, sqlite.DeleteGoalParams{Project: "bolt", Period: "week"}); err != nil {
+			t.Fatal(err)
+		}
+		if goals, _ := s.QueryGoals(ctx); len(goals) != 1 {
+			t.Errorf("QueryGoals after delete = %+v", goals)
+		}
+	})
+
+	t.Run("invoices", func(t *testing.T) {
+		s := open(t)
+		if _, err := s.UpsertClient(ctx, sqlite.UpsertClientParams{Name: "Acme", Currency: "EUR"}); err != nil {
+			t.Fatal(err)
+		}
+		billable := addActivity(t, s, "billable", "bolt", day, time.Hour)
+		if _, err := s.SetActivityBillable(ctx, sqlite.SetActivityBillableParams{ID: billable.ID, Billable: true}); err != nil {
+			t.Fatal(err)
+		}
+		addActivity(t, s, "unbilled", "bolt", day, time.Hour)
+		running := addActivity(t, s, "running", "bolt", day, 0)
+		if _, err := s.SetActivityBillable(ctx, sqlite.SetActivityBillableParams{ID: running.ID, Billable: true}); err != nil {
+			t.Fatal(err)
+		}
+		period := sqlite.QueryBillableActivitiesParams{Project: "bolt", PeriodStart: day, PeriodEnd: day.Add(24 * time.Hour)}
+		found, err := s.QueryBillableActivities(ctx, period)
+		if err != nil || names(found) != "billable" {
+			t.Fatalf("QueryBillableActivities = %s, %v, want billable", names(found), err)
+		}
+
+		seq, err := s.NextInvoiceSequence(ctx, 2026)
+		if err != nil || seq != 1 {
+			t.Fatalf("NextInvoiceSequence = %d, %v, want 1", seq, err)
+		}
+		params := sqlite.InsertInvoiceParams{
+			Number:      "2026-0001",
+			Year:        2026,
+			Sequence:    seq,
+			Client:      "Acme",
+			PeriodStart: day,
+			PeriodEnd:   day.Add(24 * time.Hour),
+			IssuedAt:    day,
+			Currency:    "EUR",
+		}
+		inv, err := s.InsertInvoice(ctx, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.InsertInvoice(ctx, params); err == nil {
+			t.Error("InsertInvoice with a duplicate number succeeded")
+		}
+		params.Number, params.Client = "2026-0002", "Nobody"
+		if _, err := s.InsertInvoice(ctx, params); err == nil {
+			t.Error("InsertInvoice for a missing client succeeded")
+		}
+		if seq, _ := s.NextInvoiceSequence(ctx, 2026); seq != 2 {
+			t.Errorf("NextInvoiceSequence after insert = %d, want 2", seq)
+		}
+		if err := s.InsertInvoiceLine(ctx, sqlite.InsertInvoiceLineParams{InvoiceID: inv.ID, Project: "bolt", Minutes: 60}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.InsertInvoiceLine(ctx, sqlite.InsertInvoiceLineParams{InvoiceID: 999, Project: "bolt"}); err == nil {
+			t.Error("InsertInvoiceLine for a missing invoice succeeded")
+		}
+
+		locked, err := s.SetActivityInvoice(ctx, sqlite.SetActivityInvoiceParams{
+			ID:        billable.ID,
+			InvoiceID: sql.NullInt64{Int64: inv.ID, Valid: true},
+		})
+		if err != nil || locked.InvoiceID.Int64 != inv.ID {
+			t.Fatalf("SetActivityInvoice = %+v, %v", locked, err)
+		}
+		if found, _ := s.QueryBillableActivities(ctx, period); len(found) != 0 {
+			t.Errorf("QueryBillableActivities after invoicing = %s", names(found))
+		}
+		if _, err := s.SetActivityProject(ctx, sqlite.SetActivityProjectParams{ID: billable.ID, Project: "docs"}); err == nil {
+			t.Error("SetActivityProject on an invoiced activity succeeded")
+		}
+		if _, err := s.SetActivityBillable(ctx, sqlite.SetActivityBillableParams{ID: billable.ID}); err == nil {
+			t.Error("SetActivityBillable on an invoiced activity succeeded")
+		}
+		if a, _ := s.QueryActivityByProject(ctx, sqlite.QueryActivityByProjectParams{Project: "bolt", OwnerID: addUser(t, s, "me")}); a.Project != "bolt" || !a.Billable {
+			t.Errorf("invoiced activity changed: %+v", a)
+		}
+	})
+
+	t.Run("encryption", func(t *testing.T) {
+		s := open(t)
+		if _, err := s.GetEncryption(ctx); !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("GetEncryption on a plain store = %v, want sql.ErrNoRows", err)
+		}
+		if err := s.InsertEncryption(ctx, sqlite.InsertEncryptionParams{Kdf: "rot13"}); err == nil {
+			t.Error("InsertEncryption with an unknown kdf succeeded")
+		}
+		if err := s.InsertEncryption(ctx, sqlite.InsertEncryptionParams{Kdf: "argon2id", Salt: "salt", Verifier: "v1"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.UpdateEncryption(ctx, sqlite.UpdateEncryptionParams{Kdf: "keyfile", Verifier: "v2"}); err != nil {
+			t.Fatal(err)
+		}
+		if e, err := s.GetEncryption(ctx); err != nil || e != (sqlite.Encryption{Kdf: "keyfile", Verifier: "v2"}) {
+			t.Errorf("GetEncryption = %+v, %v", e, err)
+		}
+
+		if _, err := s.UpsertClient(ctx, sqlite.UpsertClientParams{Name: "Acme", Currency: "EUR"}); err != nil {
+			t.Fatal(err)
+		}
+		inv, err := s.InsertInvoice(ctx, sqlite.InsertInvoiceParams{Number: "2026-0001", Year: 2026, Sequence: 1, Client: "Acme", Currency: "EUR"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		a := addActivity(t, s, "invoiced", "bolt", day, time.Hour)
+		if _, err := s.SetActivityInvoice(ctx, sqlite.SetActivityInvoiceParams{ID: a.ID, InvoiceID: sql.NullInt64{Int64: inv.ID, Valid: true}}); err != nil {
+			t.Fatal(err)
+		}
+		other, err := s.InsertActivity(ctx, sqlite.InsertActivityParams{StartTime: day, ActivityName: "ownerless", UUID: uuid.NewString()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if all, err := s.QueryAllActivities(ctx); err != nil || names(all) != "invoiced,ownerless" {
+			t.Errorf("QueryAllActivities = %s, %v", names(all), err)
+		}
+
+		text := sqlite.SetActivityTextParams{ID: a.ID, Description: "rekeyed", Notes: "secret"}
+		if err := s.SetActivityText(ctx, text); err == nil {
+			t.Error("SetActivityText on an invoiced activity succeeded")
+		}
+		if err := s.SetEncryptionRekeying(ctx, true); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.SetActivityText(ctx, text); err != nil {
+			t.Errorf("SetActivityText while rekeying: %v", err)
+		}
+		if err := s.SetEncryptionRekeying(ctx, false); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.SetActivityProject(ctx, sqlite.SetActivityProjectParams{ID: a.ID, Project: "docs"}); err == nil {
+			t.Error("SetActivityProject on an invoiced activity succeeded after rekeying")
+		}
+		got, err := s.QueryActivityByUUID(ctx, a.UUID)
+		if err != nil || got.Description != "rekeyed" || got.Notes != "secret" {
+			t.Errorf("after SetActivityText, activity = %+v, %v", got, err)
+		}
+
+		values := sql.NullString{String: `{"notes":"secret"}`, Valid: true}
+		if err := s.InsertActivityHistory(ctx, sqlite.InsertActivityHistoryParams{ActivityID: other.ID, Action: "insert", Source: "cli", ChangedAt: day}); err != nil {
+			t.Fatal(err)
+		}
+		history, err := s.QueryActivityHistory(ctx, other.ID)
+		if err != nil || len(history) != 1 {
+			t.Fatalf("QueryActivityHistory = %+v, %v", history, err)
+		}
+		if err := s.SetActivityHistoryValues(ctx, sqlite.SetActivityHistoryValuesParams{ID: history[0].ID, NewValues: values}); err != nil {
+			t.Fatal(err)
+		}
+		if history, _ := s.QueryActivityHistory(ctx, other.ID); history[0].NewValues != values || history[0].OldValues.Valid {
+			t.Errorf("after SetActivityHistoryValues, history = %+v", history[0])
+		}
+
+		if err := s.InsertSyncConflict(ctx, sqlite.InsertSyncConflictParams{ActivityUUID: a.UUID, Field: "notes", Kept: `"a"`, Other: `"b"`, CreatedAt: day}); err != nil {
+			t.Fatal(err)
+		}
+		conflicts, _ := s.QuerySyncConflicts(ctx)
+		if err := s.SetSyncConflictValues(ctx, sqlite.SetSyncConflictValuesParams{ID: conflicts[0].ID, Kept: `"c"`, Other: `"d"`}); err != nil {
+			t.Fatal(err)
+		}
+		if conflicts, _ := s.QuerySyncConflicts(ctx); conflicts[0].Kept != `"c"` || conflicts[0].Other != `"d"` {
+			t.Errorf("after SetSyncConflictValues, conflict = %+v", conflicts[0])
+		}
+
+		if err := s.DeleteEncryption(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.GetEncryption(ctx); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("GetEncryption after DeleteEncryption = %v, want sql.ErrNoRows", err)
+		}
+	})
+
+	t.Run("summaries", func(t *testing.T) {
+		s := open(t)
+		key := sqlite.GetSummaryParams{PeriodStart: day, PeriodEnd: day.AddDate(0, 0, 7), InputHash: "abc", PromptVersion: 1}
+		if _, err := s.GetSummary(ctx, key); !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("GetSummary before any = %v, want sql.ErrNoRows", err)
+		}
+		upsert := func(hash, content string) sqlite.Summary {
+			t.Helper()
+			sum, err := s.UpsertSummary(ctx, sqlite.UpsertSummaryParams{
+				PeriodStart:   key.PeriodStart,
+				PeriodEnd:     key.PeriodEnd,
+				InputHash:     hash,
+				PromptVersion: key.PromptVersion,
+				Model:         "gpt-4o-mini",
+				Content:       content,
+				CreatedAt:     day,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return sum
+		}
+		first := upsert("abc", "A quiet week.")
+		if again := upsert("abc", "A busy week."); again.ID != first.ID || again.Content != "A busy week." {
+			t.Errorf("UpsertSummary of the same key = %+v, want it replaced", again)
+		}
+		upsert("def", "Another input.")
+		if sum, err := s.GetSummary(ctx, key); err != nil || sum.Content != "A busy week." {
+			t.Errorf("GetSummary = %+v, %v", sum, err)
+		}
+		key.PromptVersion = 2
+		if _, err := s.GetSummary(ctx, key); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("GetSummary of another prompt version = %v, want sql.ErrNoRows", err)
+		}
+		if err := s.SetSummaryContent(ctx, sqlite.SetSummaryContentParams{ID: first.ID, Content: "Rewritten."}); err != nil {
+			t.Fatal(err)
+		}
+		if all, err := s.QueryAllSummaries(ctx); err != nil || len(all) != 2 || all[0].Content != "Rewritten." {
+			t.Errorf("QueryAllSummaries = %+v, %v", all, err)
+		}
+
+		for i, cost := range []sql.NullInt64{{Int64: 90, Valid: true}, {}} {
+			err := s.InsertLLMCall(ctx, sqlite.InsertLLMCallParams{
+				Model:            "gpt-4o-mini",
+				PromptTokens:     100,
+				CompletionTokens: 50,
+				CostMicros:       cost,
+				CreatedAt:        day.Add(-time.Duration(i) * time.Hour),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		calls, err := s.QueryLLMCalls(ctx)
+		if err != nil || len(calls) != 2 || calls[0].CostMicros.Valid || calls[1].CostMicros.Int64 != 90 {
+			t.Errorf("QueryLLMCalls = %+v, %v, want the unpriced call first", calls, err)
+		}
+	})
+
+	t.Run("transactions", func(t *testing.T) {
+		s := open(t)
+		err := s.InTx(ctx, func(tx ActivityStore) error {
+			a := addActivity(t, tx, "kept", "", day, time.Hour)
+			return tx.InTx(ctx, func(tx ActivityStore) error {
+				return tx.AddActivityTag(ctx, sqlite.AddActivityTagParams{ActivityID: a.ID, Tag: "nested"})
+			})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		failed := errors.New("failed")
+		err = s.InTx(ctx, func(tx ActivityStore) error {
+			addActivity(t, tx, "rolled back", "", day, time.Hour)
+			return failed
+		})
+		if !errors.Is(err, failed) {
+			t.Errorf("InTx error = %v, want %v", err, failed)
+		}
+		all, err := s.QueryActivities(ctx, addUser(t, s, "me"))
+		if err != nil || names(all) != "kept" {
+			t.Errorf("QueryActivities = %s, %v, want kept", names(all), err)
+		}
+		if tags, _ := s.QueryAllActivityTags(ctx, addUser(t, s, "me")); len(tags) != 1 {
+			t.Errorf("QueryAllActivityTags = %v, want the nested tag", tags)
+		}
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		s := open(t)
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		if _, err := s.QueryActivities(cancelled, 1); err == nil {
+			t.Error("QueryActivities with a cancelled context succeeded")
+		}
+		if _, err := s.InsertActivity(cancelled, sqlite.InsertActivityParams{StartTime: day}); err == nil {
+			t.Error("InsertActivity with a cancelled context succeeded")
+		}
+		if err := s.InTx(cancelled, func(ActivityStore) error { return nil }); err == nil {
+			t.Error("InTx with a cancelled context succeeded")
+		}
+	})
+}
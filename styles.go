@@ -0,0 +1,134 @@
+package main
+
+import (
+	"github.com/Proqpine/probable-memory/config"
+	"github.com/Proqpine/probable-memory/form"
+	"github.com/Proqpine/probable-memory/theme"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The styles below are set from the active theme by applyTheme, which
+// runs before any view is drawn.
+var (
+	activeTheme theme.Theme
+
+	appStyle       lipgloss.Style
+	titleStyle     lipgloss.Style
+	infoStyle      lipgloss.Style
+	inputStyle     lipgloss.Style
+	continueStyle  lipgloss.Style
+	groupStyle     lipgloss.Style
+	labelStyle     lipgloss.Style
+	hintStyle      lipgloss.Style
+	overStyle      lipgloss.Style
+	errorStyle     lipgloss.Style
+	formTitleStyle lipgloss.Style
+)
+
+// loadTheme resolves the theme named in cfg. The terminal is only asked
+// for its background when the theme depends on it.
+func loadTheme(cfg config.Config) (theme.Theme, error) {
+	return theme.Resolve(cfg.Theme, cfg.Themes, lipgloss.HasDarkBackground)
+}
+
+func applyTheme(t theme.Theme) {
+	activeTheme = t
+
+	appStyle = lipgloss.NewStyle().Padding(1, 2)
+	titleStyle = lipgloss.NewStyle().
+		Foreground(lipgloss.Color(t.OnPrimary)).
+		Background(lipgloss.Color(t.Primary)).
+		Padding(0, 1)
+	infoStyle = func() lipgloss.Style {
+		b := lipgloss.RoundedBorder()
+		b.Left = "┤"
+		return titleStyle.BorderStyle(b)
+	}()
+	inputStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Accent))
+	continueStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Muted))
+	groupStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Primary)).Bold(true).Padding(0, 0, 0, 2)
+	labelStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Text)).Bold(true)
+	hintStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Muted)).Italic(true)
+	overStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Error))
+	errorStyle = overStyle.Bold(true)
+	formTitleStyle = lipgloss.NewStyle().
+		Foreground(lipgloss.Color(t.OnPrimary)).
+		Background(lipgloss.Color(t.Border)).
+		Padding(0, 1).
+		Bold(true)
+}
+
+func listStyles() list.Styles {
+	t := activeTheme
+	s := list.DefaultStyles()
+	s.Title = titleStyle
+	s.Spinner = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Muted))
+	s.FilterPrompt = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Success))
+	s.FilterCursor = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Accent))
+	s.StatusBar = s.StatusBar.Foreground(lipgloss.Color(t.Subtle))
+	s.StatusEmpty = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Muted))
+	s.StatusBarActiveFilter = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Text))
+	s.StatusBarFilterCount = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Muted))
+	s.NoItems = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Muted))
+	s.ArabicPagination = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Muted))
+	s.ActivePaginationDot = s.ActivePaginationDot.Foreground(lipgloss.Color(t.Text))
+	s.InactivePaginationDot = s.InactivePaginationDot.Foreground(lipgloss.Color(t.Muted))
+	s.DividerDot = s.DividerDot.Foreground(lipgloss.Color(t.Muted))
+	return s
+}
+
+func itemStyles() list.DefaultItemStyles {
+	t := activeTheme
+	s := list.NewDefaultItemStyles()
+	s.NormalTitle = s.NormalTitle.Foreground(lipgloss.Color(t.Text))
+	s.NormalDesc = s.NormalDesc.Foreground(lipgloss.Color(t.Subtle))
+	s.SelectedTitle = s.SelectedTitle.Foreground(lipgloss.Color(t.Accent)).BorderForeground(lipgloss.Color(t.Accent))
+	s.SelectedDesc = s.SelectedDesc.Foreground(lipgloss.Color(t.Accent)).BorderForeground(lipgloss.Color(t.Accent))
+	s.DimmedTitle = s.DimmedTitle.Foreground(lipgloss.Color(t.Subtle))
+	s.DimmedDesc = s.DimmedDesc.Foreground(lipgloss.Color(t.Muted))
+	return s
+}
+
+func helpStyles() help.Styles {
+	t := activeTheme
+	key := lipgloss.NewStyle().Foreground(lipgloss.Color(t.Subtle))
+	desc := lipgloss.NewStyle().Foreground(lipgloss.Color(t.Muted))
+	return help.Styles{
+		Ellipsis:       desc,
+		ShortKey:       key,
+		ShortDesc:      desc,
+		ShortSeparator: desc,
+		FullKey:        key,
+		FullDesc:       desc,
+		FullSeparator:  desc,
+	}
+}
+
+func formStyles() form.Styles {
+	t := activeTheme
+	return form.Styles{
+		Title:        formTitleStyle,
+		Label:        labelStyle,
+		FocusedLabel: labelStyle.Foreground(lipgloss.Color(t.Focus)),
+		Error:        overStyle,
+		Hint:         hintStyle,
+		Prompt:       lipgloss.NewStyle().Foreground(lipgloss.Color(t.Border)),
+		Text:         lipgloss.NewStyle().Foreground(lipgloss.Color(t.Text)),
+		Placeholder:  lipgloss.NewStyle().Foreground(lipgloss.Color(t.Muted)),
+		Cursor:       lipgloss.NewStyle().Foreground(lipgloss.Color(t.Accent)),
+	}
+}
+
+// newInput returns a text input drawn in the active theme.
+func newInput() textinput.Model {
+	t := activeTheme
+	in := textinput.New()
+	in.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Accent))
+	in.TextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Text))
+	in.PlaceholderStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Muted))
+	in.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Accent))
+	return in
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Proqpine/probable-memory/config"
+	"github.com/Proqpine/probable-memory/gitlog"
+	"github.com/Proqpine/probable-memory/sqlite"
+	"github.com/Proqpine/probable-memory/store"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+)
+
+// maxFieldLength is the size of the varchar columns in the activities table.
+const maxFieldLength = 255
+
+// expandHome replaces a leading ~/ in path with the home directory.
+func expandHome(path string) (string, error) {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, rest), nil
+}
+
+// suggestCommand proposes activities from git commits in the configured
+// repositories and lets the user review them before they are inserted.
+func suggestCommand(ctx context.Context, q store.ActivityStore, cfg config.Config, args []string) error {
+	now := time.Now()
+	fs := flag.NewFlagSet("suggest", flag.ContinueOnError)
+	from := fs.String("from", now.Format("2006-01-02"), "first day to scan")
+	to := fs.String("to", now.Format("2006-01-02"), "last day to scan")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	start, err := time.ParseInLocation("2006-01-02", *from, now.Location())
+	if err != nil {
+		return fmt.Errorf("invalid --from: %v", err)
+	}
+	last, err := time.ParseInLocation("2006-01-02", *to, now.Location())
+	if err != nil {
+		return fmt.Errorf("invalid --to: %v", err)
+	}
+	if len(cfg.Git.Repositories) == 0 {
+		return fmt.Errorf("no repositories configured: add paths to git.repositories in the config file")
+	}
+
+	var blocks []gitlog.Block
+	for _, repo := range cfg.Git.Repositories {
+		repo, err := expandHome(repo)
+		if err != nil {
+			return err
+		}
+		author := cfg.Git.Author
+		if author == "" {
+			if author, err = gitlog.Author(repo); err != nil {
+				return err
+			}
+		}
+		commits, err := gitlog.Log(repo, author, start, last.AddDate(0, 0, 1))
+		if err != nil {
+			return err
+		}
+		blocks = append(blocks, gitlog.Cluster(commits, cfg.Git.SessionGap.Duration, cfg.Git.LeadTime.Duration)...)
+	}
+	if len(blocks) == 0 {
+		fmt.Println("No commits found")
+		return nil
+	}
+
+	keys, err := loadKeyMap(cfg.Keys)
+	if err != nil {
+		return err
+	}
+	m := newReviewModel(ctx, q, keys, blocks)
+	if _, err := tea.NewProgram(m, tea.WithContext(ctx)).Run(); err != nil {
+		return err
+	}
+	return nil
+}
+
+type suggestion struct {
+	block       gitlog.Block
+	name        string
+	description string
+	project     string
+}
+
+func newSuggestion(b gitlog.Block) suggestion {
+	name := b.Commits[0].Subject
+	if len(b.Commits) > 1 {
+		name = fmt.Sprintf("%s (%d commits)", b.Project(), len(b.Commits))
+	}
+	return suggestion{
+		block:       b,
+		name:        truncateName(name, maxFieldLength),
+		description: truncateName(b.Subjects(), maxFieldLength),
+		project:     b.Project(),
+	}
+}
+
+type reviewModel struct {
+	ctx         context.Context
+	queries     store.ActivityStore
+	keys        keyMap
+	suggestions []suggestion
+	index       int
+	accepted    int
+	rejected    int
+	editing     bool
+	adding      bool
+	inputs      []textinput.Model
+	inputIndex  int
+	err         error
+}
+
+func newReviewModel(ctx context.Context, q store.ActivityStore, keys keyMap, blocks []gitlog.Block) reviewModel {
+	m := reviewModel{ctx: ctx, queries: q, keys: keys, inputs: make([]textinput.Model, 3)}
+	for _, b := range blocks {
+		m.suggestions = append(m.suggestions, newSuggestion(b))
+	}
+	for i := range m.inputs {
+		t := newInput()
+		t.CharLimit = maxFieldLength
+		switch i {
+		case 0:
+			t.Placeholder = "Activity Name"
+		case 1:
+			t.Placeholder = "Description"
+		case 2:
+			t.Placeholder = "Project"
+		}
+		m.inputs[i] = t
+	}
+	return m
+}
+
+func (m reviewModel) Init() tea.Cmd {
+	return nil
+}
+
+type suggestionAddedMsg struct{}
+
+func (m reviewModel) addSuggestion() tea.Msg {
+	s := m.suggestions[m.index]
+	ctx, cancel := context.WithTimeout(withSource(m.ctx, sourceImport), dbTimeout)
+	defer cancel()
+	billable, err := projectBillable(ctx, m.queries, s.project)
+	if err != nil {
+		return errorMsg{err}
+	}
+	err = m.queries.InTx(ctx, func(tx store.ActivityStore) error {
+		a, err := tx.InsertActivity(ctx, sqlite.InsertActivityParams{
+			StartTime:    s.block.Start,
+			EndTime:      sql.NullTime{Time: s.block.End, Valid: true},
+			Duration:     sql.NullInt64{Int64: int64(s.block.Duration().Seconds()), Valid: true},
+			ActivityName: s.name,
+			Description:  s.description,
+			Project:      s.project,
+			Notes:        fmt.Sprintf("From %d commits in %s", len(s.block.Commits), s.block.Repo),
+			Billable:     billable,
+			OwnerID:      ownerFrom(ctx),
+			UUID:         uuid.NewString(),
+		})
+		if err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, nil, &a)
+	})
+	if err != nil {
+		return errorMsg{fmt.Errorf("failed to add activity: %v", err)}
+	}
+	return suggestionAddedMsg{}
+}
+
+func (m reviewModel) done() bool {
+	return m.index >= len(m.suggestions)
+}
+
+func (m reviewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case suggestionAddedMsg:
+		m.adding = false
+		m.accepted++
+		m.index++
+		return m, nil
+
+	case errorMsg:
+		m.adding = false
+		m.err = msg.error
+		return m, nil
+
+	case tea.KeyMsg:
+		if key.Matches(msg, m.keys.forceQuit) {
+			return m, tea.Quit
+		}
+		if m.adding {
+			// Keys pressed before the insert finishes would act on the
+			// same suggestion again.
+			return m, nil
+		}
+		if m.editing {
+			return m.updateEditing(msg)
+		}
+		if m.done() {
+			if key.Matches(msg, m.keys.back, m.keys.accept) {
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+		m.err = nil
+		switch {
+		case key.Matches(msg, m.keys.accept):
+			m.adding = true
+			return m, m.addSuggestion
+		case key.Matches(msg, m.keys.reject):
+			m.rejected++
+			m.index++
+		case key.Matches(msg, m.keys.editSuggestion):
+			s := m.suggestions[m.index]
+			m.inputs[0].SetValue(s.name)
+			m.inputs[1].SetValue(s.description)
+			m.inputs[2].SetValue(s.project)
+			m.editing = true
+			m.inputIndex = 0
+			m.inputs[0].Focus()
+		case key.Matches(msg, m.keys.back):
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m reviewModel) updateEditing(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.formCancel):
+		m.editing = false
+		return m, nil
+	case key.Matches(msg, m.keys.formSubmit):
+		s := &m.suggestions[m.index]
+		s.name = m.inputs[0].Value()
+		s.description = m.inputs[1].Value()
+		s.project = m.inputs[2].Value()
+		m.editing = false
+		m.adding = true
+		return m, m.addSuggestion
+	case key.Matches(msg, m.keys.formNext):
+		m.inputs[m.inputIndex].Blur()
+		m.inputIndex = (m.inputIndex + 1) % len(m.inputs)
+		m.inputs[m.inputIndex].Focus()
+		return m, nil
+	case key.Matches(msg, m.keys.formPrev):
+		m.inputs[m.inputIndex].Blur()
+		m.inputIndex = (m.inputIndex + len(m.inputs) - 1) % len(m.inputs)
+		m.inputs[m.inputIndex].Focus()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.inputs[m.inputIndex], cmd = m.inputs[m.inputIndex].Update(msg)
+	return m, cmd
+}
+
+func (m reviewModel) View() string {
+	var b strings.Builder
+	if m.done() {
+		b.WriteString(titleStyle.Render("Review complete") + "\n\n")
+		fmt.Fprintf(&b, "Added %d activities, rejected %d.\n\n", m.accepted, m.rejected)
+		b.WriteString(continueStyle.Render(helpLine(withDesc(m.keys.back, "quit"))))
+		return appStyle.Render(b.String())
+	}
+
+	s := m.suggestions[m.index]
+	title := fmt.Sprintf("Suggestion %d of %d", m.index+1, len(m.suggestions))
+	b.WriteString(titleStyle.Render(title) + "\n\n")
+	fmt.Fprintf(&b, "%s – %s (%s)\n\n",
+		s.block.Start.Format("Mon 2006-01-02 15:04"), s.block.End.Format("15:04"),
+		s.block.Duration().Truncate(time.Minute))
+
+	if m.editing {
+		labels := []string{"Activity Name", "Description", "Project"}
+		for i := range m.inputs {
+			b.WriteString(labelStyle.Render(labels[i]) + "\n")
+			b.WriteString(m.inputs[i].View() + "\n\n")
+		}
+		b.WriteString(continueStyle.Render(helpLine(m.keys.formNext, m.keys.formPrev, withDesc(m.keys.formSubmit, "save and accept"), m.keys.formCancel)))
+		return appStyle.Render(b.String())
+	}
+
+	fmt.Fprintf(&b, "Name:        %s\n", s.name)
+	fmt.Fprintf(&b, "Project:     %s\n", s.project)
+	fmt.Fprintf(&b, "Description: %s\n\n", s.description)
+	for _, c := range s.block.Commits {
+		fmt.Fprintf(&b, "  %s %s %s\n", c.Time.Format("15:04"), c.Hash[:7], c.Subject)
+	}
+	b.WriteString("\n")
+	if m.err != nil {
+		b.WriteString(fmt.Sprintf("Error: %v\n\n", m.err))
+	}
+	b.WriteString(continueStyle.Render(helpLine(m.keys.accept, m.keys.editSuggestion, m.keys.reject, withDesc(m.keys.back, "quit"))))
+	return appStyle.Render(b.String())
+}
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Proqpine/probable-memory/config"
+	"github.com/Proqpine/probable-memory/goals"
+	"github.com/Proqpine/probable-memory/sqlite"
+	"github.com/Proqpine/probable-memory/src"
+	"github.com/Proqpine/probable-memory/store"
+	"github.com/Proqpine/probable-memory/timesheet"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// summarizer writes a summary of activities listed one per line, and
+// returns the tokens it took, even when it fails after using some.
+type summarizer interface {
+	Summarise(ctx context.Context, input string) (string, src.Usage, error)
+}
+
+// weekSummary is a summary of a week, either reused or just written.
+type weekSummary struct {
+	Content string
+	Cached  bool
+	Model   string
+	Usage   src.Usage
+	// Cost is in millionths of a dollar, unknown for models without a
+	// price.
+	Cost sql.NullInt64
+}
+
+// summaryInput lists the activities for the model, one per line. Running
+// activities have no duration yet, so that the input, and with it the
+// cached summary, only changes when an activity does.
+func summaryInput(ctx context.Context, q store.ActivityStore, activities []sqlite.Activity) (string, error) {
+	var b strings.Builder
+	for _, a := range activities {
+		took := "running"
+		if a.EndTime.Valid {
+			d, err := activityDuration(ctx, q, a, a.EndTime.Time)
+			if err != nil {
+				return "", err
+			}
+			took = goals.FormatHours(d)
+		}
+		fmt.Fprintf(&b, "%s %s [%s] %s", a.StartTime.Format("Mon 2006-01-02"), took, a.Project, a.ActivityName)
+		if a.Description != "" {
+			fmt.Fprintf(&b, " - %s", a.Description)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+func inputHash(input string) string {
+	sum := sha256.Sum256([]byte(input))
+	return hex.EncodeToString(sum[:])
+}
+
+// modelPrice is the price of model from the config, or else the built-in
+// one.
+func modelPrice(cfg config.Config, model string) (src.Price, bool) {
+	if p, ok := cfg.Summary.Prices[model]; ok {
+		return src.Price{Prompt: p.Prompt, Completion: p.Completion}, true
+	}
+	p, ok := src.Prices[model]
+	return p, ok
+}
+
+// summariseWeek returns the summary of the user's activities in the week
+// starting at start. A summary written before from the same activities
+// and prompt is reused unless refresh is set. Every call to the model is
+// recorded with its tokens and estimated cost.
+func summariseWeek(ctx context.Context, q store.ActivityStore, s summarizer, cfg config.Config, start time.Time, refresh bool, now time.Time) (weekSummary, error) {
+	end := start.AddDate(0, 0, 7)
+	activities, err := q.QueryActivitiesBetween(ctx, sqlite.QueryActivitiesBetweenParams{
+		PeriodStart: start,
+		PeriodEnd:   end,
+		OwnerID:     userFrom(ctx),
+	})
+	if err != nil {
+		return weekSummary{}, err
+	}
+	if len(activities) == 0 {
+		return weekSummary{}, fmt.Errorf("no activities in the week of %s", start.Format("2006-01-02"))
+	}
+	input, err := summaryInput(ctx, q, activities)
+	if err != nil {
+		return weekSummary{}, err
+	}
+	key := sqlite.GetSummaryParams{
+		PeriodStart:   start,
+		PeriodEnd:     end,
+		InputHash:     inputHash(input),
+		PromptVersion: src.PromptVersion,
+	}
+	if !refresh {
+		cached, err := q.GetSummary(ctx, key)
+		if err == nil {
+			return weekSummary{Content: cached.Content, Cached: true, Model: cached.Model}, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return weekSummary{}, err
+		}
+	}
+
+	content, usage, err := s.Summarise(ctx, input)
+	sum := weekSummary{Content: content, Model: cfg.Summary.Model, Usage: usage}
+	if price, ok := modelPrice(cfg, sum.Model); ok {
+		sum.Cost = sql.NullInt64{Int64: price.Cost(usage), Valid: true}
+	}
+	// The call is recorded even if it failed or was stopped, since the
+	// tokens it used are paid for all the same. The usage is estimated
+	// once the response has begun, so it is zero only when the request
+	// was refused. Stopping cancels ctx, which must not stop the record.
+	if err != nil {
+		if usage.TotalTokens > 0 {
+			if rerr := recordCall(context.WithoutCancel(ctx), q, sum, now); rerr != nil {
+				return weekSummary{}, errors.Join(err, rerr)
+			}
+		}
+		return weekSummary{}, err
+	}
+	err = q.InTx(ctx, func(tx store.ActivityStore) error {
+		if err := recordCall(ctx, tx, sum, now); err != nil {
+			return err
+		}
+		_, err := tx.UpsertSummary(ctx, sqlite.UpsertSummaryParams{
+			PeriodStart:   key.PeriodStart,
+			PeriodEnd:     key.PeriodEnd,
+			InputHash:     key.InputHash,
+			PromptVersion: key.PromptVersion,
+			Model:         sum.Model,
+			Content:       content,
+			CreatedAt:     now,
+		})
+		return err
+	})
+	return sum, err
+}
+
+func recordCall(ctx context.Context, q store.ActivityStore, sum weekSummary, now time.Time) error {
+	return q.InsertLLMCall(ctx, sqlite.InsertLLMCallParams{
+		Model:            sum.Model,
+		PromptTokens:     int64(sum.Usage.PromptTokens),
+		CompletionTokens: int64(sum.Usage.CompletionTokens),
+		CostMicros:       sum.Cost,
+		CreatedAt:        now,
+	})
+}
+
+// monthSpend is what the calls of one month used and cost.
+type monthSpend struct {
+	Month                   string
+	Calls                   int
+	PromptTokens            int64
+	CompletionTokens        int64
+	CostMicros              int64
+	UnpricedCalls           int
+	CumulativeCostMicros    int64
+	CumulativeUnpricedCalls int
+}
+
+// spendByMonth totals calls, which are in order, by month of the local
+// calendar, with the running total since the first.
+func spendByMonth(calls []sqlite.LLMCall) []monthSpend {
+	var months []monthSpend
+	for _, c := range calls {
+		month := c.CreatedAt.Local().Format("2006-01")
+		if len(months) == 0 || months[len(months)-1].Month != month {
+			m := monthSpend{Month: month}
+			if len(months) > 0 {
+				prev := months[len(months)-1]
+				m.CumulativeCostMicros = prev.CumulativeCostMicros
+				m.CumulativeUnpricedCalls = prev.CumulativeUnpricedCalls
+			}
+			months = append(months, m)
+		}
+		m := &months[len(months)-1]
+		m.Calls++
+		m.PromptTokens += c.PromptTokens
+		m.CompletionTokens += c.CompletionTokens
+		if c.CostMicros.Valid {
+			m.CostMicros += c.CostMicros.Int64
+			m.CumulativeCostMicros += c.CostMicros.Int64
+		} else {
+			m.UnpricedCalls++
+			m.CumulativeUnpricedCalls++
+		}
+	}
+	return months
+}
+
+// formatCost renders millionths of a dollar, to the micro-dollar under a
+// cent, marking totals that leave out calls to models without a price.
+func formatCost(micros int64, unpriced int) string {
+	s := fmt.Sprintf("$%.4f", float64(micros)/1e6)
+	if micros < 10000 {
+		s = fmt.Sprintf("$%.6f", float64(micros)/1e6)
+	}
+	if unpriced > 0 {
+		s += "+"
+	}
+	return s
+}
+
+func summaryCommand(ctx context.Context, q store.ActivityStore, cfg config.Config, args []string) error {
+	if len(args) > 0 && args[0] == "spend" {
+		return spendCommand(ctx, q)
+	}
+	now := time.Now()
+	fs := flag.NewFlagSet("summary", flag.ContinueOnError)
+	week := fs.String("week", now.Format("2006-01-02"), "any day in the week to summarise")
+	refresh := fs.Bool("refresh", false, "write a new summary even if one is saved")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	day, err := time.ParseInLocation("2006-01-02", *week, now.Location())
+	if err != nil {
+		return fmt.Errorf("invalid --week: %v", err)
+	}
+	// The summary is printed as it is written, or at once when it is
+	// saved.
+	streamed := false
+	s := streamSummarizer{
+		client: src.NewClient(cfg.Summary.URL, cfg.Summary.Model),
+		onToken: func(token string) {
+			streamed = true
+			fmt.Print(token)
+		},
+	}
+	sum, err := summariseWeek(ctx, q, s, cfg, timesheet.WeekStart(day), *refresh, now)
+	if streamed {
+		fmt.Println()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to summarise: %v", err)
+	}
+	if !streamed {
+		fmt.Println(strings.TrimSpace(sum.Content))
+	}
+	fmt.Println()
+	if sum.Cached {
+		fmt.Printf("Saved summary by %s; --refresh writes a new one\n", sum.Model)
+		return nil
+	}
+	cost := "cost unknown"
+	if sum.Cost.Valid {
+		cost = "about " + formatCost(sum.Cost.Int64, 0)
+	}
+	fmt.Printf("%s: %s%d prompt and %d completion tokens, %s\n", sum.Model, approx(sum.Usage), sum.Usage.PromptTokens, sum.Usage.CompletionTokens, cost)
+	return nil
+}
+
+// approx is "about " when usage is estimated, to go before its tokens.
+func approx(usage src.Usage) string {
+	if usage.Estimated {
+		return "about "
+	}
+	return ""
+}
+
+func spendCommand(ctx context.Context, q store.ActivityStore) error {
+	calls, err := q.QueryLLMCalls(ctx)
+	if err != nil {
+		return err
+	}
+	months := spendByMonth(calls)
+	if len(months) == 0 {
+		fmt.Println("No calls to a language model yet")
+		return nil
+	}
+	fmt.Printf("%-7s %6s %10s %10s %10s %10s\n", "MONTH", "CALLS", "PROMPT", "COMPLETION", "COST", "TOTAL")
+	for _, m := range months {
+		fmt.Printf("%-7s %6d %10d %10d %10s %10s\n", m.Month, m.Calls, m.PromptTokens, m.CompletionTokens,
+			formatCost(m.CostMicros, m.UnpricedCalls), formatCost(m.CumulativeCostMicros, m.CumulativeUnpricedCalls))
+	}
+	if months[len(months)-1].CumulativeUnpricedCalls > 0 {
+		fmt.Println("+ leaves out calls to models without a price; add them under summary.prices in the config")
+	}
+	return nil
+}
+
+// streamSummarizer passes the summary to onToken as it is written.
+type streamSummarizer struct {
+	client  src.Client
+	onToken func(string)
+}
+
+func (s streamSummarizer) Summarise(ctx context.Context, input string) (string, src.Usage, error) {
+	return s.client.SummariseStream(ctx, input, s.onToken)
+}
+
+// summaryTokenMsg is the next piece of the summary being written by run.
+type summaryTokenMsg struct {
+	run   int
+	token string
+}
+
+type summaryDoneMsg struct {
+	run     int
+	summary weekSummary
+	err     error
+}
+
+// generateSummary summarises the week in the summary pane, replacing any
+// summary still being written. The tokens and the result are sent on one
+// channel, so that they arrive in order, and are read one at a time by
+// waitForSummary.
+func (m model) generateSummary(refresh bool) (model, tea.Cmd) {
+	m.stopSummary()
+	ctx, cancel := context.WithCancel(m.ctx)
+	events := make(chan tea.Msg)
+	m.summaryRun++
+	m.summaryEvents = events
+	m.cancelSummary = cancel
+	m.IsGeneratingSummary = true
+	m.WeeklyProgressSummary = ""
+	m.summaryStatus = ""
+
+	run, st, cfg, week := m.summaryRun, m.Store, m.Config, m.summaryWeek
+	// Once the summary is stopped nothing reads the channel, so sends
+	// give up when ctx is done.
+	send := func(msg tea.Msg) {
+		select {
+		case events <- msg:
+		case <-ctx.Done():
+		}
+	}
+	generate := func() tea.Msg {
+		defer close(events)
+		s := streamSummarizer{
+			client:  src.NewClient(cfg.Summary.URL, cfg.Summary.Model),
+			onToken: func(token string) { send(summaryTokenMsg{run: run, token: token}) },
+		}
+		sum, err := summariseWeek(ctx, st, s, cfg, week, refresh, time.Now())
+		send(summaryDoneMsg{run: run, summary: sum, err: err})
+		return nil
+	}
+	return m, tea.Batch(generate, waitForSummary(events))
+}
+
+// waitForSummary returns the next message about the summary being written,
+// or nil once it is stopped.
+func waitForSummary(events <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-events
+	}
+}
+
+// stopSummary cancels the summary being written, keeping what has arrived.
+func (m *model) stopSummary() {
+	if m.cancelSummary != nil {
+		m.cancelSummary()
+		m.cancelSummary = nil
+	}
+	if m.IsGeneratingSummary {
+		m.IsGeneratingSummary = false
+		m.summaryStatus = "Stopped"
+	}
+}
+
+func (m model) summaryDone(msg summaryDoneMsg) (tea.Model, tea.Cmd) {
+	if msg.run != m.summaryRun || !m.IsGeneratingSummary {
+		return m, nil
+	}
+	m.IsGeneratingSummary = false
+	m.cancelSummary()
+	m.cancelSummary = nil
+	if msg.err != nil {
+		m.summaryStatus = "Failed"
+		return m, m.showError(fmt.Errorf("failed to summarise: %v", msg.err), nil)
+	}
+	sum := msg.summary
+	m.WeeklyProgressSummary = sum.Content
+	switch {
+	case sum.Cached:
+		m.summaryStatus = "Saved summary by " + sum.Model
+	case sum.Cost.Valid:
+		m.summaryStatus = fmt.Sprintf("%s: %s%d tokens, about %s", sum.Model, approx(sum.Usage), sum.Usage.TotalTokens, formatCost(sum.Cost.Int64, 0))
+	default:
+		m.summaryStatus = fmt.Sprintf("%s: %s%d tokens", sum.Model, approx(sum.Usage), sum.Usage.TotalTokens)
+	}
+	return m, nil
+}
+
+func (m model) updateSummary(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.back):
+		// back first stops a summary being written, keeping what has
+		// arrived of it.
+		if m.IsGeneratingSummary {
+			m.stopSummary()
+			return m, nil
+		}
+		m.viewingSummary = false
+		return m, nil
+	case key.Matches(msg, m.keys.prevWeek):
+		m.summaryWeek = m.summaryWeek.AddDate(0, 0, -7)
+		return m.generateSummary(false)
+	case key.Matches(msg, m.keys.nextWeek):
+		m.summaryWeek = m.summaryWeek.AddDate(0, 0, 7)
+		return m.generateSummary(false)
+	case key.Matches(msg, m.keys.regenerate):
+		return m.generateSummary(true)
+	}
+	return m, nil
+}
+
+func (m model) summaryView() string {
+	h, _ := appStyle.GetFrameSize()
+	body := strings.TrimSpace(m.WeeklyProgressSummary)
+	help := helpLine(m.keys.prevWeek, m.keys.nextWeek, m.keys.regenerate, m.keys.back)
+	if m.IsGeneratingSummary {
+		body = m.WeeklyProgressSummary + "▍"
+		if m.WeeklyProgressSummary == "" {
+			body = "Summarising…"
+		}
+		help = helpLine(withDesc(m.keys.back, "stop"))
+	}
+	if m.summaryStatus != "" {
+		help = m.summaryStatus + " • " + help
+	}
+	return appStyle.Render(fmt.Sprintf("%s\n\n%s\n\n%s",
+		titleStyle.Render("Week of "+m.summaryWeek.Format("Mon 2 Jan 2006")),
+		lipgloss.NewStyle().Width(max(20, m.width-h)).Render(body),
+		continueStyle.Render(help)))
+}
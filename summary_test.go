@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Proqpine/probable-memory/config"
+	"github.com/Proqpine/probable-memory/src"
+	"github.com/Proqpine/probable-memory/store"
+	"github.com/Proqpine/probable-memory/timesheet"
+)
+
+// fakeSummarizer counts calls and summarises each input by its line count.
+type fakeSummarizer struct {
+	calls int
+	err   error
+}
+
+func (f *fakeSummarizer) Summarise(ctx context.Context, input string) (string, src.Usage, error) {
+	f.calls++
+	usage := src.Usage{PromptTokens: 1000, CompletionTokens: 200, TotalTokens: 1200}
+	if f.err != nil {
+		return "", usage, f.err
+	}
+	return strings.Repeat("x", strings.Count(input, "\n")), usage, nil
+}
+
+func TestSummariseWeek(t *testing.T) {
+	st := store.NewMemory()
+	cfg := config.Default()
+	cfg.User = "me"
+	cfg.Summary.Model = "gpt-4o-mini"
+	ctx, err := currentUser(context.Background(), st, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	monday := time.Date(2026, 10, 19, 9, 0, 0, 0, time.Local)
+	week := timesheet.WeekStart(monday)
+	a, err := startActivity(ctx, st, "Review", "pull requests", "core", "", monday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stopActivity(ctx, st, a, monday.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &fakeSummarizer{}
+	summarise := func(refresh bool) weekSummary {
+		t.Helper()
+		sum, err := summariseWeek(ctx, st, s, cfg, week, refresh, monday.Add(2*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sum
+	}
+	if sum := summarise(false); sum.Cached || sum.Content != "x" || !sum.Cost.Valid || sum.Cost.Int64 != 270 {
+		t.Errorf("first summary %+v, want a new one costing 270 micro-dollars", sum)
+	}
+	if sum := summarise(false); !sum.Cached || sum.Content != "x" || s.calls != 1 {
+		t.Errorf("second summary %+v after %d calls, want the saved one", sum, s.calls)
+	}
+	if sum := summarise(true); sum.Cached || s.calls != 2 {
+		t.Errorf("refreshed summary %+v after %d calls, want a new one", sum, s.calls)
+	}
+	if _, err := startActivity(ctx, st, "Deploy", "", "core", "", monday.Add(3*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if sum := summarise(false); sum.Cached || sum.Content != "xx" {
+		t.Errorf("summary after a new activity %+v, want a new one", sum)
+	}
+
+	// A failed call that used tokens is recorded, and a model without a
+	// price leaves the cost unknown.
+	s.err = errors.New("rate limited")
+	cfg.Summary.Model = "local"
+	if _, err := summariseWeek(ctx, st, s, cfg, week, true, monday.AddDate(0, 1, 0)); err == nil {
+		t.Error("a failed call succeeded")
+	}
+
+	calls, err := st.QueryLLMCalls(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	months := spendByMonth(calls)
+	if len(months) != 2 {
+		t.Fatalf("spend by month %+v, want two months", months)
+	}
+	if m := months[0]; m.Calls != 3 || m.PromptTokens != 3000 || m.CostMicros != 810 || m.UnpricedCalls != 0 {
+		t.Errorf("first month %+v", m)
+	}
+	if m := months[1]; m.Calls != 1 || m.CumulativeCostMicros != 810 || m.CumulativeUnpricedCalls != 1 {
+		t.Errorf("second month %+v", m)
+	}
+	if got := formatCost(months[1].CumulativeCostMicros, months[1].CumulativeUnpricedCalls); got != "$0.000810+" {
+		t.Errorf("formatCost = %q", got)
+	}
+}
+
+func TestSummaryPane(t *testing.T) {
+	st := store.NewMemory()
+	var stall atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, token := range []string{"Reviewed ", "pull requests."} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", token)
+			w.(http.Flusher).Flush()
+			if stall.Load() {
+				<-r.Context().Done()
+				return
+			}
+		}
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":40,\"completion_tokens\":4,\"total_tokens\":44}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	h := newHarness(t, st, 80, 24)
+	m := h.m.(model)
+	m.Config.Summary.URL = srv.URL
+	h.m = m
+	now := time.Now()
+	a, err := startActivity(m.ctx, st, "Review", "pull requests", "core", "", now.Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stopActivity(m.ctx, st, a, now); err != nil {
+		t.Fatal(err)
+	}
+
+	h.press("W")
+	if view := h.m.View(); !strings.Contains(view, "Reviewed pull requests.") || !strings.Contains(view, "44 tokens") {
+		t.Errorf("streamed summary:\n%s", view)
+	}
+	calls, err := st.QueryLLMCalls(m.ctx)
+	if err != nil || len(calls) != 1 || calls[0].PromptTokens != 40 {
+		t.Errorf("recorded calls %+v, %v, want one of 40 prompt tokens", calls, err)
+	}
+
+	// esc stops a summary being written and keeps what has arrived.
+	stall.Store(true)
+	h.press("r")
+	if view := h.m.View(); !strings.Contains(view, "Reviewed ▍") || !strings.Contains(view, "esc: stop") {
+		t.Errorf("summary being written:\n%s", view)
+	}
+	h.press("esc")
+	if view := h.m.View(); !strings.Contains(view, "Stopped") || strings.Contains(view, "pull requests.") {
+		t.Errorf("stopped summary:\n%s", view)
+	}
+	// The stopped call is recorded with its tokens estimated, once the
+	// stream has given up.
+	deadline := time.Now().Add(time.Second)
+	for {
+		calls, err = st.QueryLLMCalls(m.ctx)
+		if err != nil || len(calls) == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil || len(calls) != 2 || calls[1].PromptTokens == 0 || calls[1].CompletionTokens != 3 {
+		t.Errorf("recorded calls %+v, %v, want the stopped one estimated", calls, err)
+	}
+	h.press("q")
+	if h.m.(model).viewingSummary {
+		t.Error("q did not close the summary")
+	}
+}
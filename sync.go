@@ -0,0 +1,862 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Proqpine/probable-memory/config"
+	"github.com/Proqpine/probable-memory/fieldcrypt"
+	"github.com/Proqpine/probable-memory/sqlite"
+	"github.com/Proqpine/probable-memory/store"
+	"github.com/Proqpine/probable-memory/syncdir"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+)
+
+// syncFields are the fields of an activity that sync between devices, as
+// they are named in the change logs. Tags, time segments, invoices and
+// owners stay on each device.
+var syncFields = []string{"name", "description", "project", "notes", "start", "end", "duration", "billable"}
+
+// sealedFields are written to the change logs encrypted with the database's
+// key, when it has one.
+var sealedFields = []string{"description", "notes"}
+
+// deletedField marks an activity deleted. Deleting is final: changes made
+// elsewhere to a deleted activity are dropped.
+const deletedField = "deleted"
+
+// field points at the snapshot field with the given name.
+func (s *activitySnapshot) field(name string) any {
+	switch name {
+	case "name":
+		return &s.ActivityName
+	case "description":
+		return &s.Description
+	case "project":
+		return &s.Project
+	case "notes":
+		return &s.Notes
+	case "start":
+		return &s.StartTime
+	case "end":
+		return &s.EndTime
+	case "duration":
+		return &s.Duration
+	case "billable":
+		return &s.Billable
+	}
+	return nil
+}
+
+// fieldJSON is the value of a field as written to a change log. Times are
+// in UTC, so that equal values have equal JSON.
+func (s activitySnapshot) fieldJSON(name string) (json.RawMessage, error) {
+	s.StartTime = s.StartTime.UTC()
+	if s.EndTime != nil {
+		end := s.EndTime.UTC()
+		s.EndTime = &end
+	}
+	return json.Marshal(s.field(name))
+}
+
+func (s *activitySnapshot) setField(name string, value json.RawMessage) error {
+	f := s.field(name)
+	if f == nil {
+		return fmt.Errorf("unknown field %q", name)
+	}
+	return json.Unmarshal(value, f)
+}
+
+// syncEntries lists the fields changed between two snapshots of an
+// activity, as change log entries. old is nil for inserts and next is nil
+// for deletes.
+func syncEntries(old, next *activitySnapshot, at time.Time) ([]syncdir.Entry, error) {
+	if next == nil {
+		if old == nil || old.UUID == "" {
+			return nil, nil
+		}
+		return []syncdir.Entry{{Activity: old.UUID, Field: deletedField, Value: json.RawMessage("true"), At: at}}, nil
+	}
+	if next.UUID == "" {
+		// Recorded before activities had UUIDs.
+		return nil, nil
+	}
+	var entries []syncdir.Entry
+	for _, f := range syncFields {
+		value, err := next.fieldJSON(f)
+		if err != nil {
+			return nil, err
+		}
+		if old != nil {
+			was, err := old.fieldJSON(f)
+			if err != nil {
+				return nil, err
+			}
+			if bytes.Equal(was, value) {
+				continue
+			}
+		}
+		entries = append(entries, syncdir.Entry{Activity: next.UUID, Field: f, Value: value, At: at})
+	}
+	return entries, nil
+}
+
+// logCipher returns the cipher of an encrypted store, or nil.
+func logCipher(q store.ActivityStore) store.Cipher {
+	if e, ok := q.(*store.Encrypted); ok {
+		return e.Cipher()
+	}
+	return nil
+}
+
+// sealEntry encrypts the value of e if it is a sealed field. A nil cipher
+// leaves it as it is.
+func sealEntry(e *syncdir.Entry, c store.Cipher) error {
+	if c == nil {
+		return nil
+	}
+	return mapSealed(e, c.Encrypt)
+}
+
+// openEntry decrypts the value of e if it is a sealed field. Encrypted
+// values need the key they were written with, so devices syncing an
+// encrypted database share one.
+func openEntry(e *syncdir.Entry, c store.Cipher) error {
+	err := mapSealed(e, func(s string) (string, error) {
+		if c == nil {
+			if fieldcrypt.IsEncrypted(s) {
+				return "", errors.New("encrypted, but this database is not")
+			}
+			return s, nil
+		}
+		return c.Decrypt(s)
+	})
+	if err != nil {
+		return fmt.Errorf("change %s/%d: %v", e.Device, e.Seq, err)
+	}
+	return nil
+}
+
+func mapSealed(e *syncdir.Entry, fn func(string) (string, error)) error {
+	if !slices.Contains(sealedFields, e.Field) {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(e.Value, &s); err != nil {
+		return err
+	}
+	s, err := fn(s)
+	if err != nil {
+		return err
+	}
+	e.Value, err = json.Marshal(s)
+	return err
+}
+
+// syncResult counts what a sync did.
+type syncResult struct {
+	sent, received, conflicts int
+}
+
+func (r syncResult) String() string {
+	s := fmt.Sprintf("Synced: sent %s, received %s", plural(r.sent, "change"), plural(r.received, "activity"))
+	if r.conflicts > 0 {
+		s += ", " + plural(r.conflicts, "conflict")
+	}
+	return s
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	if strings.HasSuffix(noun, "y") {
+		return fmt.Sprintf("%d %sies", n, strings.TrimSuffix(noun, "y"))
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// notExported is the exported history ID of a device whose first sync has
+// not completed.
+const notExported = -1
+
+// syncDevice returns this device's sync state, naming the device on its
+// first sync. The name is committed on its own, before anything is written
+// to the log under it, so a sync that fails after writing carries on under
+// the same name.
+func syncDevice(ctx context.Context, q store.ActivityStore) (sqlite.SyncLocal, error) {
+	var local sqlite.SyncLocal
+	err := q.InTx(ctx, func(tx store.ActivityStore) error {
+		var err error
+		local, err = tx.GetSyncLocal(ctx)
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		local = sqlite.SyncLocal{Device: uuid.NewString(), ExportedHistoryID: notExported}
+		return tx.InsertSyncLocal(ctx, sqlite.InsertSyncLocalParams(local))
+	})
+	return local, err
+}
+
+// syncActivities writes the changes made since the last sync to this
+// device's log in dir, then applies the changes in the other devices'
+// logs. Each field takes the value of its latest change; changes made
+// without knowing of each other are also kept as conflicts for the user to
+// settle.
+//
+// If the transaction fails after the log was written, the same changes are
+// written again next time. Applying a change twice does nothing.
+func syncActivities(ctx context.Context, q store.ActivityStore, dir syncdir.Dir) (syncResult, error) {
+	ctx = withSource(ctx, sourceSync)
+	local, err := syncDevice(ctx, q)
+	if err != nil {
+		return syncResult{}, err
+	}
+	first := local.ExportedHistoryID == notExported
+	c := logCipher(q)
+	var res syncResult
+	err = q.InTx(ctx, func(tx store.ActivityStore) error {
+		res = syncResult{}
+		devices, err := tx.QuerySyncDevices(ctx)
+		if err != nil {
+			return err
+		}
+		seen := make(map[string]int64, len(devices))
+		for _, d := range devices {
+			seen[d.Device] = d.Applied
+		}
+
+		if first {
+			if err := adoptUUIDs(ctx, tx, dir, local.Device); err != nil {
+				return err
+			}
+		}
+		entries, err := localEntries(ctx, tx, local, first)
+		if err != nil {
+			return err
+		}
+		for i := range entries {
+			entries[i].Seen = seen
+			if err := sealEntry(&entries[i], c); err != nil {
+				return err
+			}
+		}
+		written, err := dir.Append(local.Device, entries)
+		if err != nil {
+			return err
+		}
+		for _, e := range written {
+			if err := setClock(ctx, tx, e); err != nil {
+				return err
+			}
+		}
+		res.sent = len(written)
+
+		others, err := dir.Devices()
+		if err != nil {
+			return err
+		}
+		for _, device := range others {
+			if device == local.Device {
+				continue
+			}
+			remote, err := dir.Read(device, seen[device])
+			if err != nil {
+				return err
+			}
+			if len(remote) == 0 {
+				continue
+			}
+			for i := range remote {
+				if err := openEntry(&remote[i], c); err != nil {
+					return err
+				}
+			}
+			if err := applyEntries(ctx, tx, remote, &res); err != nil {
+				return err
+			}
+			err = tx.SetSyncDeviceApplied(ctx, sqlite.SetSyncDeviceAppliedParams{
+				Device:  device,
+				Applied: seen[device] + int64(len(remote)),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// What was just applied is in the history too, and is not sent
+		// back.
+		last, err := tx.GetLastActivityHistoryID(ctx)
+		if err != nil {
+			return err
+		}
+		return tx.SetSyncExported(ctx, last)
+	})
+	return res, err
+}
+
+// matchFields identify an activity that was added on more than one device
+// before they first synced.
+var matchFields = []string{"start", "name", "project"}
+
+func matchKey(s activitySnapshot) (string, error) {
+	var key []byte
+	for _, f := range matchFields {
+		value, err := s.fieldJSON(f)
+		if err != nil {
+			return "", err
+		}
+		key = append(append(key, value...), 0)
+	}
+	return string(key), nil
+}
+
+// adoptUUIDs gives the user's activities the UUID they already have in
+// another device's log, matching them on their start, name and project, so
+// that a first sync does not add them a second time everywhere. Activities
+// whose match is ambiguous keep their own UUID.
+func adoptUUIDs(ctx context.Context, tx store.ActivityStore, dir syncdir.Dir, device string) error {
+	others, err := dir.Devices()
+	if err != nil {
+		return err
+	}
+	remote := map[string]*activitySnapshot{}
+	deleted := map[string]bool{}
+	for _, d := range others {
+		if d == device {
+			continue
+		}
+		entries, err := dir.Read(d, 0)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if e.Field == deletedField {
+				deleted[e.Activity] = true
+			}
+			if !slices.Contains(matchFields, e.Field) {
+				continue
+			}
+			snap, ok := remote[e.Activity]
+			if !ok {
+				snap = &activitySnapshot{UUID: e.Activity}
+				remote[e.Activity] = snap
+			}
+			if err := snap.setField(e.Field, e.Value); err != nil {
+				return fmt.Errorf("change %s/%d: %v", e.Device, e.Seq, err)
+			}
+		}
+	}
+	byKey := map[string]string{}
+	for id, snap := range remote {
+		if deleted[id] {
+			continue
+		}
+		key, err := matchKey(*snap)
+		if err != nil {
+			return err
+		}
+		if _, ok := byKey[key]; ok {
+			byKey[key] = ""
+			continue
+		}
+		byKey[key] = id
+	}
+
+	activities, err := tx.QueryActivities(ctx, userFrom(ctx))
+	if err != nil {
+		return err
+	}
+	for _, a := range activities {
+		if _, ok := remote[a.UUID]; ok {
+			continue
+		}
+		key, err := matchKey(newSnapshot(a))
+		if err != nil {
+			return err
+		}
+		id := byKey[key]
+		if id == "" {
+			continue
+		}
+		if _, err := tx.QueryActivityByUUID(ctx, id); err == nil {
+			continue
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		// The UUID is not in the history, which has nothing to send on a
+		// first sync.
+		if _, err := tx.SetActivityUUID(ctx, sqlite.SetActivityUUIDParams{UUID: id, ID: a.ID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// localEntries lists the changes to send. History from before the first
+// sync has no UUIDs, so the first sync sends the user's activities as they
+// are instead.
+func localEntries(ctx context.Context, q store.ActivityStore, local sqlite.SyncLocal, first bool) ([]syncdir.Entry, error) {
+	var entries []syncdir.Entry
+	if first {
+		activities, err := q.QueryActivities(ctx, userFrom(ctx))
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		for _, a := range activities {
+			snap := newSnapshot(a)
+			es, err := syncEntries(nil, &snap, now)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, es...)
+		}
+		return entries, nil
+	}
+	rows, err := q.QueryActivityHistorySince(ctx, local.ExportedHistoryID)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		if r.Source == sourceSync {
+			continue
+		}
+		old, err := decodeSnapshot(r.OldValues)
+		if err != nil {
+			return nil, err
+		}
+		next, err := decodeSnapshot(r.NewValues)
+		if err != nil {
+			return nil, err
+		}
+		es, err := syncEntries(old, next, r.ChangedAt)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, es...)
+	}
+	return entries, nil
+}
+
+func setClock(ctx context.Context, q store.ActivityStore, e syncdir.Entry) error {
+	return q.SetSyncClock(ctx, sqlite.SetSyncClockParams{
+		ActivityUUID: e.Activity,
+		Field:        e.Field,
+		ChangedAt:    e.At,
+		Device:       e.Device,
+		Seq:          e.Seq,
+	})
+}
+
+// pendingActivity gathers the changes to one activity from a log, so that
+// each activity is written once.
+type pendingActivity struct {
+	old     *sqlite.Activity
+	snap    activitySnapshot
+	changed bool
+	deleted bool
+}
+
+func applyEntries(ctx context.Context, tx store.ActivityStore, entries []syncdir.Entry, res *syncResult) error {
+	pending := map[string]*pendingActivity{}
+	var order []string
+	for _, e := range entries {
+		if e.Field != deletedField && !slices.Contains(syncFields, e.Field) {
+			// Written by a newer version.
+			continue
+		}
+		_, err := tx.GetSyncClock(ctx, sqlite.GetSyncClockParams{ActivityUUID: e.Activity, Field: deletedField})
+		if err == nil {
+			continue
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		p, ok := pending[e.Activity]
+		if !ok {
+			p = &pendingActivity{snap: activitySnapshot{UUID: e.Activity}}
+			a, err := tx.QueryActivityByUUID(ctx, e.Activity)
+			if err == nil {
+				p.old, p.snap = &a, newSnapshot(a)
+			} else if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+			pending[e.Activity] = p
+			order = append(order, e.Activity)
+		}
+		if e.Field == deletedField {
+			if p.old != nil && p.old.InvoiceID.Valid {
+				// Invoiced activities are locked here, so the user decides,
+				// and until then the activity is kept and takes changes.
+				err := tx.InsertSyncConflict(ctx, sqlite.InsertSyncConflictParams{
+					ActivityUUID: e.Activity,
+					Field:        e.Field,
+					Kept:         "false",
+					Other:        string(e.Value),
+					CreatedAt:    time.Now(),
+				})
+				if err != nil {
+					return err
+				}
+				res.conflicts++
+				continue
+			}
+			p.deleted = true
+			if err := setClock(ctx, tx, e); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := applyEntry(ctx, tx, p, e, res); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range order {
+		p := pending[id]
+		switch {
+		case p.deleted:
+			if p.old == nil {
+				continue
+			}
+			if err := bulkDeleteActivity(ctx, tx, *p.old); err != nil {
+				return err
+			}
+		case p.old == nil:
+			if p.snap.StartTime.IsZero() {
+				// Only later changes reached this device.
+				continue
+			}
+			a, err := insertSnapshot(ctx, tx, p.snap)
+			if err != nil {
+				return err
+			}
+			if err := recordChange(ctx, tx, nil, &a); err != nil {
+				return err
+			}
+		case p.changed:
+			a, err := applySnapshot(ctx, tx, *p.old, p.snap)
+			if err != nil {
+				return err
+			}
+			if err := recordChange(ctx, tx, p.old, &a); err != nil {
+				return err
+			}
+		default:
+			continue
+		}
+		res.received++
+	}
+	return nil
+}
+
+// applyEntry sets the field of p from e if e is the latest change to it,
+// and records a conflict if e was made without knowing of the change it
+// replaces or loses to.
+func applyEntry(ctx context.Context, tx store.ActivityStore, p *pendingActivity, e syncdir.Entry, res *syncResult) error {
+	var incoming activitySnapshot
+	if err := incoming.setField(e.Field, e.Value); err != nil {
+		return fmt.Errorf("change %s/%d: %v", e.Device, e.Seq, err)
+	}
+	value, err := incoming.fieldJSON(e.Field)
+	if err != nil {
+		return err
+	}
+	current, err := p.snap.fieldJSON(e.Field)
+	if err != nil {
+		return err
+	}
+	differs := !bytes.Equal(current, value)
+
+	clock, err := tx.GetSyncClock(ctx, sqlite.GetSyncClockParams{ActivityUUID: e.Activity, Field: e.Field})
+	known := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	last := syncdir.Clock{At: clock.ChangedAt, Device: clock.Device, Seq: clock.Seq}
+	wins := !known || e.Clock().Newer(last)
+
+	conflict := known && differs && e.Concurrent(last)
+	if wins && differs && p.old != nil && p.old.InvoiceID.Valid {
+		// Invoiced activities are locked here, so the user decides.
+		wins, conflict = false, true
+	}
+	if conflict {
+		kept, other := current, value
+		if wins {
+			kept, other = value, current
+		}
+		err := tx.InsertSyncConflict(ctx, sqlite.InsertSyncConflictParams{
+			ActivityUUID: e.Activity,
+			Field:        e.Field,
+			Kept:         string(kept),
+			Other:        string(other),
+			CreatedAt:    time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		res.conflicts++
+	}
+	if !wins {
+		return nil
+	}
+	if err := setClock(ctx, tx, e); err != nil {
+		return err
+	}
+	if differs {
+		p.changed = true
+		return p.snap.setField(e.Field, value)
+	}
+	return nil
+}
+
+// insertSnapshot adds an activity from another device, owned by the
+// current user.
+func insertSnapshot(ctx context.Context, q store.ActivityStore, snap activitySnapshot) (sqlite.Activity, error) {
+	params := sqlite.InsertActivityParams{
+		StartTime:    snap.StartTime,
+		ActivityName: snap.ActivityName,
+		Description:  snap.Description,
+		Project:      snap.Project,
+		Notes:        snap.Notes,
+		Billable:     snap.Billable,
+		OwnerID:      ownerFrom(ctx),
+		UUID:         snap.UUID,
+	}
+	if snap.EndTime != nil {
+		params.EndTime = sql.NullTime{Time: *snap.EndTime, Valid: true}
+	}
+	if snap.Duration != nil {
+		params.Duration = sql.NullInt64{Int64: *snap.Duration, Valid: true}
+	}
+	return q.InsertActivity(ctx, params)
+}
+
+// syncDir is the configured sync directory.
+func syncDir(cfg config.Config) (syncdir.Dir, error) {
+	if cfg.Sync.Dir == "" {
+		return "", errors.New("no sync directory configured: set sync.dir in the config file")
+	}
+	dir, err := expandHome(cfg.Sync.Dir)
+	return syncdir.Dir(dir), err
+}
+
+func syncCommand(ctx context.Context, q store.ActivityStore, cfg config.Config) error {
+	dir, err := syncDir(cfg)
+	if err != nil {
+		return err
+	}
+	res, err := syncActivities(ctx, q, dir)
+	if err != nil {
+		return err
+	}
+	fmt.Println(res)
+	conflicts, err := q.QuerySyncConflicts(ctx)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		fmt.Printf("%s to resolve in the TUI (%s)\n", plural(len(conflicts), "conflict"), newKeyMap().showConflicts.Help().Key)
+	}
+	return nil
+}
+
+type syncedMsg struct {
+	result syncResult
+}
+
+func (m model) runSync() tea.Msg {
+	dir, err := syncDir(m.Config)
+	if err != nil {
+		return errorMsg{err}
+	}
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	res, err := syncActivities(ctx, m.Store, dir)
+	if err != nil {
+		return errorMsg{fmt.Errorf("failed to sync: %v", err)}
+	}
+	return syncedMsg{result: res}
+}
+
+// syncConflict is a sync_conflicts row with the name of its activity,
+// which is empty if the activity has since been deleted.
+type syncConflict struct {
+	sqlite.SyncConflict
+	activity string
+}
+
+type conflictsMsg struct {
+	conflicts []syncConflict
+}
+
+func (m model) fetchConflicts() tea.Msg {
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	rows, err := m.Store.QuerySyncConflicts(ctx)
+	if err != nil {
+		return errorMsg{err}
+	}
+	conflicts := make([]syncConflict, len(rows))
+	for i, r := range rows {
+		conflicts[i].SyncConflict = r
+		a, err := m.Store.QueryActivityByUUID(ctx, r.ActivityUUID)
+		if err == nil {
+			conflicts[i].activity = a.ActivityName
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return errorMsg{err}
+		}
+	}
+	return conflictsMsg{conflicts: conflicts}
+}
+
+type conflictResolvedMsg struct{}
+
+// resolveConflict settles the selected conflict, first setting the field
+// to the value that lost if useOther is set, or deleting the activity for a
+// delete. That is an ordinary edit, so the next sync sends it to the other
+// devices.
+func (m model) resolveConflict(useOther bool) tea.Cmd {
+	c := m.conflicts[m.conflictIndex]
+	return func() tea.Msg {
+		ctx, cancel := m.dbContext()
+		defer cancel()
+		err := m.Store.InTx(ctx, func(tx store.ActivityStore) error {
+			if useOther && c.activity != "" {
+				a, err := tx.QueryActivityByUUID(ctx, c.ActivityUUID)
+				if err != nil {
+					return err
+				}
+				if c.Field == deletedField {
+					// The other device deleted an invoiced activity, which
+					// stays locked until its invoice is gone.
+					if a.InvoiceID.Valid {
+						return errors.New("invoiced activities are locked")
+					}
+					if err := bulkDeleteActivity(ctx, tx, a); err != nil {
+						return err
+					}
+				} else if err := useOtherValue(ctx, tx, a, c.SyncConflict); err != nil {
+					return err
+				}
+			}
+			return tx.DeleteSyncConflict(ctx, c.ID)
+		})
+		if err != nil {
+			return errorMsg{fmt.Errorf("failed to resolve conflict: %v", err)}
+		}
+		return conflictResolvedMsg{}
+	}
+}
+
+// useOtherValue sets the field of a that is in conflict to the value that
+// lost.
+func useOtherValue(ctx context.Context, tx store.ActivityStore, a sqlite.Activity, c sqlite.SyncConflict) error {
+	snap := newSnapshot(a)
+	if err := snap.setField(c.Field, json.RawMessage(c.Other)); err != nil {
+		return err
+	}
+	next, err := applySnapshot(ctx, tx, a, snap)
+	if err != nil {
+		return err
+	}
+	return recordChange(ctx, tx, &a, &next)
+}
+
+func (m model) openConflicts() (model, tea.Cmd) {
+	m.viewingConflicts = true
+	m.conflicts = nil
+	m.conflictIndex = 0
+	return m, m.fetchConflicts
+}
+
+func (m model) updateConflicts(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.back):
+		m.viewingConflicts = false
+		return m, nil
+	case key.Matches(msg, m.keys.selectUp):
+		m.conflictIndex = max(0, m.conflictIndex-1)
+	case key.Matches(msg, m.keys.selectDown):
+		m.conflictIndex = min(len(m.conflicts)-1, m.conflictIndex+1)
+	case key.Matches(msg, m.keys.keepConflict):
+		if len(m.conflicts) > 0 {
+			return m, retryable(m.resolveConflict(false))
+		}
+	case key.Matches(msg, m.keys.useOther):
+		if len(m.conflicts) > 0 {
+			return m, retryable(m.resolveConflict(true))
+		}
+	}
+	return m, nil
+}
+
+// formatFieldValue shows a field's value from a change log.
+func formatFieldValue(field, value string) string {
+	if field == deletedField {
+		if value == "true" {
+			return "deleted"
+		}
+		return "not deleted"
+	}
+	var s activitySnapshot
+	if err := s.setField(field, json.RawMessage(value)); err != nil {
+		return value
+	}
+	switch field {
+	case "start":
+		return s.StartTime.Local().Format("2006-01-02 15:04")
+	case "end":
+		if s.EndTime == nil {
+			return "none"
+		}
+		return s.EndTime.Local().Format("2006-01-02 15:04")
+	case "duration":
+		if s.Duration == nil {
+			return "none"
+		}
+		return (time.Duration(*s.Duration) * time.Second).String()
+	case "billable":
+		if s.Billable {
+			return "yes"
+		}
+		return "no"
+	}
+	text := strings.Join(strings.Fields(*s.field(field).(*string)), " ")
+	if r := []rune(text); len(r) > 30 {
+		text = string(r[:29]) + "…"
+	}
+	return fmt.Sprintf("%q", text)
+}
+
+func (m model) conflictsView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Sync conflicts") + "\n\n")
+	if len(m.conflicts) == 0 {
+		b.WriteString("No conflicts.\n")
+	}
+	for i, c := range m.conflicts {
+		cursor := "  "
+		if i == m.conflictIndex {
+			cursor = inputStyle.Render("> ")
+		}
+		name := c.activity
+		if name == "" {
+			name = "(deleted)"
+		}
+		fmt.Fprintf(&b, "%s%-20s %-11s kept %s, other %s\n", cursor, name, c.Field,
+			formatFieldValue(c.Field, c.Kept), formatFieldValue(c.Field, c.Other))
+	}
+	b.WriteString("\n" + continueStyle.Render(helpLine(m.keys.selectUp, m.keys.selectDown, m.keys.keepConflict, m.keys.useOther, m.keys.back)))
+	return appStyle.Render(b.String())
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Proqpine/probable-memory/config"
+	"github.com/Proqpine/probable-memory/fieldcrypt"
+	"github.com/Proqpine/probable-memory/sqlite"
+	"github.com/Proqpine/probable-memory/store"
+	"github.com/Proqpine/probable-memory/syncdir"
+)
+
+// device is one copy of the database syncing through a shared directory.
+type device struct {
+	t   *testing.T
+	ctx context.Context
+	st  store.ActivityStore
+	dir syncdir.Dir
+}
+
+func newDevice(t *testing.T, dir syncdir.Dir) *device {
+	t.Helper()
+	st := store.NewMemory()
+	cfg := config.Default()
+	cfg.User = "me"
+	ctx, err := currentUser(context.Background(), st, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &device{t: t, ctx: ctx, st: st, dir: dir}
+}
+
+func (d *device) sync() syncResult {
+	d.t.Helper()
+	res, err := syncActivities(d.ctx, d.st, d.dir)
+	if err != nil {
+		d.t.Fatal(err)
+	}
+	return res
+}
+
+func (d *device) only() sqlite.Activity {
+	d.t.Helper()
+	all, err := d.st.QueryActivities(d.ctx, userFrom(d.ctx))
+	if err != nil {
+		d.t.Fatal(err)
+	}
+	if len(all) != 1 {
+		d.t.Fatalf("%d activities, want 1", len(all))
+	}
+	return all[0]
+}
+
+// edit changes the only activity the way the TUI does.
+func (d *device) edit(change func(*activitySnapshot)) {
+	d.t.Helper()
+	a := d.only()
+	snap := newSnapshot(a)
+	change(&snap)
+	next, err := applySnapshot(d.ctx, d.st, a, snap)
+	if err == nil {
+		err = recordChange(d.ctx, d.st, &a, &next)
+	}
+	if err != nil {
+		d.t.Fatal(err)
+	}
+}
+
+func (d *device) conflicts() []sqlite.SyncConflict {
+	d.t.Helper()
+	c, err := d.st.QuerySyncConflicts(d.ctx)
+	if err != nil {
+		d.t.Fatal(err)
+	}
+	return c
+}
+
+func TestSync(t *testing.T) {
+	dir := syncdir.Dir(t.TempDir())
+	laptop, desktop := newDevice(t, dir), newDevice(t, dir)
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	a, err := startActivity(laptop.ctx, laptop.st, "Write docs", "", "docs", "", start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := laptop.sync(); res.sent != len(syncFields) || res.received != 0 {
+		t.Errorf("first laptop sync = %+v", res)
+	}
+	if res := desktop.sync(); res.received != 1 {
+		t.Errorf("first desktop sync = %+v, want the activity", res)
+	}
+	got := desktop.only()
+	if got.UUID != a.UUID || got.ActivityName != "Write docs" || got.Project != "docs" || !got.StartTime.Equal(start) || got.EndTime.Valid {
+		t.Fatalf("desktop has %+v", got)
+	}
+
+	// Different fields merge.
+	laptop.edit(func(s *activitySnapshot) { s.Project = "site" })
+	desktop.edit(func(s *activitySnapshot) { s.Notes = "outline" })
+	laptop.sync()
+	desktop.sync()
+	laptop.sync()
+	for _, d := range []*device{laptop, desktop} {
+		if got := d.only(); got.Project != "site" || got.Notes != "outline" {
+			t.Errorf("after merging, project = %q, notes = %q", got.Project, got.Notes)
+		}
+		if c := d.conflicts(); len(c) != 0 {
+			t.Errorf("conflicts = %+v, want none", c)
+		}
+	}
+
+	// The same field changed on both: the later change wins everywhere,
+	// and both devices show the conflict.
+	laptop.edit(func(s *activitySnapshot) { s.ActivityName = "Draft docs" })
+	time.Sleep(time.Millisecond)
+	desktop.edit(func(s *activitySnapshot) { s.ActivityName = "Review docs" })
+	laptop.sync()
+	desktop.sync()
+	laptop.sync()
+	for _, d := range []*device{laptop, desktop} {
+		if got := d.only(); got.ActivityName != "Review docs" {
+			t.Errorf("name = %q, want the later Review docs", got.ActivityName)
+		}
+		c := d.conflicts()
+		if len(c) != 1 || c[0].Field != "name" || c[0].Kept != `"Review docs"` || c[0].Other != `"Draft docs"` {
+			t.Errorf("conflicts = %+v", c)
+		}
+	}
+
+	// Syncing again changes nothing.
+	if res := laptop.sync(); res != (syncResult{}) {
+		t.Errorf("idle sync = %+v", res)
+	}
+
+	// Deletes win over edits.
+	desktop.edit(func(s *activitySnapshot) { s.Description = "late edit" })
+	if err := bulkDeleteActivity(laptop.ctx, laptop.st, laptop.only()); err != nil {
+		t.Fatal(err)
+	}
+	laptop.sync()
+	desktop.sync()
+	laptop.sync()
+	for _, d := range []*device{laptop, desktop} {
+		if all, _ := d.st.QueryActivities(d.ctx, userFrom(d.ctx)); len(all) != 0 {
+			t.Errorf("after deleting, %s remain", names(all))
+		}
+	}
+}
+
+func names(activities []sqlite.Activity) string {
+	var s string
+	for i, a := range activities {
+		if i > 0 {
+			s += ","
+		}
+		s += a.ActivityName
+	}
+	return s
+}
+
+func TestClockNewer(t *testing.T) {
+	at := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	a := syncdir.Clock{At: at, Device: "a", Seq: 1}
+	b := syncdir.Clock{At: at, Device: "b", Seq: 1}
+	if !b.Newer(a) || a.Newer(b) {
+		t.Error("equal times are not broken by device")
+	}
+	later := syncdir.Clock{At: at.Add(time.Second), Device: "a", Seq: 2}
+	if !later.Newer(b) {
+		t.Error("a later change does not win")
+	}
+	e := syncdir.Entry{Device: "b", Seq: 3, Seen: map[string]int64{"a": 1}}
+	if e.Concurrent(a) || !e.Concurrent(later) {
+		t.Error("Concurrent does not follow the version vector")
+	}
+}
+
+// exportFailing fails to record what a sync exported, after the sync has
+// written its log.
+type exportFailing struct {
+	store.ActivityStore
+}
+
+func (s exportFailing) SetSyncExported(context.Context, int64) error {
+	return errors.New("disk full")
+}
+
+func (s exportFailing) InTx(ctx context.Context, fn func(store.ActivityStore) error) error {
+	return s.ActivityStore.InTx(ctx, func(tx store.ActivityStore) error {
+		return fn(exportFailing{tx})
+	})
+}
+
+func TestSyncRetryKeepsDevice(t *testing.T) {
+	dir := syncdir.Dir(t.TempDir())
+	laptop := newDevice(t, dir)
+	if _, err := startActivity(laptop.ctx, laptop.st, "Write docs", "", "docs", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := syncActivities(laptop.ctx, exportFailing{laptop.st}, dir); err == nil {
+		t.Fatal("sync without recording the export succeeded")
+	}
+	laptop.sync()
+	if devices, err := dir.Devices(); err != nil || len(devices) != 1 {
+		t.Errorf("devices = %v, %v, want the one laptop log", devices, err)
+	}
+}
+
+func TestFirstSyncMatchesActivities(t *testing.T) {
+	dir := syncdir.Dir(t.TempDir())
+	laptop, desktop := newDevice(t, dir), newDevice(t, dir)
+
+	// The same activity, entered on both before they first synced.
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	a, err := startActivity(laptop.ctx, laptop.st, "Write docs", "", "docs", "", start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := startActivity(desktop.ctx, desktop.st, "Write docs", "", "docs", "", start); err != nil {
+		t.Fatal(err)
+	}
+	laptop.sync()
+	desktop.sync()
+	laptop.sync()
+	for _, d := range []*device{laptop, desktop} {
+		if got := d.only(); got.UUID != a.UUID {
+			t.Errorf("UUID = %s, want the laptop's %s", got.UUID, a.UUID)
+		}
+	}
+}
+
+func TestSyncEncrypted(t *testing.T) {
+	dir := syncdir.Dir(t.TempDir())
+	key, err := fieldcrypt.ParseKeyFile([]byte(strings.Repeat("k", fieldcrypt.KeySize)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	laptop, desktop := newDevice(t, dir), newDevice(t, dir)
+	laptop.st, desktop.st = store.NewEncrypted(laptop.st, key), store.NewEncrypted(desktop.st, key)
+
+	if _, err := startActivity(laptop.ctx, laptop.st, "Call", "Acme merger", "", "confidential", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	laptop.sync()
+	devices, err := dir.Devices()
+	if err != nil || len(devices) != 1 {
+		t.Fatalf("devices = %v, %v", devices, err)
+	}
+	b, err := os.ReadFile(filepath.Join(string(dir), devices[0]+".jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "Acme merger") || strings.Contains(string(b), "confidential") {
+		t.Errorf("the log holds the text in the clear:\n%s", b)
+	}
+	desktop.sync()
+	if got := desktop.only(); got.Description != "Acme merger" || got.Notes != "confidential" {
+		t.Errorf("desktop read %q, %q", got.Description, got.Notes)
+	}
+
+	// A device without the key cannot take the text as it is.
+	plain := newDevice(t, dir)
+	if _, err := syncActivities(plain.ctx, plain.st, dir); err == nil || !strings.Contains(err.Error(), "encrypted") {
+		t.Errorf("syncing encrypted changes into a plain database = %v", err)
+	}
+}
+
+func TestSyncKeepsInvoicedActivities(t *testing.T) {
+	dir := syncdir.Dir(t.TempDir())
+	laptop, desktop := newDevice(t, dir), newDevice(t, dir)
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if _, err := startActivity(laptop.ctx, laptop.st, "Call", "", "bolt", "", start); err != nil {
+		t.Fatal(err)
+	}
+	laptop.sync()
+	desktop.sync()
+
+	// The desktop invoices the activity, then the laptop deletes it.
+	if _, err := desktop.st.UpsertClient(desktop.ctx, sqlite.UpsertClientParams{Name: "Acme", Currency: "EUR"}); err != nil {
+		t.Fatal(err)
+	}
+	inv, err := desktop.st.InsertInvoice(desktop.ctx, sqlite.InsertInvoiceParams{
+		Number: "2026-0001", Year: 2026, Sequence: 1, Client: "Acme",
+		PeriodStart: start, PeriodEnd: start.Add(time.Hour), IssuedAt: start, Currency: "EUR",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := sqlite.SetActivityInvoiceParams{ID: desktop.only().ID, InvoiceID: sql.NullInt64{Int64: inv.ID, Valid: true}}
+	if _, err := desktop.st.SetActivityInvoice(desktop.ctx, params); err != nil {
+		t.Fatal(err)
+	}
+	if err := bulkDeleteActivity(laptop.ctx, laptop.st, laptop.only()); err != nil {
+		t.Fatal(err)
+	}
+	laptop.sync()
+	if res := desktop.sync(); res.conflicts != 1 {
+		t.Errorf("sync of the delete = %+v, want a conflict", res)
+	}
+	if got := desktop.only(); !got.InvoiceID.Valid {
+		t.Errorf("invoiced activity is %+v", got)
+	}
+	if c := desktop.conflicts(); len(c) != 1 || c[0].Field != deletedField || c[0].Other != "true" {
+		t.Errorf("conflicts = %+v", c)
+	}
+}
@@ -0,0 +1,203 @@
+package syncdir
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Entry is one change to one field of an activity, as written by the
+// device that made it.
+type Entry struct {
+	Device string `json:"device"`
+	// Seq numbers the device's entries from 1, in the order written.
+	Seq      int64           `json:"seq"`
+	Activity string          `json:"activity"`
+	Field    string          `json:"field"`
+	Value    json.RawMessage `json:"value"`
+	At       time.Time       `json:"at"`
+	// Seen is the device's version vector when it wrote the entry: how
+	// many entries of each other device it had applied.
+	Seen map[string]int64 `json:"seen,omitempty"`
+}
+
+// Clock identifies the change that last set a field.
+type Clock struct {
+	At     time.Time
+	Device string
+	Seq    int64
+}
+
+func (e Entry) Clock() Clock {
+	return Clock{At: e.At, Device: e.Device, Seq: e.Seq}
+}
+
+// Newer reports whether c wins over other: the later change wins, and
+// the greater device id breaks ties, so every device picks the same one.
+func (c Clock) Newer(other Clock) bool {
+	if !c.At.Equal(other.At) {
+		return c.At.After(other.At)
+	}
+	if c.Device != other.Device {
+		return c.Device > other.Device
+	}
+	return c.Seq > other.Seq
+}
+
+// Concurrent reports whether e was written without knowing of the change
+// c, so that one of the two silently overrides the other.
+func (e Entry) Concurrent(c Clock) bool {
+	return c.Device != e.Device && e.Seen[c.Device] < c.Seq
+}
+
+// Dir is a directory shared between devices, by a file sync tool or a
+// network drive. Each device appends to a log of its own, named after it,
+// and only reads the others', so no file is ever written by two devices.
+type Dir string
+
+const ext = ".jsonl"
+
+func (d Dir) path(device string) string {
+	return filepath.Join(string(d), device+ext)
+}
+
+// Devices lists the devices with a log in the directory.
+func (d Dir) Devices() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(string(d), "*"+ext))
+	if err != nil {
+		return nil, err
+	}
+	devices := make([]string, 0, len(matches))
+	for _, m := range matches {
+		devices = append(devices, strings.TrimSuffix(filepath.Base(m), ext))
+	}
+	sort.Strings(devices)
+	return devices, nil
+}
+
+// Read returns the entries of device's log after the first skip. A last
+// line without a newline is still being written, or synced, and is left
+// for next time.
+func (d Dir) Read(device string, skip int64) ([]Entry, error) {
+	f, err := os.Open(d.path(device))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	r := bufio.NewReader(f)
+	for n := int64(1); ; n++ {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			// io.EOF, with or without a partial line.
+			return entries, nil
+		}
+		if n <= skip {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("%s line %d: %v", d.path(device), n, err)
+		}
+		if e.Device != device || e.Seq != n {
+			return nil, fmt.Errorf("%s line %d: entry %s/%d is out of place", d.path(device), n, e.Device, e.Seq)
+		}
+		entries = append(entries, e)
+	}
+}
+
+// count returns the number of complete lines in device's log.
+func (d Dir) count(device string) (int64, error) {
+	b, err := os.ReadFile(d.path(device))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return int64(bytes.Count(b, []byte{'\n'})), nil
+}
+
+// Append numbers entries after those already in device's log and adds
+// them to it in one write. It returns the numbered entries.
+func (d Dir) Append(device string, entries []Entry) ([]Entry, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	if err := os.MkdirAll(string(d), 0o755); err != nil {
+		return nil, err
+	}
+	n, err := d.count(device)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	out := make([]Entry, len(entries))
+	for i, e := range entries {
+		e.Device = device
+		e.Seq = n + int64(i) + 1
+		b, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+		buf.WriteByte('\n')
+		out[i] = e
+	}
+	f, err := os.OpenFile(d.path(device), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return out, f.Close()
+}
+
+// Rewrite changes the values in device's log with fn, keeping every entry
+// in its place. The new log replaces the old one in a single rename.
+func (d Dir) Rewrite(device string, fn func(*Entry) error) error {
+	entries, err := d.Read(device, 0)
+	if err != nil || len(entries) == 0 {
+		return err
+	}
+	var buf bytes.Buffer
+	for i := range entries {
+		if err := fn(&entries[i]); err != nil {
+			return err
+		}
+		b, err := json.Marshal(entries[i])
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+	f, err := os.CreateTemp(string(d), "."+device+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), d.path(device))
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxTagLength is the size of the tag column in the activity_tags table.
+const maxTagLength = 64
+
+// parseTags splits a comma separated list into trimmed, lower-case tags,
+// dropping empty ones and duplicates.
+func parseTags(s string) []string {
+	var tags []string
+	seen := map[string]bool{}
+	for _, t := range strings.Split(s, ",") {
+		t = truncateName(strings.ToLower(strings.TrimSpace(t)), maxTagLength)
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		tags = append(tags, t)
+	}
+	return tags
+}
+
+type tagsMsg struct {
+	activityID int64
+	tags       []string
+}
+
+func (m model) fetchSelectedTags() tea.Msg {
+	id := m.SelectedActivity.ID
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	tags, err := m.Store.QueryActivityTags(ctx, id)
+	if err != nil {
+		return errorMsg{err}
+	}
+	return tagsMsg{activityID: id, tags: tags}
+}
+
+func tagsView(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	return "\nTags: " + strings.Join(tags, ", ") + "\n"
+}
@@ -0,0 +1,173 @@
+package theme
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Theme is the palette the TUI draws with. Colors are hex codes such as
+// "#25A065" or ANSI color numbers such as "205".
+type Theme struct {
+	// Base names the theme a user-defined theme starts from; its empty
+	// colors are taken from there.
+	Base string `json:"base,omitempty"`
+
+	// Primary is the background of titles and the color of group headers.
+	Primary string `json:"primary,omitempty"`
+	// OnPrimary is text drawn on Primary.
+	OnPrimary string `json:"on_primary,omitempty"`
+	// Accent marks the selected item, cursors and prompts.
+	Accent string `json:"accent,omitempty"`
+	// Text is regular text, Subtle secondary text such as descriptions.
+	Text   string `json:"text,omitempty"`
+	Subtle string `json:"subtle,omitempty"`
+	// Muted is used for help and hints.
+	Muted string `json:"muted,omitempty"`
+	// Success is used for status messages, Error for errors and budgets
+	// that have been exceeded.
+	Success string `json:"success,omitempty"`
+	Error   string `json:"error,omitempty"`
+	// Border is the background of form titles and the prompt of notes;
+	// Focus marks the focused form field.
+	Border string `json:"border,omitempty"`
+	Focus  string `json:"focus,omitempty"`
+}
+
+// Auto picks Light or Dark from the terminal background.
+const Auto = "auto"
+
+var (
+	Dark = Theme{
+		Primary:   "#25A065",
+		OnPrimary: "#FFFDF5",
+		Accent:    "#FF06B7",
+		Text:      "#DDDDDD",
+		Subtle:    "#777777",
+		Muted:     "#767676",
+		Success:   "#04B575",
+		Error:     "#FF5F5F",
+		Border:    "#7D56F4",
+		Focus:     "#FF00FF",
+	}
+	Light = Theme{
+		Primary:   "#1E7A4C",
+		OnPrimary: "#FFFFFF",
+		Accent:    "#B8006A",
+		Text:      "#1A1A1A",
+		Subtle:    "#5F5F5F",
+		Muted:     "#6B6B6B",
+		Success:   "#02814E",
+		Error:     "#C62828",
+		Border:    "#5A3FC0",
+		Focus:     "#B8006A",
+	}
+	HighContrast = Theme{
+		Primary:   "#FFFF00",
+		OnPrimary: "#000000",
+		Accent:    "#00FFFF",
+		Text:      "#FFFFFF",
+		Subtle:    "#FFFFFF",
+		Muted:     "#FFFFFF",
+		Success:   "#00FF00",
+		Error:     "#FF0000",
+		Border:    "#FFFFFF",
+		Focus:     "#FFFF00",
+	}
+)
+
+var builtin = map[string]Theme{
+	"dark":          Dark,
+	"light":         Light,
+	"high-contrast": HighContrast,
+}
+
+var colorPattern = regexp.MustCompile(`^(#[0-9A-Fa-f]{3}|#[0-9A-Fa-f]{6}|[0-9]{1,3})$`)
+
+func (t Theme) colors() map[string]*string {
+	return map[string]*string{
+		"primary":    &t.Primary,
+		"on_primary": &t.OnPrimary,
+		"accent":     &t.Accent,
+		"text":       &t.Text,
+		"subtle":     &t.Subtle,
+		"muted":      &t.Muted,
+		"success":    &t.Success,
+		"error":      &t.Error,
+		"border":     &t.Border,
+		"focus":      &t.Focus,
+	}
+}
+
+// over fills the empty colors of t from base.
+func (t Theme) over(base Theme) Theme {
+	fill := func(c *string, from string) {
+		if *c == "" {
+			*c = from
+		}
+	}
+	fill(&t.Primary, base.Primary)
+	fill(&t.OnPrimary, base.OnPrimary)
+	fill(&t.Accent, base.Accent)
+	fill(&t.Text, base.Text)
+	fill(&t.Subtle, base.Subtle)
+	fill(&t.Muted, base.Muted)
+	fill(&t.Success, base.Success)
+	fill(&t.Error, base.Error)
+	fill(&t.Border, base.Border)
+	fill(&t.Focus, base.Focus)
+	t.Base = ""
+	return t
+}
+
+func (t Theme) validate() error {
+	colors := t.colors()
+	names := make([]string, 0, len(colors))
+	for name := range colors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if c := *colors[name]; !colorPattern.MatchString(c) {
+			return fmt.Errorf("invalid %s color %q", name, c)
+		}
+	}
+	return nil
+}
+
+// Resolve returns the theme called name: a built-in theme, one of custom,
+// or, for "auto" and "", Light or Dark depending on what dark reports.
+// User-defined themes may build on another theme through Base.
+func Resolve(name string, custom map[string]Theme, dark func() bool) (Theme, error) {
+	return resolve(name, custom, dark, nil)
+}
+
+func resolve(name string, custom map[string]Theme, dark func() bool, seen []string) (Theme, error) {
+	if name == "" || name == Auto {
+		if dark() {
+			return Dark, nil
+		}
+		return Light, nil
+	}
+	for _, s := range seen {
+		if s == name {
+			return Theme{}, fmt.Errorf("theme %q builds on itself: %s", name, strings.Join(append(seen, name), " → "))
+		}
+	}
+	if t, ok := custom[name]; ok {
+		base, err := resolve(t.Base, custom, dark, append(seen, name))
+		if err != nil {
+			return Theme{}, err
+		}
+		t = t.over(base)
+		if err := t.validate(); err != nil {
+			return Theme{}, fmt.Errorf("theme %q: %v", name, err)
+		}
+		return t, nil
+	}
+	if t, ok := builtin[name]; ok {
+		return t, nil
+	}
+	return Theme{}, fmt.Errorf("unknown theme %q", name)
+}
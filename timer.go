@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Proqpine/probable-memory/sqlite"
+	"github.com/Proqpine/probable-memory/store"
+	"github.com/google/uuid"
+)
+
+// heartbeatInterval limits how often TUI input is written back as a
+// heartbeat on the running activity.
+const heartbeatInterval = time.Minute
+
+// lastSeen is the last time there was any sign of life for a running
+// activity: its most recent heartbeat, or its start if it has none.
+func lastSeen(a sqlite.Activity) time.Time {
+	if a.HeartbeatAt.Valid && a.HeartbeatAt.Time.After(a.StartTime) {
+		return a.HeartbeatAt.Time
+	}
+	return a.StartTime
+}
+
+// openSegment returns the segment that is still being timed, if any.
+func openSegment(segments []sqlite.TimeSegment) *sqlite.TimeSegment {
+	for i := range segments {
+		if !segments[i].EndTime.Valid {
+			return &segments[i]
+		}
+	}
+	return nil
+}
+
+// isPaused reports whether a running activity has segments but none of
+// them is open. Activities started before segments existed have none and
+// are never paused.
+func isPaused(segments []sqlite.TimeSegment) bool {
+	return len(segments) > 0 && openSegment(segments) == nil
+}
+
+// segmentsDuration sums the segments, counting an open one up to now.
+func segmentsDuration(segments []sqlite.TimeSegment, now time.Time) time.Duration {
+	var d time.Duration
+	for _, s := range segments {
+		end := now
+		if s.EndTime.Valid {
+			end = s.EndTime.Time
+		}
+		d += end.Sub(s.StartTime)
+	}
+	return d
+}
+
+func startActivity(ctx context.Context, q store.ActivityStore, name, description, project, notes string, at time.Time) (sqlite.Activity, error) {
+	billable, err := projectBillable(ctx, q, project)
+	if err != nil {
+		return sqlite.Activity{}, err
+	}
+	var a sqlite.Activity
+	err = q.InTx(ctx, func(tx store.ActivityStore) error {
+		var err error
+		a, err = tx.InsertActivity(ctx, sqlite.InsertActivityParams{
+			StartTime:    at,
+			ActivityName: name,
+			Description:  description,
+			Project:      project,
+			Notes:        notes,
+			Billable:     billable,
+			OwnerID:      ownerFrom(ctx),
+			UUID:         uuid.NewString(),
+		})
+		if err != nil {
+			return err
+		}
+		if err := recordChange(ctx, tx, nil, &a); err != nil {
+			return err
+		}
+		_, err = tx.InsertTimeSegment(ctx, sqlite.InsertTimeSegmentParams{
+			ActivityID: a.ID,
+			StartTime:  at,
+		})
+		return err
+	})
+	return a, err
+}
+
+func pauseActivity(ctx context.Context, q store.ActivityStore, a sqlite.Activity, at time.Time) error {
+	segments, err := q.QueryTimeSegments(ctx, a.ID)
+	if err != nil {
+		return err
+	}
+	if len(segments) == 0 {
+		// Started before segments existed: the time so far becomes one.
+		_, err := q.InsertTimeSegment(ctx, sqlite.InsertTimeSegmentParams{
+			ActivityID: a.ID,
+			StartTime:  a.StartTime,
+			EndTime:    sql.NullTime{Time: at, Valid: true},
+		})
+		return err
+	}
+	open := openSegment(segments)
+	if open == nil {
+		return fmt.Errorf("%q is already paused", a.ActivityName)
+	}
+	if at.Before(open.StartTime) {
+		return fmt.Errorf("pause time %s is before the segment start %s",
+			at.Format(time.RFC3339), open.StartTime.Format(time.RFC3339))
+	}
+	return q.CloseTimeSegment(ctx, sqlite.CloseTimeSegmentParams{
+		EndTime: sql.NullTime{Time: at, Valid: true},
+		ID:      open.ID,
+	})
+}
+
+func resumeActivity(ctx context.Context, q store.ActivityStore, a sqlite.Activity, at time.Time) error {
+	segments, err := q.QueryTimeSegments(ctx, a.ID)
+	if err != nil {
+		return err
+	}
+	if !isPaused(segments) {
+		return fmt.Errorf("%q is not paused", a.ActivityName)
+	}
+	_, err = q.InsertTimeSegment(ctx, sqlite.InsertTimeSegmentParams{
+		ActivityID: a.ID,
+		StartTime:  at,
+	})
+	return err
+}
+
+// stopActivity closes a at the given time. Its duration is the sum of its
+// segments, or the whole span for activities recorded without segments.
+func stopActivity(ctx context.Context, q store.ActivityStore, a sqlite.Activity, at time.Time) (sqlite.Activity, error) {
+	if at.Before(a.StartTime) {
+		return a, fmt.Errorf("stop time %s is before start time %s",
+			at.Format(time.RFC3339), a.StartTime.Format(time.RFC3339))
+	}
+	stopped := a
+	err := q.InTx(ctx, func(tx store.ActivityStore) error {
+		segments, err := tx.QueryTimeSegments(ctx, a.ID)
+		if err != nil {
+			return err
+		}
+		// Segments are not cut short, so a stop inside one would leave
+		// the duration longer than the activity.
+		for _, s := range segments {
+			if s.EndTime.Valid && at.Before(s.EndTime.Time) {
+				return fmt.Errorf("stop time %s is before the end of a segment at %s",
+					at.Format(time.RFC3339), s.EndTime.Time.Format(time.RFC3339))
+			}
+		}
+		duration := at.Sub(a.StartTime)
+		if len(segments) > 0 {
+			if open := openSegment(segments); open != nil {
+				if err := pauseActivity(ctx, tx, a, at); err != nil {
+					return err
+				}
+				open.EndTime = sql.NullTime{Time: at, Valid: true}
+			}
+			duration = segmentsDuration(segments, at)
+		}
+		stopped, err = tx.StopActivity(ctx, sqlite.StopActivityParams{
+			EndTime:  sql.NullTime{Time: at, Valid: true},
+			Duration: sql.NullInt64{Int64: int64(duration.Seconds()), Valid: true},
+			ID:       a.ID,
+		})
+		if err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, &a, &stopped)
+	})
+	if err != nil {
+		return a, err
+	}
+	return stopped, nil
+}
+
+// parseAt reads a time given on the command line. A bare clock time refers
+// to the most recent occurrence of it, so "stop --at 18:00" the next
+// morning closes yesterday's entry.
+func parseAt(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", s, now.Location()); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("15:04", s, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: use HH:MM, \"YYYY-MM-DD HH:MM\" or RFC 3339", s)
+	}
+	t = time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+	if t.After(now) {
+		t = t.AddDate(0, 0, -1)
+	}
+	return t, nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Proqpine/probable-memory/config"
+	"github.com/Proqpine/probable-memory/form"
+	"github.com/Proqpine/probable-memory/sqlite"
+	"github.com/Proqpine/probable-memory/store"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestStopPausedActivity(t *testing.T) {
+	st := store.NewMemory()
+	cfg := config.Default()
+	cfg.User = "me"
+	ctx, err := currentUser(context.Background(), st, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	a, err := startActivity(ctx, st, "Review", "", "core", "", start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pauseActivity(ctx, st, a, start.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stopActivity(ctx, st, a, start.Add(30*time.Minute)); err == nil {
+		t.Error("stopping inside a segment succeeded")
+	}
+	stopped, err := stopActivity(ctx, st, a, start.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stopped.Duration.Int64 != 3600 {
+		t.Errorf("duration %d, want the hour before the pause", stopped.Duration.Int64)
+	}
+}
+
+// historyFailing fails every history insert, in and out of transactions.
+type historyFailing struct {
+	store.ActivityStore
+}
+
+func (s historyFailing) InsertActivityHistory(context.Context, sqlite.InsertActivityHistoryParams) error {
+	return errors.New("disk full")
+}
+
+func (s historyFailing) InTx(ctx context.Context, fn func(store.ActivityStore) error) error {
+	return s.ActivityStore.InTx(ctx, func(tx store.ActivityStore) error {
+		return fn(historyFailing{tx})
+	})
+}
+
+func TestStopWithoutHistory(t *testing.T) {
+	st := store.NewMemory()
+	cfg := config.Default()
+	cfg.User = "me"
+	ctx, err := currentUser(context.Background(), st, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	a, err := startActivity(ctx, st, "Review", "", "core", "", start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stopActivity(ctx, historyFailing{st}, a, start.Add(time.Hour)); err == nil {
+		t.Fatal("stopping without history succeeded")
+	}
+	// Neither the stop nor the pause it starts with may be left behind.
+	if _, err := st.QueryRunningActivity(ctx, userFrom(ctx)); err != nil {
+		t.Errorf("activity is no longer running: %v", err)
+	}
+	segments, err := st.QueryTimeSegments(ctx, a.ID)
+	if err != nil || openSegment(segments) == nil {
+		t.Errorf("segments %+v, %v, want the open one", segments, err)
+	}
+}
+
+func TestIdleHeartbeatFromOutside(t *testing.T) {
+	st := store.NewMemory()
+	h := newHarness(t, st, 80, 24)
+	ctx := h.m.(model).ctx
+	if _, err := startActivity(ctx, st, "Review", "", "core", "", time.Now().Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	h.run(h.m.(model).fetchRunningActivity)
+	if !h.m.(model).isIdle() {
+		t.Fatal("an hour without a heartbeat is not idle")
+	}
+
+	// An editor plugin reports the user is working.
+	if err := heartbeatCommand(ctx, st); err != nil {
+		t.Fatal(err)
+	}
+	h.press("j")
+	if m := h.m.(model); m.idlePrompt || m.isIdle() {
+		t.Error("the idle prompt ignores the heartbeat in the store")
+	}
+}
+
+func TestEditDuration(t *testing.T) {
+	st := store.NewMemory()
+	h := newHarness(t, st, 80, 24)
+	m := h.m.(model)
+	start := time.Now().Add(-5 * time.Hour).Truncate(time.Minute)
+	edit := func(a sqlite.Activity, start time.Time, duration string) (sqlite.Activity, []sqlite.TimeSegment, tea.Msg) {
+		t.Helper()
+		m.SelectedActivity = &a
+		msg := m.updateActivity(form.Values{
+			"name":     a.ActivityName,
+			"start":    start.Local().Format(form.DateTimeLayout),
+			"duration": duration,
+		})
+		got, err := st.QueryActivityByUUID(m.ctx, a.UUID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		segments, err := st.QueryTimeSegments(m.ctx, a.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got, segments, msg
+	}
+
+	a, err := startActivity(m.ctx, st, "Review", "", "core", "", start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, err = stopActivity(m.ctx, st, a, start.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	moved := start.Add(30 * time.Minute)
+	a, segments, _ := edit(a, moved, "2h")
+	end := moved.Add(2 * time.Hour)
+	if a.Duration.Int64 != 7200 || !a.EndTime.Time.Equal(end) {
+		t.Errorf("edited to %s for %ds, want the end at %s", a.EndTime.Time, a.Duration.Int64, end)
+	}
+	if len(segments) != 1 || !segments[0].StartTime.Equal(moved) || !segments[0].EndTime.Time.Equal(end) {
+		t.Errorf("segments %+v do not match the activity", segments)
+	}
+
+	// The duration of a paused activity is the time between its pauses.
+	b, err := startActivity(m.ctx, st, "Write", "", "core", "", start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pauseActivity(m.ctx, st, b, start.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := resumeActivity(m.ctx, st, b, start.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if b, err = stopActivity(m.ctx, st, b, start.Add(3*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, msg := edit(b, start, "3h"); msg != (errorMsg{errPausedDuration}) {
+		t.Errorf("setting the duration of a paused activity = %v", msg)
+	}
+	b, segments, _ = edit(b, moved, "2h")
+	if b.Duration.Int64 != 7200 || !b.EndTime.Time.Equal(start.Add(3*time.Hour+30*time.Minute)) {
+		t.Errorf("moved to end at %s for %ds", b.EndTime.Time, b.Duration.Int64)
+	}
+	if len(segments) != 2 || !segments[0].StartTime.Equal(moved) || !segments[1].EndTime.Time.Equal(b.EndTime.Time) {
+		t.Errorf("segments %+v did not move with the activity", segments)
+	}
+}
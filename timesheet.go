@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/Proqpine/probable-memory/sqlite"
+	"github.com/Proqpine/probable-memory/store"
+	"github.com/Proqpine/probable-memory/timesheet"
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// buildTimesheet totals the current user's week, or everyone's if team is
+// set.
+func buildTimesheet(ctx context.Context, q store.ActivityStore, start, now time.Time, team bool) (timesheet.Timesheet, error) {
+	end := start.AddDate(0, 0, 7)
+	var activities []sqlite.Activity
+	var err error
+	if team {
+		activities, err = q.QueryTeamActivitiesBetween(ctx, sqlite.QueryTeamActivitiesBetweenParams{
+			PeriodStart: start,
+			PeriodEnd:   end,
+		})
+	} else {
+		activities, err = q.QueryActivitiesBetween(ctx, sqlite.QueryActivitiesBetweenParams{
+			PeriodStart: start,
+			PeriodEnd:   end,
+			OwnerID:     userFrom(ctx),
+		})
+	}
+	if err != nil {
+		return timesheet.Timesheet{}, err
+	}
+	entries := make([]timesheet.Entry, 0, len(activities))
+	for _, a := range activities {
+		d, err := activityDuration(ctx, q, a, now)
+		if err != nil {
+			return timesheet.Timesheet{}, err
+		}
+		entries = append(entries, timesheet.Entry{Project: a.Project, Start: a.StartTime, Duration: d})
+	}
+	return timesheet.New(start, entries), nil
+}
+
+func timesheetCommand(ctx context.Context, q store.ActivityStore, args []string) error {
+	now := time.Now()
+	fs := flag.NewFlagSet("timesheet", flag.ContinueOnError)
+	week := fs.String("week", now.Format("2006-01-02"), "any day in the week to show")
+	format := fs.String("format", "text", "output format: markdown, html or text")
+	team := fs.Bool("team", false, "include every user's activities")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	day, err := time.ParseInLocation("2006-01-02", *week, now.Location())
+	if err != nil {
+		return fmt.Errorf("invalid --week: %v", err)
+	}
+	t, err := buildTimesheet(ctx, q, timesheet.WeekStart(day), now, *team)
+	if err != nil {
+		return err
+	}
+	out, err := timesheet.Render(t, *format)
+	if err != nil {
+		return err
+	}
+	fmt.Print(out)
+	return nil
+}
+
+type timesheetMsg struct {
+	timesheet timesheet.Timesheet
+}
+
+func (m model) fetchTimesheet() tea.Msg {
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	t, err := buildTimesheet(ctx, m.Store, m.timesheetWeek, time.Now(), false)
+	if err != nil {
+		return errorMsg{err}
+	}
+	return timesheetMsg{timesheet: t}
+}
+
+func (m model) updateTimesheet(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.back):
+		m.viewingTimesheet = false
+		m.timesheetStatus = ""
+		return m, nil
+	case key.Matches(msg, m.keys.prevWeek):
+		m.timesheetWeek = m.timesheetWeek.AddDate(0, 0, -7)
+		m.timesheetStatus = ""
+		return m, m.fetchTimesheet
+	case key.Matches(msg, m.keys.nextWeek):
+		m.timesheetWeek = m.timesheetWeek.AddDate(0, 0, 7)
+		m.timesheetStatus = ""
+		return m, m.fetchTimesheet
+	case key.Matches(msg, m.keys.copyMarkdown):
+		return m, copyToClipboard(timesheet.Markdown(m.timesheet), "Markdown")
+	case key.Matches(msg, m.keys.copyHTML):
+		return m, copyToClipboard(timesheet.HTML(m.timesheet), "HTML")
+	}
+	return m, nil
+}
+
+type copiedMsg struct {
+	what string
+}
+
+// copyToClipboard copies s, which runs xclip, pbcopy or the like, so it is
+// done outside Update.
+func copyToClipboard(s, what string) tea.Cmd {
+	return func() tea.Msg {
+		if err := clipboard.WriteAll(s); err != nil {
+			return errorMsg{fmt.Errorf("failed to copy %s: %v", what, err)}
+		}
+		return copiedMsg{what: what}
+	}
+}
+
+func (m model) timesheetView() string {
+	help := helpLine(m.keys.prevWeek, m.keys.nextWeek, m.keys.copyMarkdown, m.keys.copyHTML, m.keys.back)
+	if m.timesheetStatus != "" {
+		help = m.timesheetStatus
+	}
+	return appStyle.Render(fmt.Sprintf("%s\n\n%s\n%s",
+		titleStyle.Render("Timesheet"),
+		timesheet.Text(m.timesheet),
+		continueStyle.Render(help)))
+}
@@ -0,0 +1,129 @@
+package timesheet
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+func dayHeader(t Timesheet, i int) string {
+	return t.Day(i).Format("Mon 02")
+}
+
+func Markdown(t Timesheet) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n\n", t.Title())
+
+	b.WriteString("| Project |")
+	for i := 0; i < 7; i++ {
+		fmt.Fprintf(&b, " %s |", dayHeader(t, i))
+	}
+	b.WriteString(" Total |\n|---------|")
+	for i := 0; i < 7; i++ {
+		b.WriteString("-------:|")
+	}
+	b.WriteString("------:|\n")
+
+	for _, p := range t.Projects {
+		fmt.Fprintf(&b, "| %s |", strings.ReplaceAll(p, "|", `\|`))
+		for i := 0; i < 7; i++ {
+			fmt.Fprintf(&b, " %s |", formatHours(t.Cell(p, i)))
+		}
+		fmt.Fprintf(&b, " **%s** |\n", formatHours(t.ProjectTotal(p)))
+	}
+
+	b.WriteString("| **Total** |")
+	for i := 0; i < 7; i++ {
+		fmt.Fprintf(&b, " **%s** |", formatHours(t.DayTotal(i)))
+	}
+	fmt.Fprintf(&b, " **%s** |\n", formatHours(t.Total()))
+	return b.String()
+}
+
+func HTML(t Timesheet) string {
+	e := html.EscapeString
+	cell := `style="border:1px solid #ccc;padding:4px 8px;text-align:right"`
+	head := `style="border:1px solid #ccc;padding:4px 8px;background:#25A065;color:#FFFDF5"`
+	name := `style="border:1px solid #ccc;padding:4px 8px;text-align:left"`
+	total := `style="border:1px solid #ccc;padding:4px 8px;text-align:right;font-weight:bold;background:#f4f4f4"`
+	totalName := `style="border:1px solid #ccc;padding:4px 8px;text-align:left;font-weight:bold;background:#f4f4f4"`
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n</head>\n", e(t.Title()))
+	b.WriteString(`<body style="font-family:sans-serif;color:#222">` + "\n")
+	fmt.Fprintf(&b, "<h2>%s</h2>\n", e(t.Title()))
+	b.WriteString(`<table style="border-collapse:collapse">` + "\n<tr>")
+	fmt.Fprintf(&b, "<th %s>Project</th>", head)
+	for i := 0; i < 7; i++ {
+		fmt.Fprintf(&b, "<th %s>%s</th>", head, e(dayHeader(t, i)))
+	}
+	fmt.Fprintf(&b, "<th %s>Total</th></tr>\n", head)
+
+	for _, p := range t.Projects {
+		fmt.Fprintf(&b, "<tr><td %s>%s</td>", name, e(p))
+		for i := 0; i < 7; i++ {
+			fmt.Fprintf(&b, "<td %s>%s</td>", cell, formatHours(t.Cell(p, i)))
+		}
+		fmt.Fprintf(&b, "<td %s>%s</td></tr>\n", total, formatHours(t.ProjectTotal(p)))
+	}
+
+	fmt.Fprintf(&b, "<tr><td %s>Total</td>", totalName)
+	for i := 0; i < 7; i++ {
+		fmt.Fprintf(&b, "<td %s>%s</td>", total, formatHours(t.DayTotal(i)))
+	}
+	fmt.Fprintf(&b, "<td %s>%s</td></tr>\n", total, formatHours(t.Total()))
+	b.WriteString("</table>\n</body>\n</html>\n")
+	return b.String()
+}
+
+// Text renders a fixed-width table for terminals. The project column is
+// as wide as the longest project name, up to 24 columns, counting wide
+// characters twice.
+func Text(t Timesheet) string {
+	width := len("Project")
+	for _, p := range t.Projects {
+		width = max(width, runewidth.StringWidth(p))
+	}
+	width = min(width, 24)
+
+	var b strings.Builder
+	row := func(label string, cells []string) {
+		b.WriteString(runewidth.FillRight(runewidth.Truncate(label, width, "…"), width))
+		for _, c := range cells {
+			fmt.Fprintf(&b, " │ %6s", c)
+		}
+		b.WriteString("\n")
+	}
+	rule := func() {
+		b.WriteString(strings.Repeat("─", width))
+		for i := 0; i < 8; i++ {
+			b.WriteString("─┼─" + strings.Repeat("─", 6))
+		}
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "%s\n\n", t.Title())
+	header := make([]string, 0, 8)
+	for i := 0; i < 7; i++ {
+		header = append(header, dayHeader(t, i))
+	}
+	row("Project", append(header, "Total"))
+	rule()
+	for _, p := range t.Projects {
+		cells := make([]string, 0, 8)
+		for i := 0; i < 7; i++ {
+			cells = append(cells, formatHours(t.Cell(p, i)))
+		}
+		row(p, append(cells, formatHours(t.ProjectTotal(p))))
+	}
+	rule()
+	totals := make([]string, 0, 8)
+	for i := 0; i < 7; i++ {
+		totals = append(totals, formatHours(t.DayTotal(i)))
+	}
+	row("Total", append(totals, formatHours(t.Total())))
+	return b.String()
+}
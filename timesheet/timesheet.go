@@ -0,0 +1,127 @@
+package timesheet
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// NoProject labels time recorded without a project.
+const NoProject = "(no project)"
+
+// Entry is time spent on a project, attributed to the day it started.
+type Entry struct {
+	Project  string
+	Start    time.Time
+	Duration time.Duration
+}
+
+// Timesheet is a weekly grid of time per project and day.
+type Timesheet struct {
+	Start    time.Time
+	Projects []string
+	cells    map[string]*[7]time.Duration
+}
+
+// WeekStart returns midnight on the Monday of the week containing t.
+func WeekStart(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// New builds the timesheet for the week starting at start. Entries outside
+// the week are ignored.
+func New(start time.Time, entries []Entry) Timesheet {
+	t := Timesheet{Start: start, cells: map[string]*[7]time.Duration{}}
+	for _, e := range entries {
+		day := t.dayOf(e.Start)
+		if day < 0 {
+			continue
+		}
+		project := e.Project
+		if project == "" {
+			project = NoProject
+		}
+		row, ok := t.cells[project]
+		if !ok {
+			row = &[7]time.Duration{}
+			t.cells[project] = row
+			t.Projects = append(t.Projects, project)
+		}
+		row[day] += e.Duration
+	}
+	sort.Strings(t.Projects)
+	return t
+}
+
+func (t Timesheet) Day(i int) time.Time {
+	return t.Start.AddDate(0, 0, i)
+}
+
+// dayOf returns the index of the day containing at, or -1 if it is outside
+// the week. Days are compared by date so DST changes don't shift entries.
+func (t Timesheet) dayOf(at time.Time) int {
+	for i := 0; i < 7; i++ {
+		if !at.Before(t.Day(i)) && at.Before(t.Day(i+1)) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (t Timesheet) Cell(project string, day int) time.Duration {
+	if row, ok := t.cells[project]; ok {
+		return row[day]
+	}
+	return 0
+}
+
+func (t Timesheet) ProjectTotal(project string) time.Duration {
+	var total time.Duration
+	for day := 0; day < 7; day++ {
+		total += t.Cell(project, day)
+	}
+	return total
+}
+
+func (t Timesheet) DayTotal(day int) time.Duration {
+	var total time.Duration
+	for _, p := range t.Projects {
+		total += t.Cell(p, day)
+	}
+	return total
+}
+
+func (t Timesheet) Total() time.Duration {
+	var total time.Duration
+	for _, p := range t.Projects {
+		total += t.ProjectTotal(p)
+	}
+	return total
+}
+
+// Title names the week, e.g. "Week of 2024-09-02".
+func (t Timesheet) Title() string {
+	return "Week of " + t.Start.Format("2006-01-02")
+}
+
+func formatHours(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	d = d.Round(time.Minute)
+	return fmt.Sprintf("%d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// Render produces the timesheet as "markdown", "html" or "text".
+func Render(t Timesheet, format string) (string, error) {
+	switch format {
+	case "markdown", "md":
+		return Markdown(t), nil
+	case "html":
+		return HTML(t), nil
+	case "text", "txt":
+		return Text(t), nil
+	}
+	return "", fmt.Errorf("unknown timesheet format %q: use markdown, html or text", format)
+}
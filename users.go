@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"os/user"
+
+	"github.com/Proqpine/probable-memory/config"
+	"github.com/Proqpine/probable-memory/store"
+)
+
+type userKey struct{}
+
+// withUser tags ctx with the id of the user whose activities are read and
+// recorded under it.
+func withUser(ctx context.Context, id int64) context.Context {
+	return context.WithValue(ctx, userKey{}, id)
+}
+
+func userFrom(ctx context.Context) int64 {
+	id, _ := ctx.Value(userKey{}).(int64)
+	return id
+}
+
+// ownerFrom is the owner to record on activities added under ctx.
+func ownerFrom(ctx context.Context) sql.NullInt64 {
+	id := userFrom(ctx)
+	return sql.NullInt64{Int64: id, Valid: id != 0}
+}
+
+// userName is the name from the config, or else the operating system's.
+func userName(cfg config.Config) (string, error) {
+	if cfg.User != "" {
+		return cfg.User, nil
+	}
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username, nil
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name, nil
+	}
+	return "", errors.New(`no user name: set "user" in the config`)
+}
+
+// lookupUser returns ctx tagged with the configured user without adding
+// them or claiming activities, for commands that only read. A user who is
+// not there yet has nothing to read.
+func lookupUser(ctx context.Context, q store.ActivityStore, cfg config.Config) (context.Context, error) {
+	name, err := userName(cfg)
+	if err != nil {
+		return nil, err
+	}
+	u, err := q.GetUser(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ctx, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return withUser(ctx, u.ID), nil
+}
+
+// currentUser returns ctx tagged with the configured user, adding them if
+// they are new. Activities recorded before there were users are given to
+// whoever runs the program first.
+func currentUser(ctx context.Context, q store.ActivityStore, cfg config.Config) (context.Context, error) {
+	name, err := userName(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var id int64
+	err = q.InTx(ctx, func(tx store.ActivityStore) error {
+		u, err := tx.UpsertUser(ctx, name)
+		if err != nil {
+			return err
+		}
+		id = u.ID
+		return tx.ClaimActivities(ctx, u.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return withUser(ctx, id), nil
+}